@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"blog/internal/config"
+	"blog/internal/database"
+	"blog/internal/server"
+	"blog/pkg/geoip"
+	"blog/pkg/retention"
+	"blog/pkg/tracking"
+	"blog/pkg/users"
+)
+
+// runCommand 执行命令行子命令，用于运维操作
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "create-admin":
+		return createAdmin(cfg, args[1:])
+	case "migrate":
+		return migrate(cfg, args[1:])
+	case "config":
+		return configCommand(cfg, args[1:])
+	case "bench-tracking":
+		return benchTracking(cfg, args[1:])
+	case "retention":
+		return retentionCommand(cfg, args[1:])
+	case "geoip":
+		return geoipCommand(cfg, args[1:])
+	case "forget":
+		return forgetCommand(cfg, args[1:])
+	default:
+		return fmt.Errorf("未知命令: %s", args[0])
+	}
+}
+
+// createAdmin 创建管理员账号，账号已存在时重置其密码并解除禁用
+func createAdmin(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	username := fs.String("username", cfg.Auth.AdminUser, "管理员用户名")
+	password := fs.String("password", cfg.Auth.AdminPassword, "管理员密码（默认读取 ADMIN_PASSWORD）")
+	fs.Parse(args)
+
+	if *password == "" {
+		return fmt.Errorf("请通过 -password 或环境变量 ADMIN_PASSWORD 提供密码")
+	}
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		return err
+	}
+
+	user, err := users.NewUserService(db).SetAdminPassword(*username, *password)
+	if err != nil {
+		return err
+	}
+	log.Printf("管理员账号已就绪: id=%d, username=%s", user.ID, user.Username)
+	return nil
+}
+
+// migrate 管理数据库迁移: migrate [up | down -steps N | status]，不带参数时等同于 up
+func migrate(cfg *config.Config, args []string) error {
+	action := "up"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("migrate "+action, flag.ExitOnError)
+	steps := fs.Int("steps", 1, "回滚的迁移数量（仅 down）")
+	fs.Parse(args)
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("迁移完成，本次执行了 %d 个迁移", applied)
+		return nil
+	case "down":
+		if *steps <= 0 {
+			return fmt.Errorf("-steps 必须大于 0")
+		}
+		reverted, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("回滚完成，本次回滚了 %d 个迁移", reverted)
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "版本\t名称\t状态\t执行时间")
+		for _, s := range statuses {
+			state, appliedAt := "未执行", ""
+			if s.Applied {
+				state = "已执行"
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				state = "已执行（脚本已被修改）"
+			}
+			if s.Unknown {
+				state = "已执行（当前程序未包含）"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("未知的迁移操作: %s（可选 up、down、status）", action)
+	}
+}
+
+// configCommand 查看配置: config print 输出每个配置项的最终取值和来源，密钥已脱敏
+func configCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("用法: config print")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "配置项\t环境变量\t取值\t来源")
+	for _, s := range cfg.Settings() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Key, s.Env, s.Value, s.Source)
+	}
+	return w.Flush()
+}
+
+// benchTracking 埋点写入基准: 对比逐条预编译插入与 COPY 在不同批大小下的吞吐量
+func benchTracking(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("bench-tracking", flag.ExitOnError)
+	events := fs.Int("events", 20000, "每轮写入的事件数")
+	batches := fs.String("batches", "200,1000,5000", "逗号分隔的批大小")
+	fs.Parse(args)
+
+	if *events <= 0 {
+		return fmt.Errorf("-events 必须大于 0")
+	}
+	var batchSizes []int
+	for _, item := range strings.Split(*batches, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("-batches 中的批大小无效: %q", item)
+		}
+		batchSizes = append(batchSizes, n)
+	}
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("开始埋点写入基准测试: 每轮 %d 条事件，批大小 %v", *events, batchSizes)
+	results, err := tracking.Benchmark(ctx, db, *events, batchSizes)
+	if err != nil {
+		return err
+	}
+
+	// 以同批大小下 prepared 的吞吐量为基准计算提升倍数
+	baseline := make(map[int]float64)
+	for _, r := range results {
+		if r.Method == "prepared" {
+			baseline[r.BatchSize] = r.EventsPerSecond()
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "批大小\t写入方式\t事件数\t耗时\t事件/秒\t相对 prepared")
+	for _, r := range results {
+		speedup := "-"
+		if base := baseline[r.BatchSize]; base > 0 {
+			speedup = fmt.Sprintf("%.2fx", r.EventsPerSecond()/base)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%.0f\t%s\n",
+			r.BatchSize, r.Method, r.Events, r.Elapsed.Round(time.Millisecond), r.EventsPerSecond(), speedup)
+	}
+	return w.Flush()
+}
+
+// retentionCommand 按保留策略立即执行一次数据清理: retention [-dry-run]
+func retentionCommand(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("retention", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", cfg.Retention.DryRun, "只统计将被清理的数据，不实际修改")
+	fs.Parse(args)
+
+	opts := server.RetentionOptions(cfg.Retention)
+	opts.Interval = 0 // 只执行一次，不启动定时任务
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rs := retention.NewRetentionService(db, opts)
+	if len(rs.Policies()) == 0 {
+		log.Printf("未配置任何数据保留策略（RETENTION_*_DAYS 均为 0），无需清理")
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	run, runErr := rs.Run(ctx, *dryRun)
+	if run == nil {
+		return runErr
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	rowsHeader := "清理行数"
+	if run.DryRun {
+		rowsHeader = "将清理行数"
+	}
+	fmt.Fprintf(w, "策略\t动作\t截止时间\t%s\t批次\t整体删除的分区\t错误\n", rowsHeader)
+	for _, r := range run.Results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			r.Policy, r.Action, r.Cutoff, r.Rows, r.Batches, strings.Join(r.Partitions, ","), r.Error)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return runErr
+}
+
+// geoipCommand 用配置的 GeoIP 数据库查询 IP 地址的地理位置，用于确认数据库可用: geoip [-database 文件] IP...
+func geoipCommand(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("geoip", flag.ExitOnError)
+	path := fs.String("database", cfg.Tracking.GeoIPDatabase, "MMDB 文件路径（默认读取 TRACKING_GEOIP_DATABASE）")
+	fs.Parse(args)
+
+	if *path == "" {
+		return fmt.Errorf("请通过 -database 或环境变量 TRACKING_GEOIP_DATABASE 指定 GeoIP 数据库")
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("用法: geoip [-database 文件] IP...")
+	}
+
+	db, err := geoip.Open(*path, cfg.Tracking.GeoIPLanguage)
+	if err != nil {
+		return err
+	}
+	meta := db.Metadata()
+	fmt.Printf("数据库: %s（IPv%d，构建于 %s）\n\n", meta.DatabaseType, meta.IPVersion,
+		time.Unix(int64(meta.BuildEpoch), 0).Format("2006-01-02"))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IP\t国家\t地区\t城市")
+	for _, ip := range fs.Args() {
+		loc, err := db.Lookup(ip)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t%v\t\t\n", ip, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ip, orDash(loc.Country), orDash(loc.Region), orDash(loc.City))
+	}
+	return w.Flush()
+}
+
+// forgetCommand 由管理员删除设备指纹关联的埋点事件和评论: forget 设备指纹...
+// 用于没有设备密钥的旧版设备指纹，管理员应先通过其他方式确认请求者确实是该设备的使用者
+func forgetCommand(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("forget", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("用法: forget 设备指纹...")
+	}
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "设备指纹\t埋点事件\t评论\t回执")
+	for _, deviceID := range fs.Args() {
+		deviceID = strings.TrimSpace(deviceID)
+		if deviceID == "" || strings.HasPrefix(deviceID, "auto_") {
+			return fmt.Errorf("无效的设备指纹: %q", deviceID)
+		}
+		receipt, err := tracking.Forget(db, deviceID, "")
+		if err != nil {
+			w.Flush()
+			return fmt.Errorf("删除设备 %s 的数据失败: %w", deviceID, err)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", deviceID, receipt.TrackEventsDeleted, receipt.CommentsDeleted, orDash(receipt.ID))
+	}
+	return w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -1,14 +1,25 @@
 package config
 
 import (
-	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config 应用配置
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
+	Database  DatabaseConfig
+	Server    ServerConfig
+	Tracking  TrackingConfig
+	Retention RetentionConfig
+	Auth      AuthConfig
+	OIDC      OIDCConfig
+
+	// 每个配置项的最终取值和来源，密钥已脱敏
+	settings []Setting
 }
 
 // DatabaseConfig 数据库配置
@@ -18,44 +29,294 @@ type DatabaseConfig struct {
 	User     string
 	Password string
 	DBName   string
+
+	// 启动时自动执行数据库迁移，关闭后需手动执行 ./blog migrate up
+	AutoMigrate bool
+
+	// 连接池配置
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port string
+	Port          string
+	StaticDir     string   // 登录页、管理后台等静态资源目录
+	UploadMaxSize int64    // 单个上传文件的最大字节数
+	CORSOrigins   []string // 允许跨域访问的来源，"*" 表示任意来源
+
+	// 可信的反向代理地址段（CIDR 或单个 IP），只有来自这些地址的请求才读取 X-Forwarded-For 和 X-Real-IP 确定客户端 IP
+	TrustedProxies []string
+
+	// 收到退出信号后等待处理中的请求和埋点写入完成的最长时间
+	ShutdownTimeout time.Duration
+}
+
+// TrackingConfig 埋点事件批量写入配置
+type TrackingConfig struct {
+	BatchSize     int           // 缓冲区达到该数量时立即写入
+	FlushInterval time.Duration // 定时写入间隔
+	QueueSize     int           // 待处理事件队列容量，队列满时改为同步写入
+
+	// 数据库不可用时将写入失败的事件暂存到本地磁盘，恢复后自动重放
+	SpoolEnabled bool
+	SpoolDir     string
+	SpoolMaxSize int64
+
+	// 按月分区：预先创建的月份数
+	PartitionPremake int
+
+	// 统计日汇总任务的执行间隔
+	RollupInterval time.Duration
+
+	// 离线 GeoIP 数据库（MMDB 文件），为空时不补充地理位置；地名优先使用 GeoIPLanguage
+	GeoIPDatabase string
+	GeoIPLanguage string
+
+	// 除内置的爬虫地址段外，额外视为机器人流量的地址段（CIDR 或单个 IP）
+	BotIPRanges []string
+
+	// 无 Cookie 模式：访客标识由每日轮换的盐、站点、IP 和 User-Agent 哈希得到，不保存 IP 地址
+	Cookieless bool
+
+	// 请求带有 DNT: 1、Sec-GPC: 1 时的处理策略（ignore/anonymous/drop）
+	DNTPolicy string
+	GPCPolicy string
+}
+
+// RetentionConfig 数据保留策略，天数为 0 表示永久保留
+type RetentionConfig struct {
+	TrackEventDays          int // 埋点事件保留天数
+	TrackEventIPDays        int // 埋点事件中的 IP 地址保留天数，到期后置空
+	TrackEventUserAgentDays int // 埋点事件中的用户代理保留天数，到期后置空
+	CommentIPDays           int // 评论中的 IP 地址保留天数，到期后置空
+	CommentUserAgentDays    int // 评论中的用户代理保留天数，到期后置空
+
+	BatchSize int           // 每批删除或更新的行数，避免长时间持有锁
+	Interval  time.Duration // 清理任务的执行间隔
+	DryRun    bool          // 只统计将被清理的数据，不实际修改
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	// 初始管理员账号，仅在用户表为空时用于创建第一个账号
+	AdminUser     string
+	AdminPassword string
+
+	// JWT 签名密钥，留空时自动生成并保存在数据库中，可通过管理接口轮换
+	JWTSecret string
+	JWTKeyID  string
+
+	// 登录防暴力破解：单个 IP 在窗口内允许的失败次数，以及账号锁定阈值和时长
+	LoginIPMaxFailures    int
+	LoginLockoutThreshold int
+	LoginLockoutDuration  time.Duration
+
+	// 认证 Cookie 的 SameSite 属性（lax/strict/none）以及是否仅通过 HTTPS 发送
+	CookieSameSite http.SameSite
+	CookieSecure   bool
 }
 
-// LoadConfig 从环境变量加载配置
+// OIDCConfig OpenID Connect 单点登录配置，Issuer 为空时不启用
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	ProviderName string // 登录页按钮上显示的名称
+
+	// 用户组到角色的映射，按管理员、编辑、分析师的顺序匹配
+	GroupsClaim    string
+	AdminGroups    []string
+	EditorGroups   []string
+	AnalystGroups  []string
+	DefaultRole    string // 未匹配任何用户组时的角色，留空则拒绝登录
+	AllowedDomains []string
+}
+
+// Enabled 是否启用单点登录
+func (o OIDCConfig) Enabled() bool {
+	return o.Issuer != ""
+}
+
+// LoadConfig 加载配置：先取默认值，再读取 CONFIG_FILE 指定的配置文件，最后由环境变量覆盖
+// 全部配置项校验完成后一次性返回所有错误
 func LoadConfig() (*Config, error) {
+	l, err := newLoader(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
 		Database: DatabaseConfig{
-			Host:   getEnv("DB_HOST", "db"),
-			Port:   getEnv("DB_PORT", "5432"),
-			User:   getEnv("DB_USER", "postgres"),
-			DBName: getEnv("POSTGRES_DB", "blog_db"),
+			Host:            l.getString("database.host", "DB_HOST", "db"),
+			Port:            l.getString("database.port", "DB_PORT", "5432"),
+			User:            l.getString("database.user", "DB_USER", "postgres"),
+			DBName:          l.getString("database.name", "POSTGRES_DB", "blog_db"),
+			Password:        l.getSecret("database.password", "DB_PASSWORD", ""), // 必须显式配置，不提供默认值
+			AutoMigrate:     l.getBool("database.auto_migrate", "DB_AUTO_MIGRATE", true),
+			MaxOpenConns:    l.getInt("database.max_open_conns", "DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:    l.getInt("database.max_idle_conns", "DB_MAX_IDLE_CONNS", 5),
+			ConnMaxLifetime: l.getDuration("database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", 5*time.Minute),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "3000"),
+			Port:            l.getString("server.port", "SERVER_PORT", "3000"),
+			StaticDir:       l.getString("server.static_dir", "STATIC_DIR", "/app/static"),
+			UploadMaxSize:   l.getSize("server.upload_max_size", "UPLOAD_MAX_SIZE", 10<<20),
+			CORSOrigins:     l.getList("server.cors_origins", "CORS_ORIGINS", "*"),
+			TrustedProxies:  l.getList("server.trusted_proxies", "TRUSTED_PROXIES", "127.0.0.1,::1,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16"),
+			ShutdownTimeout: l.getDuration("server.shutdown_timeout", "SHUTDOWN_TIMEOUT", 30*time.Second),
+		},
+		Tracking: TrackingConfig{
+			BatchSize:     l.getInt("tracking.batch_size", "TRACKING_BATCH_SIZE", 200),
+			FlushInterval: l.getDuration("tracking.flush_interval", "TRACKING_FLUSH_INTERVAL", 10*time.Second),
+			QueueSize:     l.getInt("tracking.queue_size", "TRACKING_QUEUE_SIZE", 50000),
+			SpoolEnabled:  l.getBool("tracking.spool_enabled", "TRACKING_SPOOL_ENABLED", true),
+			SpoolDir:      l.getString("tracking.spool_dir", "TRACKING_SPOOL_DIR", "/app/spool"),
+			SpoolMaxSize:  l.getSize("tracking.spool_max_size", "TRACKING_SPOOL_MAX_SIZE", 256<<20),
+
+			PartitionPremake: l.getInt("tracking.partition_premake", "TRACKING_PARTITION_PREMAKE", 3),
+			RollupInterval:   l.getDuration("tracking.rollup_interval", "TRACKING_ROLLUP_INTERVAL", 15*time.Minute),
+
+			GeoIPDatabase: l.getString("tracking.geoip_database", "TRACKING_GEOIP_DATABASE", ""),
+			GeoIPLanguage: l.getString("tracking.geoip_language", "TRACKING_GEOIP_LANGUAGE", "zh-CN"),
+			BotIPRanges:   l.getList("tracking.bot_ip_ranges", "TRACKING_BOT_IP_RANGES", ""),
+			Cookieless:    l.getBool("tracking.cookieless", "TRACKING_COOKIELESS", false),
+			DNTPolicy:     l.getString("tracking.dnt_policy", "TRACKING_DNT_POLICY", "anonymous"),
+			GPCPolicy:     l.getString("tracking.gpc_policy", "TRACKING_GPC_POLICY", "anonymous"),
+		},
+		Retention: RetentionConfig{
+			TrackEventDays:          l.getNonNegativeInt("retention.track_event_days", "RETENTION_TRACK_EVENT_DAYS", 0),
+			TrackEventIPDays:        l.getNonNegativeInt("retention.track_event_ip_days", "RETENTION_TRACK_EVENT_IP_DAYS", 0),
+			TrackEventUserAgentDays: l.getNonNegativeInt("retention.track_event_user_agent_days", "RETENTION_TRACK_EVENT_USER_AGENT_DAYS", 0),
+			CommentIPDays:           l.getNonNegativeInt("retention.comment_ip_days", "RETENTION_COMMENT_IP_DAYS", 0),
+			CommentUserAgentDays:    l.getNonNegativeInt("retention.comment_user_agent_days", "RETENTION_COMMENT_USER_AGENT_DAYS", 0),
+			BatchSize:               l.getInt("retention.batch_size", "RETENTION_BATCH_SIZE", 5000),
+			Interval:                l.getDuration("retention.interval", "RETENTION_INTERVAL", time.Hour),
+			DryRun:                  l.getBool("retention.dry_run", "RETENTION_DRY_RUN", false),
+		},
+		Auth: AuthConfig{
+			AdminUser:             l.getString("auth.admin_username", "ADMIN_USERNAME", "admin"),
+			AdminPassword:         l.getSecret("auth.admin_password", "ADMIN_PASSWORD", ""),
+			JWTSecret:             l.getSecret("auth.jwt_secret", "JWT_SECRET", ""),
+			JWTKeyID:              l.getString("auth.jwt_key_id", "JWT_KEY_ID", "config"),
+			LoginIPMaxFailures:    l.getInt("auth.login_ip_max_failures", "LOGIN_IP_MAX_FAILURES", 30),
+			LoginLockoutThreshold: l.getInt("auth.login_lockout_threshold", "LOGIN_LOCKOUT_THRESHOLD", 10),
+			LoginLockoutDuration:  l.getDuration("auth.login_lockout_duration", "LOGIN_LOCKOUT_DURATION", 30*time.Minute),
+			CookieSameSite:        l.getSameSite("auth.cookie_samesite", "COOKIE_SAMESITE", "lax"),
+			CookieSecure:          l.getBool("auth.cookie_secure", "COOKIE_SECURE", false),
+		},
+		OIDC: OIDCConfig{
+			Issuer:         l.getString("oidc.issuer", "OIDC_ISSUER", ""),
+			ClientID:       l.getString("oidc.client_id", "OIDC_CLIENT_ID", ""),
+			ClientSecret:   l.getSecret("oidc.client_secret", "OIDC_CLIENT_SECRET", ""),
+			RedirectURL:    l.getString("oidc.redirect_url", "OIDC_REDIRECT_URL", ""),
+			Scopes:         l.getList("oidc.scopes", "OIDC_SCOPES", "openid,email,profile"),
+			ProviderName:   l.getString("oidc.provider_name", "OIDC_PROVIDER_NAME", "单点登录"),
+			GroupsClaim:    l.getString("oidc.groups_claim", "OIDC_GROUPS_CLAIM", "groups"),
+			AdminGroups:    l.getList("oidc.admin_groups", "OIDC_ADMIN_GROUPS", ""),
+			EditorGroups:   l.getList("oidc.editor_groups", "OIDC_EDITOR_GROUPS", ""),
+			AnalystGroups:  l.getList("oidc.analyst_groups", "OIDC_ANALYST_GROUPS", ""),
+			DefaultRole:    l.getString("oidc.default_role", "OIDC_DEFAULT_ROLE", ""),
+			AllowedDomains: l.getList("oidc.allowed_domains", "OIDC_ALLOWED_DOMAINS", ""),
 		},
 	}
 
-	// 密码必须从环境变量获取，不提供默认值
-	cfg.Database.Password = os.Getenv("DB_PASSWORD")
+	cfg.validate(l)
+	if err := l.finish(); err != nil {
+		return nil, err
+	}
+	cfg.settings = l.settings
+	return cfg, nil
+}
+
+// validate 校验配置项之间的约束，错误统一记录到 loader 中
+func (cfg *Config) validate(l *loader) {
 	if cfg.Database.Password == "" {
-		cfg.Database.Password = os.Getenv("POSTGRES_PASSWORD")
-		if cfg.Database.Password == "" {
-			return nil, fmt.Errorf("数据库密码未设置: 请在环境变量中设置 DB_PASSWORD 或 POSTGRES_PASSWORD")
+		l.fail("数据库密码未设置: 请设置环境变量 DB_PASSWORD 或 POSTGRES_PASSWORD，或在配置文件中设置 database.password")
+	}
+	for _, p := range []struct{ name, port string }{
+		{"DB_PORT", cfg.Database.Port},
+		{"SERVER_PORT", cfg.Server.Port},
+	} {
+		if n, err := strconv.Atoi(p.port); err != nil || n <= 0 || n > 65535 {
+			l.fail("%s 必须是 1-65535 之间的端口号: %q", p.name, p.port)
+		}
+	}
+	if cfg.Database.MaxIdleConns > cfg.Database.MaxOpenConns {
+		l.fail("DB_MAX_IDLE_CONNS (%d) 不能大于 DB_MAX_OPEN_CONNS (%d)", cfg.Database.MaxIdleConns, cfg.Database.MaxOpenConns)
+	}
+	if cfg.Server.StaticDir == "" {
+		l.fail("STATIC_DIR 不能为空")
+	}
+	for _, origin := range cfg.Server.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			l.fail("CORS_ORIGINS 中的来源必须是 * 或 http(s)://host[:port] 形式: %q", origin)
+		}
+	}
+	for _, proxy := range cfg.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			l.fail("TRUSTED_PROXIES 中的地址必须是 CIDR 或 IP: %q", proxy)
+		}
+	}
+	if cfg.Tracking.SpoolEnabled && cfg.Tracking.SpoolMaxSize < 1<<20 {
+		l.fail("TRACKING_SPOOL_MAX_SIZE 不能小于 1MB: %d", cfg.Tracking.SpoolMaxSize)
+	}
+	if cfg.Tracking.BatchSize > cfg.Tracking.QueueSize {
+		l.fail("TRACKING_BATCH_SIZE (%d) 不能大于 TRACKING_QUEUE_SIZE (%d)", cfg.Tracking.BatchSize, cfg.Tracking.QueueSize)
+	}
+	for _, p := range []struct{ env, policy string }{
+		{"TRACKING_DNT_POLICY", cfg.Tracking.DNTPolicy},
+		{"TRACKING_GPC_POLICY", cfg.Tracking.GPCPolicy},
+	} {
+		switch p.policy {
+		case "ignore", "anonymous", "drop":
+		default:
+			l.fail("%s 只能是 ignore、anonymous 或 drop: %q", p.env, p.policy)
 		}
 	}
 
-	return cfg, nil
+	if cfg.Auth.CookieSameSite == http.SameSiteNoneMode && !cfg.Auth.CookieSecure {
+		l.fail("COOKIE_SAMESITE=none 时必须设置 COOKIE_SECURE=true")
+	}
+	if cfg.Auth.JWTSecret != "" && len(cfg.Auth.JWTSecret) < 32 {
+		l.fail("JWT_SECRET 长度不能少于 32 个字符")
+	}
+
+	if cfg.OIDC.Enabled() {
+		if cfg.OIDC.ClientID == "" || cfg.OIDC.RedirectURL == "" {
+			l.fail("启用 OIDC 时必须设置 OIDC_CLIENT_ID 和 OIDC_REDIRECT_URL")
+		}
+		switch cfg.OIDC.DefaultRole {
+		case "", "admin", "editor", "analyst":
+		default:
+			l.fail("OIDC_DEFAULT_ROLE 只能是 admin、editor 或 analyst: %q", cfg.OIDC.DefaultRole)
+		}
+	}
 }
 
-// getEnv 获取环境变量，如果不存在则返回默认值
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// getSameSite 获取 Cookie SameSite 配置（lax/strict/none）
+func (l *loader) getSameSite(key, env, defaultValue string) http.SameSite {
+	switch value := l.getString(key, env, defaultValue); value {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		l.fail("COOKIE_SAMESITE 只能是 lax、strict 或 none: %q", value)
+		return http.SameSiteLaxMode
 	}
-	return value
+}
+
+// Settings 返回每个配置项的最终取值和来源，密钥类配置已脱敏
+func (cfg *Config) Settings() []Setting {
+	return cfg.settings
 }
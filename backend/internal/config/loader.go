@@ -0,0 +1,341 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置项的取值来源
+const (
+	SourceDefault = "默认值"
+	SourceFile    = "配置文件"
+	SourceEnv     = "环境变量"
+)
+
+// Setting 一项配置的最终取值，用于 config print 输出
+type Setting struct {
+	Key    string // 配置文件中的路径，如 database.host
+	Env    string // 对应的环境变量名
+	Value  string // 密钥类配置已脱敏
+	Source string
+}
+
+// envAliases 兼容旧部署的环境变量别名，主变量未设置时读取别名
+var envAliases = map[string]string{
+	"DB_PASSWORD": "POSTGRES_PASSWORD",
+}
+
+// loader 按 默认值 -> 配置文件 -> 环境变量 的顺序解析配置项，并收集全部校验错误
+type loader struct {
+	filename string
+	file     map[string]string // 配置文件展开后的键值，如 "database.host" -> "db"
+	used     map[string]bool
+	settings []Setting
+	errs     []error
+}
+
+// newLoader 读取配置文件（.yaml/.yml/.toml），filename 为空时只使用默认值和环境变量
+func newLoader(filename string) (*loader, error) {
+	l := &loader{
+		filename: filename,
+		file:     make(map[string]string),
+		used:     make(map[string]bool),
+	}
+	if filename == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	var raw map[string]any
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("不支持的配置文件格式: %s（仅支持 .yaml、.yml、.toml）", filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %w", filename, err)
+	}
+
+	if err := flatten("", raw, l.file); err != nil {
+		return nil, fmt.Errorf("配置文件 %s 格式错误: %w", filename, err)
+	}
+	return l, nil
+}
+
+// flatten 将嵌套的配置展开为以点分隔的键，列表以逗号拼接，与环境变量的格式保持一致
+func flatten(prefix string, value any, out map[string]string) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			if err := flatten(key, child, out); err != nil {
+				return err
+			}
+		}
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				return fmt.Errorf("%s 只能是字符串列表", prefix)
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		out[prefix] = strings.Join(items, ",")
+	case nil:
+		out[prefix] = ""
+	default:
+		if prefix == "" {
+			return fmt.Errorf("顶层必须是键值对")
+		}
+		out[prefix] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+// lookup 返回配置项的原始值和来源，环境变量优先于配置文件。
+// 设置为空的环境变量同样覆盖配置文件：字符串和列表取空值（如 OIDC_ISSUER= 关闭单点登录），
+// 数字、布尔、时长和大小取默认值；密钥类配置例外，见 getSecret
+func (l *loader) lookup(key, env string) (string, string, bool) {
+	l.used[key] = true
+	if value, ok := lookupEnv(env); ok {
+		return value, SourceEnv, true
+	}
+	return l.lookupFile(key)
+}
+
+// lookupEnv 读取环境变量，主变量未设置时读取别名
+func lookupEnv(env string) (string, bool) {
+	if env == "" {
+		return "", false
+	}
+	if value, ok := os.LookupEnv(env); ok {
+		return value, true
+	}
+	if alias, ok := envAliases[env]; ok {
+		return os.LookupEnv(alias)
+	}
+	return "", false
+}
+
+// lookupFile 读取配置文件中的值，空值视为未配置
+func (l *loader) lookupFile(key string) (string, string, bool) {
+	if value, ok := l.file[key]; ok && value != "" {
+		return value, SourceFile, true
+	}
+	return "", SourceDefault, false
+}
+
+// name 返回便于定位问题的配置项名称
+func (l *loader) name(key, env, source string) string {
+	switch source {
+	case SourceEnv:
+		return "环境变量 " + env
+	case SourceFile:
+		return fmt.Sprintf("配置文件 %s 中的 %s", l.filename, key)
+	default:
+		return key
+	}
+}
+
+func (l *loader) record(key, env, value, source string, secret bool) {
+	if secret && value != "" {
+		value = "******"
+	}
+	l.settings = append(l.settings, Setting{Key: key, Env: env, Value: value, Source: source})
+}
+
+func (l *loader) fail(format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf(format, args...))
+}
+
+// getString 获取字符串配置
+func (l *loader) getString(key, env, defaultValue string) string {
+	value, source, ok := l.lookup(key, env)
+	if !ok {
+		value = defaultValue
+	}
+	l.record(key, env, value, source, false)
+	return value
+}
+
+// getSecret 获取密钥类配置，config print 时脱敏显示。
+// 留空的环境变量不覆盖配置文件，以免部署工具传入的空值替换掉配置文件中的密钥（如 JWT_SECRET 被清空后重新生成，所有会话失效）
+func (l *loader) getSecret(key, env, defaultValue string) string {
+	l.used[key] = true
+	value, ok := lookupEnv(env)
+	source := SourceEnv
+	if !ok || value == "" {
+		value, source, ok = l.lookupFile(key)
+	}
+	if !ok {
+		value = defaultValue
+	}
+	l.record(key, env, value, source, true)
+	return value
+}
+
+// getInt 获取正整数配置
+func (l *loader) getInt(key, env string, defaultValue int) int {
+	value, source, ok := l.lookup(key, env)
+	if !ok || value == "" {
+		l.record(key, env, strconv.Itoa(defaultValue), source, false)
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		l.fail("%s 必须是正整数: %q", l.name(key, env, source), value)
+		return defaultValue
+	}
+	l.record(key, env, strconv.Itoa(n), source, false)
+	return n
+}
+
+// getNonNegativeInt 获取非负整数配置，0 通常表示不限制
+func (l *loader) getNonNegativeInt(key, env string, defaultValue int) int {
+	value, source, ok := l.lookup(key, env)
+	if !ok || value == "" {
+		l.record(key, env, strconv.Itoa(defaultValue), source, false)
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		l.fail("%s 必须是非负整数: %q", l.name(key, env, source), value)
+		return defaultValue
+	}
+	l.record(key, env, strconv.Itoa(n), source, false)
+	return n
+}
+
+// getDuration 获取时长配置（如 30m、1h）
+func (l *loader) getDuration(key, env string, defaultValue time.Duration) time.Duration {
+	value, source, ok := l.lookup(key, env)
+	if !ok || value == "" {
+		l.record(key, env, defaultValue.String(), source, false)
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		l.fail("%s 必须是有效的时长（如 30m）: %q", l.name(key, env, source), value)
+		return defaultValue
+	}
+	l.record(key, env, d.String(), source, false)
+	return d
+}
+
+// getBool 获取布尔配置
+func (l *loader) getBool(key, env string, defaultValue bool) bool {
+	value, source, ok := l.lookup(key, env)
+	if !ok || value == "" {
+		l.record(key, env, strconv.FormatBool(defaultValue), source, false)
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		l.fail("%s 必须是 true 或 false: %q", l.name(key, env, source), value)
+		return defaultValue
+	}
+	l.record(key, env, strconv.FormatBool(b), source, false)
+	return b
+}
+
+// getSize 获取字节数配置，支持 KB、MB、GB 后缀（按 1024 换算）
+func (l *loader) getSize(key, env string, defaultValue int64) int64 {
+	value, source, ok := l.lookup(key, env)
+	if !ok || value == "" {
+		l.record(key, env, formatSize(defaultValue), source, false)
+		return defaultValue
+	}
+	n, err := parseSize(value)
+	if err != nil || n <= 0 {
+		l.fail("%s 必须是有效的大小（如 10MB）: %q", l.name(key, env, source), value)
+		return defaultValue
+	}
+	l.record(key, env, formatSize(n), source, false)
+	return n
+}
+
+// getList 获取逗号分隔的列表配置，忽略空白项；配置文件中也可以直接写成列表
+func (l *loader) getList(key, env, defaultValue string) []string {
+	value, source, ok := l.lookup(key, env)
+	if !ok {
+		value = defaultValue
+	}
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	l.record(key, env, strings.Join(result, ","), source, false)
+	return result
+}
+
+// finish 检查配置文件中是否有无法识别的配置项，并返回全部校验错误
+func (l *loader) finish() error {
+	var unknown []string
+	for key := range l.file {
+		if !l.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		l.fail("配置文件 %s 中有无法识别的配置项: %s", l.filename, strings.Join(unknown, ", "))
+	}
+	return errors.Join(l.errs...)
+}
+
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize 解析 10MB、512KB 或纯数字（字节）形式的大小
+func parseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			n, err := strconv.ParseInt(strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return n * unit.factor, nil
+		}
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// formatSize 将字节数格式化为能整除的最大单位
+func formatSize(n int64) string {
+	for _, unit := range sizeUnits {
+		if n >= unit.factor && n%unit.factor == 0 {
+			return strconv.FormatInt(n/unit.factor, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10) + "B"
+}
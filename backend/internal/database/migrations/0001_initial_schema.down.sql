@@ -0,0 +1,10 @@
+-- 回滚初始表结构，会删除全部业务数据，请先做好备份
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS api_tokens;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS auth_sessions;
+DROP TABLE IF EXISTS auth_signing_keys;
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS track_event;
@@ -0,0 +1,170 @@
+-- 初始表结构，整合了原先各服务 InitSchema 中的建表、补列和索引语句
+-- 所有语句均可重复执行，已有部署的数据库会被平滑接管
+
+-- 埋点事件
+-- 注意：不使用特定的 COLLATE "zh-Hans-CN-x-icu"，以确保在不同 PostgreSQL 环境（如 Alpine）下的兼容性
+CREATE TABLE IF NOT EXISTS track_event (
+	id SERIAL PRIMARY KEY,
+	session_id VARCHAR(100),
+	user_id VARCHAR(100),
+	event_type VARCHAR(50) NOT NULL,
+	element_path TEXT,
+	page_path TEXT,
+	referrer TEXT,
+	metadata JSONB DEFAULT '{}'::jsonb,
+	user_agent TEXT,
+	ip_address VARCHAR(50),
+	created_at TIMESTAMP NOT NULL,
+	custom_properties JSONB DEFAULT '{}'::jsonb,
+	platform VARCHAR(20),
+	device_info JSONB DEFAULT '{}'::jsonb,
+	event_duration INTEGER DEFAULT 0,
+	device_id VARCHAR(100),
+	version VARCHAR(20),
+	device_type VARCHAR(50)
+);
+ALTER TABLE track_event ADD COLUMN IF NOT EXISTS device_type VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_track_event_created_at ON track_event(created_at);
+CREATE INDEX IF NOT EXISTS idx_track_event_event_type ON track_event(event_type);
+CREATE INDEX IF NOT EXISTS idx_track_event_session_id ON track_event(session_id);
+CREATE INDEX IF NOT EXISTS idx_track_event_user_id ON track_event(user_id);
+CREATE INDEX IF NOT EXISTS idx_track_event_platform ON track_event(platform);
+CREATE INDEX IF NOT EXISTS idx_track_event_metadata ON track_event USING gin (metadata);
+CREATE INDEX IF NOT EXISTS idx_track_event_custom_properties ON track_event USING gin (custom_properties);
+
+-- 评论
+CREATE TABLE IF NOT EXISTS comments (
+	id SERIAL PRIMARY KEY,
+	article_id VARCHAR(100) NOT NULL,
+	nickname VARCHAR(100) NOT NULL,
+	email VARCHAR(100),
+	content TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	ip_address VARCHAR(50),
+	status VARCHAR(20) DEFAULT 'approved',
+	reply_to INTEGER DEFAULT NULL,
+	user_agent TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_article_id ON comments(article_id);
+CREATE INDEX IF NOT EXISTS idx_comments_created_at ON comments(created_at);
+CREATE INDEX IF NOT EXISTS idx_comments_status ON comments(status);
+CREATE INDEX IF NOT EXISTS idx_comments_reply_to ON comments(reply_to);
+
+-- 后台账号
+CREATE TABLE IF NOT EXISTS users (
+	id BIGSERIAL PRIMARY KEY,
+	username VARCHAR(64) NOT NULL UNIQUE,
+	display_name VARCHAR(100) NOT NULL DEFAULT '',
+	role VARCHAR(20) NOT NULL DEFAULT 'admin',
+	password_hash VARCHAR(255) NOT NULL,
+	disabled BOOLEAN NOT NULL DEFAULT FALSE,
+	totp_secret VARCHAR(64) NOT NULL DEFAULT '',
+	totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+	totp_last_step BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_login_at TIMESTAMP,
+	email VARCHAR(255) NOT NULL DEFAULT '',
+	oidc_subject VARCHAR(255)
+);
+-- 角色上线前的账号都是管理员，因此 role 默认值为 admin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'admin';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject VARCHAR(255);
+
+-- 每个单点登录身份只能绑定一个本地账号
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc_subject ON users(oidc_subject) WHERE oidc_subject IS NOT NULL;
+
+-- 两步验证恢复码，只保存哈希
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	code_hash VARCHAR(64) NOT NULL,
+	used_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+
+-- JWT 签名密钥
+CREATE TABLE IF NOT EXISTS auth_signing_keys (
+	kid VARCHAR(64) PRIMARY KEY,
+	secret TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	retired_at TIMESTAMP
+);
+
+-- 登录会话
+CREATE TABLE IF NOT EXISTS auth_sessions (
+	id VARCHAR(64) PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	refresh_hash VARCHAR(64) NOT NULL,
+	previous_refresh_hash VARCHAR(64),
+	rotated_at TIMESTAMP,
+	ip_address VARCHAR(50),
+	user_agent TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP,
+	csrf_hash VARCHAR(64)
+);
+ALTER TABLE auth_sessions ADD COLUMN IF NOT EXISTS csrf_hash VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_user_id ON auth_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_expires_at ON auth_sessions(expires_at);
+
+-- 登录安全日志
+CREATE TABLE IF NOT EXISTS login_attempts (
+	id BIGSERIAL PRIMARY KEY,
+	username VARCHAR(64) NOT NULL,
+	ip_address VARCHAR(50) NOT NULL,
+	user_agent TEXT,
+	success BOOLEAN NOT NULL,
+	reason VARCHAR(50) NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_username ON login_attempts(username, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts(ip_address, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_created_at ON login_attempts(created_at);
+
+-- 个人 API 令牌
+CREATE TABLE IF NOT EXISTS api_tokens (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(100) NOT NULL,
+	prefix VARCHAR(16) NOT NULL,
+	token_hash VARCHAR(64) NOT NULL UNIQUE,
+	scopes TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMP,
+	expires_at TIMESTAMP,
+	revoked_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
+
+-- 审计日志，操作者不使用外键，删除用户后仍保留其操作记录
+CREATE TABLE IF NOT EXISTS audit_log (
+	id BIGSERIAL PRIMARY KEY,
+	actor_id BIGINT,
+	actor VARCHAR(64) NOT NULL DEFAULT '',
+	auth_method VARCHAR(20) NOT NULL DEFAULT '',
+	action VARCHAR(100) NOT NULL,
+	target TEXT NOT NULL DEFAULT '',
+	before_hash VARCHAR(64) NOT NULL DEFAULT '',
+	after_hash VARCHAR(64) NOT NULL DEFAULT '',
+	details TEXT NOT NULL DEFAULT '',
+	status INTEGER NOT NULL DEFAULT 0,
+	ip_address VARCHAR(50),
+	user_agent TEXT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target text_pattern_ops);
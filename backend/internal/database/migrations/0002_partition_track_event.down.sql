@@ -0,0 +1,19 @@
+-- 将分区表还原为普通表。需要复制全部数据并在复制期间锁表，请在维护窗口执行
+
+LOCK TABLE track_event IN EXCLUSIVE MODE;
+
+CREATE TABLE track_event_flat (LIKE track_event INCLUDING DEFAULTS);
+INSERT INTO track_event_flat SELECT * FROM track_event;
+ALTER SEQUENCE track_event_id_seq OWNED BY track_event_flat.id;
+
+DROP TABLE track_event;
+ALTER TABLE track_event_flat RENAME TO track_event;
+ALTER TABLE track_event ADD PRIMARY KEY (id);
+
+CREATE INDEX idx_track_event_created_at ON track_event(created_at);
+CREATE INDEX idx_track_event_event_type ON track_event(event_type);
+CREATE INDEX idx_track_event_session_id ON track_event(session_id);
+CREATE INDEX idx_track_event_user_id ON track_event(user_id);
+CREATE INDEX idx_track_event_platform ON track_event(platform);
+CREATE INDEX idx_track_event_metadata ON track_event USING gin (metadata);
+CREATE INDEX idx_track_event_custom_properties ON track_event USING gin (custom_properties);
@@ -0,0 +1,55 @@
+-- 埋点事件表改为按 created_at 的月度范围分区
+--
+-- 迁移只修改元数据，不复制数据，在大表上也能很快完成：
+-- 原表改名为 track_event_default 并作为默认分区挂到新的分区表下，已有数据立即可查，
+-- 之后由埋点服务的分区维护任务按月把历史数据搬入各月分区（见 pkg/tracking/partition.go）
+--
+-- 分区表的主键必须包含分区键，事件表只追加写入且 id 由序列生成，因此分区表不再设置主键；
+-- id 保持 INTEGER，与原表一致，挂载时无需重写数据
+
+ALTER TABLE track_event RENAME TO track_event_default;
+ALTER INDEX track_event_pkey RENAME TO track_event_default_pkey;
+ALTER INDEX idx_track_event_created_at RENAME TO track_event_default_created_at_idx;
+ALTER INDEX idx_track_event_event_type RENAME TO track_event_default_event_type_idx;
+ALTER INDEX idx_track_event_session_id RENAME TO track_event_default_session_id_idx;
+ALTER INDEX idx_track_event_user_id RENAME TO track_event_default_user_id_idx;
+ALTER INDEX idx_track_event_platform RENAME TO track_event_default_platform_idx;
+ALTER INDEX idx_track_event_metadata RENAME TO track_event_default_metadata_idx;
+ALTER INDEX idx_track_event_custom_properties RENAME TO track_event_default_custom_properties_idx;
+
+CREATE TABLE track_event (
+	id INTEGER NOT NULL DEFAULT nextval('track_event_id_seq'),
+	session_id VARCHAR(100),
+	user_id VARCHAR(100),
+	event_type VARCHAR(50) NOT NULL,
+	element_path TEXT,
+	page_path TEXT,
+	referrer TEXT,
+	metadata JSONB DEFAULT '{}'::jsonb,
+	user_agent TEXT,
+	ip_address VARCHAR(50),
+	created_at TIMESTAMP NOT NULL,
+	custom_properties JSONB DEFAULT '{}'::jsonb,
+	platform VARCHAR(20),
+	device_info JSONB DEFAULT '{}'::jsonb,
+	event_duration INTEGER DEFAULT 0,
+	device_id VARCHAR(100),
+	version VARCHAR(20),
+	device_type VARCHAR(50)
+) PARTITION BY RANGE (created_at);
+
+-- 序列改为归属新表，删除默认分区时不会连带删除序列
+ALTER SEQUENCE track_event_id_seq OWNED BY track_event.id;
+ALTER TABLE track_event_default ALTER COLUMN id DROP DEFAULT;
+
+-- 分区表上的索引会自动创建到每个分区；挂载默认分区时复用上面改名后的同结构索引，不会重建
+CREATE INDEX idx_track_event_created_at ON track_event(created_at);
+CREATE INDEX idx_track_event_event_type ON track_event(event_type);
+CREATE INDEX idx_track_event_session_id ON track_event(session_id);
+CREATE INDEX idx_track_event_user_id ON track_event(user_id);
+CREATE INDEX idx_track_event_platform ON track_event(platform);
+CREATE INDEX idx_track_event_metadata ON track_event USING gin (metadata);
+CREATE INDEX idx_track_event_custom_properties ON track_event USING gin (custom_properties);
+
+-- 此时还没有其他分区，挂载默认分区不需要扫描校验数据
+ALTER TABLE track_event ATTACH PARTITION track_event_default DEFAULT;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS analytics_daily_dimension;
+DROP TABLE IF EXISTS analytics_daily_page;
+DROP TABLE IF EXISTS analytics_daily_site;
@@ -0,0 +1,32 @@
+-- 统计看板的日汇总表，由 AnalyticsService 的汇总任务按天写入（见 pkg/tracking/rollup.go）
+-- 日期按 created_at 的本地时间（Asia/Shanghai）划分；uv_sketch 是序列化的 HyperLogLog，可跨天合并计算 UV
+
+-- 全站每日汇总，每个已汇总的日期都有一行（没有事件的日期 pv 为 0），用于确定已汇总到哪一天
+CREATE TABLE IF NOT EXISTS analytics_daily_site (
+	day DATE PRIMARY KEY,
+	pv BIGINT NOT NULL DEFAULT 0,
+	events BIGINT NOT NULL DEFAULT 0,
+	uv_sketch BYTEA NOT NULL,
+	rolled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 每日 × 页面的访问量，只统计 PAGEVIEW 事件
+CREATE TABLE IF NOT EXISTS analytics_daily_page (
+	day DATE NOT NULL,
+	page_path TEXT NOT NULL,
+	pv BIGINT NOT NULL,
+	uv_sketch BYTEA NOT NULL,
+	PRIMARY KEY (day, page_path)
+);
+CREATE INDEX IF NOT EXISTS idx_analytics_daily_page_path ON analytics_daily_page(page_path);
+
+-- 每日 × 维度（设备类型、操作系统、浏览器）的事件数，统计全部事件
+CREATE TABLE IF NOT EXISTS analytics_daily_dimension (
+	day DATE NOT NULL,
+	dimension VARCHAR(20) NOT NULL,
+	value TEXT NOT NULL,
+	events BIGINT NOT NULL,
+	uv_sketch BYTEA NOT NULL,
+	PRIMARY KEY (day, dimension, value)
+);
+CREATE INDEX IF NOT EXISTS idx_analytics_daily_dimension ON analytics_daily_dimension(dimension, value);
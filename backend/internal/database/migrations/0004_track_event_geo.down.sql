@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_track_event_country_region;
+
+ALTER TABLE track_event DROP COLUMN IF EXISTS city;
+ALTER TABLE track_event DROP COLUMN IF EXISTS region;
+ALTER TABLE track_event DROP COLUMN IF EXISTS country;
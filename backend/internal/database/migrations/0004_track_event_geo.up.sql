@@ -0,0 +1,7 @@
+-- 埋点事件的地理位置，写入时根据 IP 地址查询离线 GeoIP 数据库得到（见 pkg/geoip），未配置数据库或查不到时为空
+-- 在分区表上添加的列和索引会同步到所有分区
+ALTER TABLE track_event ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+ALTER TABLE track_event ADD COLUMN IF NOT EXISTS region VARCHAR(100);
+ALTER TABLE track_event ADD COLUMN IF NOT EXISTS city VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_track_event_country_region ON track_event(country, region);
@@ -0,0 +1,20 @@
+-- 回滚后日汇总不再区分机器人流量，删除机器人部分的汇总，其余数据保持不变
+DELETE FROM analytics_daily_dimension WHERE is_bot;
+ALTER TABLE analytics_daily_dimension DROP CONSTRAINT IF EXISTS analytics_daily_dimension_pkey;
+ALTER TABLE analytics_daily_dimension DROP COLUMN IF EXISTS is_bot;
+ALTER TABLE analytics_daily_dimension ADD PRIMARY KEY (day, dimension, value);
+
+DELETE FROM analytics_daily_page WHERE is_bot;
+ALTER TABLE analytics_daily_page DROP CONSTRAINT IF EXISTS analytics_daily_page_pkey;
+ALTER TABLE analytics_daily_page DROP COLUMN IF EXISTS is_bot;
+ALTER TABLE analytics_daily_page ADD PRIMARY KEY (day, page_path);
+
+DELETE FROM analytics_daily_site WHERE is_bot;
+ALTER TABLE analytics_daily_site DROP CONSTRAINT IF EXISTS analytics_daily_site_pkey;
+ALTER TABLE analytics_daily_site DROP COLUMN IF EXISTS is_bot;
+ALTER TABLE analytics_daily_site ADD PRIMARY KEY (day);
+
+DROP INDEX IF EXISTS idx_track_event_bot_name;
+
+ALTER TABLE track_event DROP COLUMN IF EXISTS bot_name;
+ALTER TABLE track_event DROP COLUMN IF EXISTS is_bot;
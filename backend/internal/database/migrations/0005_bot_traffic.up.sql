@@ -0,0 +1,18 @@
+-- 机器人流量标记，写入时由 pkg/bots 根据 User-Agent、IP 地址段和无头浏览器特征识别
+ALTER TABLE track_event ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE track_event ADD COLUMN IF NOT EXISTS bot_name VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_track_event_bot_name ON track_event(bot_name) WHERE is_bot;
+
+-- 日汇总按是否为机器人流量分开保存，统计时可以选择是否包含机器人；已有的汇总数据视为非机器人流量
+ALTER TABLE analytics_daily_site ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE analytics_daily_site DROP CONSTRAINT IF EXISTS analytics_daily_site_pkey;
+ALTER TABLE analytics_daily_site ADD PRIMARY KEY (day, is_bot);
+
+ALTER TABLE analytics_daily_page ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE analytics_daily_page DROP CONSTRAINT IF EXISTS analytics_daily_page_pkey;
+ALTER TABLE analytics_daily_page ADD PRIMARY KEY (day, is_bot, page_path);
+
+ALTER TABLE analytics_daily_dimension ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE analytics_daily_dimension DROP CONSTRAINT IF EXISTS analytics_daily_dimension_pkey;
+ALTER TABLE analytics_daily_dimension ADD PRIMARY KEY (day, is_bot, dimension, value);
@@ -0,0 +1,7 @@
+-- 无 Cookie 模式下计算访客标识用的每日随机盐（见 pkg/tracking/visitor.go），day 为 Asia/Shanghai 的日期
+-- 每天只保留当天的盐，轮换后旧盐立即删除，此后无法再由 IP 和 User-Agent 还原出之前的访客标识
+CREATE TABLE IF NOT EXISTS tracking_visitor_salt (
+	day DATE PRIMARY KEY,
+	salt BYTEA NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS privacy_deletion_receipts;
+DROP TABLE IF EXISTS tracking_consent;
+
+DROP INDEX IF EXISTS idx_comments_device_id;
+ALTER TABLE comments DROP COLUMN IF EXISTS device_id;
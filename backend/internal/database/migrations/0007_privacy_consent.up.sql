@@ -0,0 +1,22 @@
+-- 访客隐私：同意状态、按设备删除个人数据及其删除回执（见 pkg/tracking/consent.go、forget.go）
+
+-- 评论关联发表者的设备指纹，访客请求删除个人数据时据此删除其评论；升级前的评论没有设备指纹
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS device_id VARCHAR(100);
+CREATE INDEX IF NOT EXISTS idx_comments_device_id ON comments(device_id);
+
+-- 每个设备指纹的埋点同意状态，没有记录的设备按默认策略处理
+CREATE TABLE IF NOT EXISTS tracking_consent (
+	device_id VARCHAR(100) PRIMARY KEY,
+	status VARCHAR(10) NOT NULL CHECK (status IN ('granted', 'denied')),
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 删除回执：只保存设备指纹的 SHA-256，访客凭原始指纹可以证明回执属于自己
+CREATE TABLE IF NOT EXISTS privacy_deletion_receipts (
+	id VARCHAR(32) PRIMARY KEY,
+	subject_hash VARCHAR(64) NOT NULL,
+	session_count INTEGER NOT NULL DEFAULT 0,
+	track_events_deleted BIGINT NOT NULL DEFAULT 0,
+	comments_deleted BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS sso_link_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS sso_link_email;
+ALTER TABLE users DROP COLUMN IF EXISTS sso_managed;
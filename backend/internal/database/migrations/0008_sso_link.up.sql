@@ -0,0 +1,10 @@
+-- 单点登录账号绑定（见 pkg/users/sso.go）
+-- sso_managed: 账号由单点登录创建，每次登录按用户组同步角色；管理员绑定的已有账号不同步角色
+-- sso_link_email / sso_link_expires_at: 管理员允许已有账号绑定的单点登录邮箱及有效期，绑定成功后清空
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sso_managed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sso_link_email VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sso_link_expires_at TIMESTAMP;
+
+-- 升级前已绑定的账号无法区分是单点登录创建的还是按同名邮箱自动绑定的，保持原有的角色同步行为；
+-- 管理员应检查这些账号，对按同名邮箱自动绑定的本地账号调用 DELETE /api/users/:id/sso 解除绑定
+UPDATE users SET sso_managed = TRUE WHERE oidc_subject IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE privacy_deletion_receipts ADD COLUMN IF NOT EXISTS session_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- 删除个人数据改为凭设备密钥按设备指纹删除（见 pkg/tracking/forget.go），不再接受单独提交的会话 ID
+ALTER TABLE privacy_deletion_receipts DROP COLUMN IF EXISTS session_count;
@@ -4,7 +4,8 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"time"
+	"net"
+	"net/url"
 
 	"blog/internal/config"
 
@@ -13,14 +14,7 @@ import (
 
 // NewPostgresDB 创建并配置 PostgreSQL 数据库连接
 func NewPostgresDB(cfg config.DatabaseConfig) (*sql.DB, error) {
-	// 构建连接字符串
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&timezone=Asia/Shanghai&client_encoding=UTF8",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DBName,
-	)
+	dbURL := buildDSN(cfg)
 
 	log.Printf("正在连接数据库: %s@%s:%s/%s", cfg.User, cfg.Host, cfg.Port, cfg.DBName)
 
@@ -31,9 +25,9 @@ func NewPostgresDB(cfg config.DatabaseConfig) (*sql.DB, error) {
 	}
 
 	// 配置连接池
-	db.SetMaxOpenConns(25)                 // 最大打开连接数
-	db.SetMaxIdleConns(5)                  // 最大空闲连接数
-	db.SetConnMaxLifetime(5 * time.Minute) // 连接最大生命周期
+	db.SetMaxOpenConns(cfg.MaxOpenConns)       // 最大打开连接数
+	db.SetMaxIdleConns(cfg.MaxIdleConns)       // 最大空闲连接数
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime) // 连接最大生命周期
 
 	// 验证数据库连接
 	if err := db.Ping(); err != nil {
@@ -42,7 +36,25 @@ func NewPostgresDB(cfg config.DatabaseConfig) (*sql.DB, error) {
 	}
 
 	log.Printf("数据库连接成功 - 连接池配置: 最大连接=%d, 空闲连接=%d, 生命周期=%v",
-		25, 5, 5*time.Minute)
+		cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.ConnMaxLifetime)
 
 	return db, nil
 }
+
+// buildDSN 构建连接字符串。客户端编码在连接参数中统一指定为 UTF8，
+// 每个新连接建立时即生效，业务代码无需再执行 SET client_encoding
+func buildDSN(cfg config.DatabaseConfig) string {
+	query := url.Values{}
+	query.Set("sslmode", "disable")
+	query.Set("timezone", "Asia/Shanghai")
+	query.Set("client_encoding", "UTF8")
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, cfg.Port),
+		Path:     "/" + cfg.DBName,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"log"
+	"time"
 
 	"blog/pkg/tracking"
 
@@ -20,9 +21,15 @@ func NewAnalyticsHandler(analyticsService *tracking.AnalyticsService) *Analytics
 	}
 }
 
-// GetFullStats 获取完整统计数据
+// GetFullStats 获取完整统计数据，查询参数见 tracking.ParseStatsQuery
 func (h *AnalyticsHandler) GetFullStats(c *gin.Context) {
-	stats, err := h.analyticsService.GetFullStats()
+	query, err := tracking.ParseStatsQuery(c.Request.URL.Query(), time.Now())
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.analyticsService.GetFullStats(query)
 	if err != nil {
 		log.Printf("获取统计数据失败: %v", err)
 		c.JSON(500, gin.H{"error": "获取统计数据失败"})
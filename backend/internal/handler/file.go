@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 
+	"blog/pkg/audit"
 	"blog/pkg/filemanager"
 
 	"github.com/gin-gonic/gin"
@@ -17,11 +18,13 @@ type FileRequest struct {
 }
 
 // FileHandler 文件管理处理器
-type FileHandler struct{}
+type FileHandler struct {
+	maxUploadSize int64 // 单个上传文件的最大字节数
+}
 
 // NewFileHandler 创建文件处理器
-func NewFileHandler() *FileHandler {
-	return &FileHandler{}
+func NewFileHandler(maxUploadSize int64) *FileHandler {
+	return &FileHandler{maxUploadSize: maxUploadSize}
 }
 
 // GetAllFiles 获取所有文件
@@ -66,12 +69,20 @@ func (h *FileHandler) SaveFile(c *gin.Context) {
 	// 检查是否需要重命名（删除旧文件）
 	if req.OriginalFilename != "" && req.OriginalFilename != req.Filename {
 		log.Printf("检测到重命名操作: %s -> %s", req.OriginalFilename, req.Filename)
+		before := fileHash(req.OriginalFilename)
 		if err := filemanager.DeleteFile(req.OriginalFilename); err != nil {
 			log.Printf("重命名时删除旧文件失败: %v", err)
+		} else {
+			audit.Record(c, audit.Entry{
+				Action:     audit.ActionFileRename,
+				Target:     req.OriginalFilename,
+				BeforeHash: before,
+				Details:    "重命名为 " + req.Filename,
+			})
 		}
 	}
 
-	if err := filemanager.SaveFile(req.Filename, req.Content); err != nil {
+	if err := saveFile(c, req.Filename, req.Content); err != nil {
 		c.JSON(500, gin.H{"error": "保存文件失败"})
 		return
 	}
@@ -94,11 +105,13 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 
 	}
 
+	before := fileHash(filename)
 	if err := filemanager.DeleteFile(filename); err != nil {
 		log.Printf("删除文件失败: %v", err)
 		c.JSON(500, gin.H{"error": fmt.Sprintf("删除失败: %v", err)})
 		return
 	}
+	audit.Record(c, audit.Entry{Action: audit.ActionFileDelete, Target: filename, BeforeHash: before})
 
 	// 更新侧边栏配置
 	if err := filemanager.UpdateSidebarConfig(); err != nil {
@@ -109,7 +122,14 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 }
 
 func (h *FileHandler) BuildSite(c *gin.Context) {
-	if err := filemanager.BuildSite(); err != nil {
+	err := filemanager.BuildSite()
+	entry := audit.Entry{Action: audit.ActionSiteBuild}
+	if err != nil {
+		entry.Details = err.Error()
+	}
+	audit.Record(c, entry)
+
+	if err != nil {
 		c.JSON(500, gin.H{"error": "构建失败"})
 		return
 	}
@@ -138,8 +158,8 @@ func (h *FileHandler) UploadFiles(c *gin.Context) {
 		}
 		defer src.Close()
 
-		// 限制文件大小 10MB
-		if file.Size > 10*1024*1024 {
+		// 限制单个文件大小
+		if file.Size > h.maxUploadSize {
 			failCount++
 			errorMsgs = append(errorMsgs, fmt.Sprintf("%s: 文件过大", file.Filename))
 			continue
@@ -153,7 +173,7 @@ func (h *FileHandler) UploadFiles(c *gin.Context) {
 			continue
 		}
 
-		if err := filemanager.SaveFile(file.Filename, string(contentBytes)); err != nil {
+		if err := saveFile(c, file.Filename, string(contentBytes)); err != nil {
 			failCount++
 			errorMsgs = append(errorMsgs, fmt.Sprintf("%s: 保存失败 - %v", file.Filename, err))
 		} else {
@@ -175,3 +195,33 @@ func (h *FileHandler) UploadFiles(c *gin.Context) {
 		"total":   len(files),
 	})
 }
+
+// saveFile 保存文件并提交包含前后内容哈希的审计事件
+func saveFile(c *gin.Context, filename, content string) error {
+	filename = filemanager.NormalizeFilename(filename)
+	before := fileHash(filename)
+	if err := filemanager.SaveFile(filename, content); err != nil {
+		return err
+	}
+
+	action := audit.ActionFileUpdate
+	if before == "" {
+		action = audit.ActionFileCreate
+	}
+	audit.Record(c, audit.Entry{
+		Action:     action,
+		Target:     filename,
+		BeforeHash: before,
+		AfterHash:  fileHash(filename),
+	})
+	return nil
+}
+
+// fileHash 返回文件当前内容的哈希，文件不存在时返回空字符串
+func fileHash(filename string) string {
+	content, err := filemanager.GetFileContent(filename)
+	if err != nil {
+		return ""
+	}
+	return audit.HashContent(content)
+}
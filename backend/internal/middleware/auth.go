@@ -1,27 +1,47 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"blog/pkg/apitokens"
+	"blog/pkg/keystore"
+	"blog/pkg/loginguard"
+	"blog/pkg/oidc"
+	"blog/pkg/sessions"
+	"blog/pkg/users"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// 默认管理员凭据 - 可通过环境变量修改
 const (
-	DefaultAdminUser     = "admin"
-	DefaultAdminPassword = "admin123"
-	TokenCookieName      = "mblog_token"
-	TokenExpiration      = 24 * time.Hour
-	// TODO: 生产环境应从环境变量加载 Secret
-	JWTSecret = "your-secret-key-should-be-complex"
+	TokenCookieName   = "mblog_token"
+	RefreshCookieName = "mblog_refresh"
+	// AccessTokenExpiration 访问令牌有效期，过期后由刷新令牌自动续期
+	AccessTokenExpiration = 15 * time.Minute
+	// MFATokenExpiration 密码校验通过后，提交两步验证码的时限
+	MFATokenExpiration = 5 * time.Minute
+	mfaTokenPurpose    = "mfa"
 )
 
-// Claims 自定义 JWT Claims
+// Claims 自定义 JWT Claims，ID (jti) 即会话ID
 type Claims struct {
+	UserID   int64  `json:"uid"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// mfaClaims 两步验证挑战令牌，仅证明密码已校验通过，不能用于访问受保护路由
+type mfaClaims struct {
+	UserID  int64  `json:"uid"`
+	Purpose string `json:"purpose"`
 	jwt.RegisteredClaims
 }
 
@@ -31,12 +51,58 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// GenerateToken 生成 JWT Token
-func GenerateToken(username string) (string, error) {
-	expirationTime := time.Now().Add(TokenExpiration)
+// TOTPLoginRequest 登录第二步请求体
+type TOTPLoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// Auth 认证处理器，负责登录、登出和路由保护
+type Auth struct {
+	userService *users.UserService
+	keys        *keystore.KeyStore
+	sessions    *sessions.SessionService
+	guard       *loginguard.Guard
+	tokens      *apitokens.TokenService
+	cookies     CookieConfig
+	oidc        *oidc.Provider // 未配置单点登录时为 nil
+}
+
+// CookieConfig 认证 Cookie 的属性，按部署环境配置
+type CookieConfig struct {
+	SameSite http.SameSite
+	Secure   bool // 仅通过 HTTPS 发送，生产环境建议开启
+}
+
+// NewAuth 创建认证处理器
+func NewAuth(userService *users.UserService, keys *keystore.KeyStore, sessionService *sessions.SessionService,
+	guard *loginguard.Guard, tokenService *apitokens.TokenService, cookies CookieConfig, oidcProvider *oidc.Provider) *Auth {
+	return &Auth{
+		userService: userService,
+		keys:        keys,
+		sessions:    sessionService,
+		guard:       guard,
+		tokens:      tokenService,
+		cookies:     cookies,
+		oidc:        oidcProvider,
+	}
+}
+
+// GenerateToken 使用当前签名密钥生成 JWT Token，并在头部写入 kid
+func (a *Auth) GenerateToken(user *users.User, sessionID string) (string, error) {
+	kid, secret, err := a.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	expirationTime := time.Now().Add(AccessTokenExpiration)
 	claims := &Claims{
-		Username: username,
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
+			Subject:   strconv.FormatInt(user.ID, 10),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "mblog-backend",
@@ -44,31 +110,99 @@ func GenerateToken(username string) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(JWTSecret))
+	token.Header["kid"] = kid
+	return token.SignedString(secret)
+}
+
+// keyFunc 根据令牌头部的 kid 选择验证密钥
+func (a *Auth) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, fmt.Errorf("token missing kid")
+	}
+	return a.keys.VerificationKey(kid)
 }
 
 // ParseToken 解析并验证 JWT Token
-func ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(JWTSecret), nil
-	})
+func (a *Auth) ParseToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, a.keyFunc)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		// 旧版本签发的令牌不含用户ID或会话ID，视为无效，要求重新登录
+		if claims.UserID == 0 || claims.ID == "" {
+			return nil, fmt.Errorf("token missing user or session id")
+		}
 		return claims, nil
 	}
 
 	return nil, fmt.Errorf("invalid token")
 }
 
+// generateMFAToken 生成两步验证挑战令牌
+func (a *Auth) generateMFAToken(user *users.User) (string, error) {
+	kid, secret, err := a.keys.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &mfaClaims{
+		UserID:  user.ID,
+		Purpose: mfaTokenPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFATokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "mblog-backend",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(secret)
+}
+
+// parseMFAToken 解析两步验证挑战令牌，返回用户ID
+func (a *Auth) parseMFAToken(tokenString string) (int64, error) {
+	claims := &mfaClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, a.keyFunc)
+	if err != nil {
+		return 0, err
+	}
+	if claims.Purpose != mfaTokenPurpose || claims.UserID == 0 {
+		return 0, fmt.Errorf("invalid mfa token")
+	}
+	return claims.UserID, nil
+}
+
+// setCookie 按部署配置的 SameSite 和 Secure 属性写入 Cookie
+func (a *Auth) setCookie(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	c.SetSameSite(a.cookies.SameSite)
+	c.SetCookie(name, value, maxAge, "/", "", a.cookies.Secure, httpOnly)
+}
+
+// setTokenCookies 写入访问令牌和刷新令牌 Cookie，refreshToken 为空时保留原有刷新令牌
+func (a *Auth) setTokenCookies(c *gin.Context, accessToken, refreshToken string) {
+	a.setCookie(c, TokenCookieName, accessToken, int(AccessTokenExpiration.Seconds()), true)
+	if refreshToken != "" {
+		a.setCookie(c, RefreshCookieName, refreshToken, int(sessions.RefreshTokenExpiration.Seconds()), true)
+	}
+}
+
+// clearTokenCookies 清除认证相关 Cookie
+func (a *Auth) clearTokenCookies(c *gin.Context) {
+	a.setCookie(c, TokenCookieName, "", -1, true)
+	a.setCookie(c, RefreshCookieName, "", -1, true)
+	a.setCookie(c, CSRFCookieName, "", -1, false)
+}
+
 // Login 处理登录请求
-func Login(c *gin.Context) {
+func (a *Auth) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -78,10 +212,37 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// 校验密码之前先检查限流和锁定状态
+	if !a.checkGuard(c, req.Username) {
+		return
+	}
+
 	// 验证凭据
-	if req.Username == DefaultAdminUser && req.Password == DefaultAdminPassword {
-		// 生成 JWT
-		tokenString, err := GenerateToken(req.Username)
+	user, err := a.userService.Authenticate(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidCredentials) || errors.Is(err, users.ErrUserDisabled) {
+			reason := loginguard.ReasonBadPassword
+			if errors.Is(err, users.ErrUserDisabled) {
+				reason = loginguard.ReasonDisabled
+			}
+			a.guard.RecordFailure(c.ClientIP(), req.Username, c.Request.UserAgent(), reason)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+		log.Printf("登录校验失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "登录失败，请稍后重试",
+		})
+		return
+	}
+
+	// 启用两步验证的账号需要先提交验证码，此时不签发任何访问令牌
+	if user.TOTPEnabled {
+		mfaToken, err := a.generateMFAToken(user)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
@@ -89,49 +250,182 @@ func Login(c *gin.Context) {
 			})
 			return
 		}
+		c.JSON(http.StatusOK, gin.H{
+			"success":      false,
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"message":      "请输入两步验证码",
+		})
+		return
+	}
 
-		// 设置 HttpOnly Cookie
-		c.SetCookie(
-			TokenCookieName,
-			tokenString,
-			int(TokenExpiration.Seconds()),
-			"/",
-			"",
-			false, // 生产环境建议设为 true (HTTPS)
-			true,  // HttpOnly
-		)
+	a.completeLogin(c, user)
+}
 
-		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-			"message": "登录成功",
+// LoginTOTP 处理登录第二步，校验两步验证码或恢复码
+func (a *Auth) LoginTOTP(c *gin.Context) {
+	var req TOTPLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请输入验证码",
 		})
-	} else {
+		return
+	}
+
+	userID, err := a.parseMFAToken(req.MFAToken)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "用户名或密码错误",
+			"success":     false,
+			"message":     "验证已超时，请重新登录",
+			"mfa_restart": true,
+		})
+		return
+	}
+
+	user, err := a.userService.GetUserByID(userID)
+	if err != nil || user.Disabled {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success":     false,
+			"message":     "验证失败，请重新登录",
+			"mfa_restart": true,
 		})
+		return
+	}
+
+	// 验证码同样计入失败次数，防止在挑战令牌有效期内穷举
+	if !a.checkGuard(c, user.Username) {
+		return
 	}
+
+	if err := a.userService.VerifySecondFactor(userID, req.Code); err != nil {
+		if errors.Is(err, users.ErrInvalidTOTPCode) {
+			a.guard.RecordFailure(c.ClientIP(), user.Username, c.Request.UserAgent(), loginguard.ReasonBadTOTP)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+		log.Printf("两步验证校验失败: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success":     false,
+			"message":     "验证失败，请重新登录",
+			"mfa_restart": true,
+		})
+		return
+	}
+
+	a.completeLogin(c, user)
+}
+
+// checkGuard 检查当前 IP 和用户名是否允许尝试登录，被拒绝时写入响应并返回 false
+func (a *Auth) checkGuard(c *gin.Context, username string) bool {
+	err := a.guard.Check(c.ClientIP(), username)
+	if err == nil {
+		return true
+	}
+
+	var blocked *loginguard.BlockedError
+	if errors.As(err, &blocked) {
+		a.guard.RecordFailure(c.ClientIP(), username, c.Request.UserAgent(), loginguard.ReasonThrottled)
+		c.Header("Retry-After", strconv.Itoa(int(blocked.RetryAfter.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"success":     false,
+			"message":     blocked.Error(),
+			"retry_after": int(blocked.RetryAfter.Seconds()) + 1,
+		})
+		return false
+	}
+
+	log.Printf("检查登录限制失败: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"message": "登录失败，请稍后重试",
+	})
+	return false
 }
 
-// Logout 处理登出请求
-func Logout(c *gin.Context) {
-	// 清除 Cookie
-	c.SetCookie(TokenCookieName, "", -1, "/", "", false, true)
+// completeLogin 在全部认证步骤通过后创建会话并签发令牌
+func (a *Auth) completeLogin(c *gin.Context, user *users.User) {
+	csrfToken, err := a.startSession(c, user)
+	if err != nil {
+		log.Printf("创建会话失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "创建会话失败",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "已登出",
+		"success":    true,
+		"message":    "登录成功",
+		"role":       user.Role,
+		"csrf_token": csrfToken,
 	})
 }
 
-// CheckAuth 检查登录状态
-func CheckAuth(c *gin.Context) {
-	tokenString, err := c.Cookie(TokenCookieName)
+// startSession 创建会话、签发访问令牌并写入 Cookie，返回 CSRF 令牌
+func (a *Auth) startSession(c *gin.Context, user *users.User) (string, error) {
+	session, refreshToken, err := a.sessions.Create(user.ID, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{"authenticated": false})
+		return "", err
+	}
+	tokenString, err := a.GenerateToken(user, session.ID)
+	if err != nil {
+		return "", err
+	}
+	csrfToken, err := a.sessions.IssueCSRFToken(session.ID)
+	if err != nil {
+		return "", err
+	}
+
+	a.setTokenCookies(c, tokenString, refreshToken)
+	a.setCookie(c, CSRFCookieName, csrfToken, int(sessions.RefreshTokenExpiration.Seconds()), false)
+	a.userService.RecordLogin(user)
+	a.guard.RecordSuccess(c.ClientIP(), user.Username, c.Request.UserAgent())
+	return csrfToken, nil
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌
+func (a *Auth) Refresh(c *gin.Context) {
+	claims, err := a.refresh(c)
+	if err != nil {
+		a.clearTokenCookies(c)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "登录已过期，请重新登录",
+		})
 		return
 	}
 
-	claims, err := ParseToken(tokenString)
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"expires_at": claims.ExpiresAt.Time,
+	})
+}
+
+// Logout 处理登出请求，注销服务端会话并清除 Cookie
+func (a *Auth) Logout(c *gin.Context) {
+	if refreshToken, err := c.Cookie(RefreshCookieName); err == nil {
+		a.sessions.RevokeByRefreshToken(refreshToken)
+	} else if tokenString, err := c.Cookie(TokenCookieName); err == nil {
+		if claims, err := a.ParseToken(tokenString); err == nil {
+			a.sessions.Revoke(claims.ID)
+		}
+	}
+
+	a.clearTokenCookies(c)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已登出",
+	})
+}
+
+// CheckAuth 检查登录状态
+func (a *Auth) CheckAuth(c *gin.Context) {
+	claims, err := a.authenticate(c)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{"authenticated": false})
 		return
@@ -139,35 +433,200 @@ func CheckAuth(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"authenticated": true,
+		"user_id":       claims.UserID,
 		"username":      claims.Username,
+		"role":          claims.Role,
 	})
 }
 
+// authenticate 校验访问令牌及其会话状态，访问令牌缺失或过期时尝试用刷新令牌续期
+func (a *Auth) authenticate(c *gin.Context) (*Claims, error) {
+	tokenString, err := c.Cookie(TokenCookieName)
+	if err != nil {
+		return a.refresh(c)
+	}
+
+	claims, err := a.ParseToken(tokenString)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return a.refresh(c)
+		}
+		return nil, err
+	}
+
+	active, err := a.sessions.IsActive(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, sessions.ErrSessionInvalid
+	}
+	return claims, nil
+}
+
+// refresh 轮换刷新令牌并签发新的访问令牌，同时重新读取用户角色
+func (a *Auth) refresh(c *gin.Context) (*Claims, error) {
+	refreshToken, err := c.Cookie(RefreshCookieName)
+	if err != nil {
+		return nil, err
+	}
+
+	session, newRefreshToken, err := a.sessions.Rotate(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.userService.GetUserByID(session.UserID)
+	if err == nil && user.Disabled {
+		err = users.ErrUserDisabled
+	}
+	if err != nil {
+		a.sessions.Revoke(session.ID)
+		return nil, err
+	}
+
+	tokenString, err := a.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	a.setTokenCookies(c, tokenString, newRefreshToken)
+
+	return &Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.ID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenExpiration)),
+		},
+	}, nil
+}
+
 // RequireAuth 认证中间件 - 保护需要登录的路由
-func RequireAuth() gin.HandlerFunc {
+// 支持浏览器的登录 Cookie，以及脚本使用的 Authorization: Bearer API 令牌
+func (a *Auth) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString, err := c.Cookie(TokenCookieName)
-		if err != nil {
-			handleUnauthorized(c)
+		if header := c.GetHeader("Authorization"); header != "" {
+			a.authenticateToken(c, header)
 			return
 		}
 
-		claims, err := ParseToken(tokenString)
+		claims, err := a.authenticate(c)
 		if err != nil {
 			handleUnauthorized(c)
 			return
 		}
 
 		// 将用户信息存入上下文
+		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.ID)
 		c.Next()
 	}
 }
 
+// authenticateToken 校验 Authorization 头中的 API 令牌，令牌无效时直接返回 401，不回退到 Cookie
+func (a *Auth) authenticateToken(c *gin.Context, header string) {
+	plain, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "仅支持 Bearer 令牌",
+		})
+		c.Abort()
+		return
+	}
+
+	owner, err := a.tokens.Authenticate(strings.TrimSpace(plain))
+	if err != nil {
+		if !errors.Is(err, apitokens.ErrTokenInvalid) {
+			log.Printf("校验 API 令牌失败: %v", err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": apitokens.ErrTokenInvalid.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", owner.UserID)
+	c.Set("username", owner.Username)
+	c.Set("role", owner.Role)
+	c.Set("api_token_id", owner.TokenID)
+	c.Set("api_token_scopes", owner.Scopes)
+	c.Next()
+}
+
+// RequireRole 角色授权中间件，必须挂在 RequireAuth 之后
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(c *gin.Context) {
+		if !allowed[c.GetString("role")] {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "权限不足",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission 权限授权中间件，必须挂在 RequireAuth 之后
+// 用户角色须在 roles 中；使用 API 令牌访问时，令牌的权限范围还必须包含 permission
+func RequirePermission(permission string, roles ...string) gin.HandlerFunc {
+	requireRole := RequireRole(roles...)
+
+	return func(c *gin.Context) {
+		if scopes, ok := c.Get("api_token_scopes"); ok && !containsScope(scopes.([]string), permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "API 令牌未授予该权限",
+			})
+			c.Abort()
+			return
+		}
+		requireRole(c)
+	}
+}
+
+// RequireSession 要求通过浏览器登录会话访问，拒绝 API 令牌
+// 用于账号安全设置等不应由脚本操作的路由
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("api_token_id"); ok {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "API 令牌不能用于此操作",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func containsScope(scopes []string, permission string) bool {
+	for _, s := range scopes {
+		if s == permission {
+			return true
+		}
+	}
+	return false
+}
+
 func handleUnauthorized(c *gin.Context) {
-	// 如果是页面请求，跳转到登录页
-	if c.GetHeader("Accept") == "" || c.GetHeader("Accept") == "text/html" ||
-		c.Request.URL.Path == "/admin" || c.Request.URL.Path == "/admin/" {
+	// 如果是页面请求，跳转到登录页；/api/ 下的接口始终返回 401
+	isAPI := strings.HasPrefix(c.Request.URL.Path, "/api/")
+	if !isAPI && (c.GetHeader("Accept") == "" || c.GetHeader("Accept") == "text/html" ||
+		c.Request.URL.Path == "/admin" || c.Request.URL.Path == "/admin/") {
 		c.Redirect(http.StatusFound, "/login")
 		c.Abort()
 		return
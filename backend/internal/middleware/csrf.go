@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"blog/pkg/sessions"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CSRFCookieName 保存 CSRF 令牌的 Cookie，前端脚本读取后放入 CSRFHeaderName 请求头
+	CSRFCookieName = "mblog_csrf"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+// isSafeMethod 判断请求方法是否不会修改服务端状态
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// RequireCSRF CSRF 防护中间件，必须挂在 RequireAuth 之后
+// 通过登录 Cookie 认证的修改类请求必须在请求头中携带与会话绑定的 CSRF 令牌；
+// API 令牌不会被浏览器自动携带，不需要校验
+func (a *Auth) RequireCSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("api_token_id"); ok {
+			c.Next()
+			return
+		}
+		sessionID := c.GetString("session_id")
+
+		if isSafeMethod(c.Request.Method) {
+			// 升级前创建的会话或 Cookie 被清除时，在安全请求中补发令牌
+			if _, err := c.Cookie(CSRFCookieName); err != nil && sessionID != "" {
+				token, err := a.sessions.IssueCSRFToken(sessionID)
+				if err != nil {
+					log.Printf("补发 CSRF 令牌失败: %v", err)
+				} else {
+					a.setCookie(c, CSRFCookieName, token, int(sessions.RefreshTokenExpiration.Seconds()), false)
+				}
+			}
+			c.Next()
+			return
+		}
+
+		ok, err := a.sessions.VerifyCSRFToken(sessionID, c.GetHeader(CSRFHeaderName))
+		if err != nil {
+			log.Printf("校验 CSRF 令牌失败: %v", err)
+		}
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "CSRF 令牌无效，请刷新页面后重试",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger 请求日志中间件，格式与 gin 默认日志相同（不含颜色）；
+// omitClientIP 为 true 时不输出客户端 IP，用于无 Cookie 模式下保证 IP 不会随容器日志保存下来
+func Logger(omitClientIP bool) gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
+		latency := p.Latency
+		if latency > time.Minute {
+			latency = latency.Truncate(time.Second)
+		}
+		timestamp := p.TimeStamp.Format("2006/01/02 - 15:04:05")
+
+		if omitClientIP {
+			return fmt.Sprintf("[GIN] %v | %3d | %13v | %-7s %#v\n%s",
+				timestamp, p.StatusCode, latency, p.Method, p.Path, p.ErrorMessage)
+		}
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			timestamp, p.StatusCode, latency, p.ClientIP, p.Method, p.Path, p.ErrorMessage)
+	})
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoggerOmitsClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const clientIP = "198.51.100.7"
+
+	for _, omit := range []bool{false, true} {
+		var buf bytes.Buffer
+		old := gin.DefaultWriter
+		gin.DefaultWriter = &buf
+		r := gin.New()
+		r.Use(Logger(omit))
+		gin.DefaultWriter = old
+		r.POST("/api/track", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodPost, "/api/track", nil)
+		req.RemoteAddr = clientIP + ":1234"
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		line := buf.String()
+		if !strings.Contains(line, `"/api/track"`) {
+			t.Fatalf("日志中缺少请求路径: %q", line)
+		}
+		if got := strings.Contains(line, clientIP); got == omit {
+			t.Errorf("omitClientIP=%v 时日志 = %q", omit, line)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"blog/pkg/oidc"
+	"blog/pkg/users"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// OIDCCookieName 保存单点登录流程状态的 Cookie，仅在跳转到身份提供方期间有效
+	OIDCCookieName      = "mblog_oidc"
+	oidcCookiePath      = "/api/auth/oidc"
+	oidcFlowExpiration  = 10 * time.Minute
+	oidcFlowPurpose     = "oidc"
+	oidcLoginSuccessURL = "/admin"
+)
+
+// oidcFlowClaims 单点登录流程状态，签名后保存在 Cookie 中，服务端无需保存
+type oidcFlowClaims struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Purpose  string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// AuthProviders 返回登录页可用的登录方式
+func (a *Auth) AuthProviders(c *gin.Context) {
+	if a.oidc == nil {
+		c.JSON(http.StatusOK, gin.H{"oidc": gin.H{"enabled": false}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"oidc": gin.H{
+			"enabled":   true,
+			"name":      a.oidc.Name(),
+			"login_url": oidcCookiePath + "/login",
+		},
+	})
+}
+
+// OIDCLogin 生成 state、nonce 和 PKCE 参数后跳转到身份提供方
+func (a *Auth) OIDCLogin(c *gin.Context) {
+	if a.oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用单点登录"})
+		return
+	}
+
+	var flow oidcFlowClaims
+	var err error
+	for _, v := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		if *v, err = oidc.RandomString(); err != nil {
+			oidcLoginFailed(c, "生成登录参数失败", err)
+			return
+		}
+	}
+
+	authURL, err := a.oidc.AuthCodeURL(c.Request.Context(), flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		oidcLoginFailed(c, "无法连接身份提供方", err)
+		return
+	}
+
+	kid, secret, err := a.keys.SigningKey()
+	if err != nil {
+		oidcLoginFailed(c, "生成登录参数失败", err)
+		return
+	}
+	flow.Purpose = oidcFlowPurpose
+	flow.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(oidcFlowExpiration)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		Issuer:    "mblog-backend",
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &flow)
+	token.Header["kid"] = kid
+	flowToken, err := token.SignedString(secret)
+	if err != nil {
+		oidcLoginFailed(c, "生成登录参数失败", err)
+		return
+	}
+
+	a.setOIDCCookie(c, flowToken, int(oidcFlowExpiration.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback 处理身份提供方回调：校验 state，用授权码换取并校验 ID Token，映射本地账号后签发登录令牌
+func (a *Auth) OIDCCallback(c *gin.Context) {
+	if a.oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未启用单点登录"})
+		return
+	}
+
+	flowToken, cookieErr := c.Cookie(OIDCCookieName)
+	// 流程状态只能使用一次
+	a.setOIDCCookie(c, "", -1)
+
+	if errMsg := c.Query("error"); errMsg != "" {
+		oidcLoginFailed(c, "身份提供方拒绝了登录请求", fmt.Errorf("%s: %s", errMsg, c.Query("error_description")))
+		return
+	}
+	if cookieErr != nil {
+		oidcLoginFailed(c, "登录已超时，请重试", cookieErr)
+		return
+	}
+
+	flow := &oidcFlowClaims{}
+	if _, err := jwt.ParseWithClaims(flowToken, flow, a.keyFunc); err != nil || flow.Purpose != oidcFlowPurpose {
+		oidcLoginFailed(c, "登录已超时，请重试", err)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(flow.State)) != 1 {
+		oidcLoginFailed(c, "登录状态校验失败，请重试", errors.New("state mismatch"))
+		return
+	}
+
+	identity, err := a.oidc.Exchange(c.Request.Context(), c.Query("code"), flow.Verifier, flow.Nonce)
+	if err != nil {
+		message := "单点登录失败"
+		if errors.Is(err, oidc.ErrEmailNotVerified) || errors.Is(err, oidc.ErrDomainNotAllowed) {
+			message = err.Error()
+		}
+		oidcLoginFailed(c, message, err)
+		return
+	}
+
+	role, err := a.oidc.RoleFor(identity)
+	if err != nil {
+		oidcLoginFailed(c, err.Error(), fmt.Errorf("%s groups=%v", identity.Email, identity.Groups))
+		return
+	}
+
+	user, err := a.userService.LoginSSO(identity.Subject, identity.Email, identity.Name, role)
+	if err != nil {
+		message := "单点登录失败"
+		if errors.Is(err, users.ErrUserDisabled) || errors.Is(err, users.ErrSSOIdentityTaken) ||
+			errors.Is(err, users.ErrSSOLinkRequired) || errors.Is(err, users.ErrInvalidUsername) {
+			message = err.Error()
+		}
+		oidcLoginFailed(c, message, err)
+		return
+	}
+
+	if _, err := a.startSession(c, user); err != nil {
+		oidcLoginFailed(c, "创建会话失败", err)
+		return
+	}
+	log.Printf("用户 %s 通过单点登录登录，角色: %s", user.Username, user.Role)
+	c.Redirect(http.StatusFound, oidcLoginSuccessURL)
+}
+
+// setOIDCCookie 写入单点登录流程 Cookie
+// 回调是从身份提供方跨站跳转回来的，SameSite=Strict 时浏览器不会携带 Cookie，因此至少使用 Lax
+func (a *Auth) setOIDCCookie(c *gin.Context, value string, maxAge int) {
+	sameSite := a.cookies.SameSite
+	if sameSite == http.SameSiteStrictMode {
+		sameSite = http.SameSiteLaxMode
+	}
+	c.SetSameSite(sameSite)
+	c.SetCookie(OIDCCookieName, value, maxAge, oidcCookiePath, "", a.cookies.Secure, true)
+}
+
+// oidcLoginFailed 记录失败原因并带着错误提示跳转回登录页
+func oidcLoginFailed(c *gin.Context, message string, err error) {
+	log.Printf("[安全] 单点登录失败: %s, ip=%s: %v", message, c.ClientIP(), err)
+	c.Redirect(http.StatusFound, "/login?error="+url.QueryEscape(message))
+}
@@ -1,28 +1,79 @@
 package router
 
 import (
+	"log"
+	"path/filepath"
+
+	"blog/internal/config"
 	"blog/internal/handler"
 	"blog/internal/middleware"
+	"blog/pkg/apitokens"
+	"blog/pkg/audit"
 	"blog/pkg/comments"
+	"blog/pkg/keystore"
+	"blog/pkg/loginguard"
+	"blog/pkg/retention"
+	"blog/pkg/sessions"
 	"blog/pkg/tracking"
+	"blog/pkg/users"
 
 	"github.com/gin-gonic/gin"
 )
 
+// routePermissions 受保护路由的权限表：权限 -> 允许访问的角色
+// 编辑可以查看和修改文章但不能删除或构建，分析师只能查看统计数据
+var routePermissions = map[string][]string{
+	"admin:view":      {users.RoleAdmin, users.RoleEditor, users.RoleAnalyst},
+	"files:read":      {users.RoleAdmin, users.RoleEditor},
+	"files:write":     {users.RoleAdmin, users.RoleEditor},
+	"files:delete":    {users.RoleAdmin},
+	"comments:manage": {users.RoleAdmin, users.RoleEditor},
+	"build:run":       {users.RoleAdmin},
+	"analytics:read":  {users.RoleAdmin, users.RoleAnalyst},
+	"users:manage":    {users.RoleAdmin},
+	"keys:manage":     {users.RoleAdmin},
+	"security:manage": {users.RoleAdmin},
+	"audit:read":      {users.RoleAdmin},
+	"retention:read":  {users.RoleAdmin},
+}
+
+// allow 返回指定权限对应的授权中间件，API 令牌还需在权限范围内包含该权限
+func allow(permission string) gin.HandlerFunc {
+	return middleware.RequirePermission(permission, routePermissions[permission]...)
+}
+
 // SetupRouter 设置并返回配置好的 Gin 路由器
 func SetupRouter(
+	cfg config.ServerConfig,
 	trackingService *tracking.TrackingService,
 	analyticsService *tracking.AnalyticsService,
 	commentService *comments.CommentService,
+	userService *users.UserService,
+	keyStore *keystore.KeyStore,
+	sessionService *sessions.SessionService,
+	loginGuard *loginguard.Guard,
+	tokenService *apitokens.TokenService,
+	auditService *audit.AuditService,
+	retentionService *retention.RetentionService,
+	auth *middleware.Auth,
 ) *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(middleware.Logger(trackingService.Cookieless()), gin.Recovery())
+
+	// 只信任配置的反向代理转发的客户端 IP，否则任何人都能通过伪造 X-Forwarded-For 绕过按 IP 的登录限流和请求频率限制
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Printf("可信代理配置无效，不再信任任何代理: %v", err)
+		r.SetTrustedProxies(nil)
+	}
 
 	// 注册全局中间件
-	r.Use(middleware.CORS())
+	r.Use(middleware.CORS(cfg.CORSOrigins))
 	r.Use(trackingService.TrackingMiddleware())
+	// 审计中间件需在认证之前注册，请求结束后才能读取到认证写入的操作者信息
+	r.Use(auditService.Middleware())
 
 	// 创建处理器
-	fileHandler := handler.NewFileHandler()
+	fileHandler := handler.NewFileHandler(cfg.UploadMaxSize)
 	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
 	healthHandler := handler.NewHealthHandler()
 
@@ -33,47 +84,76 @@ func SetupRouter(
 	r.GET("/api/ping", healthHandler.Ping)
 
 	// 登录页面和认证 API
-	r.StaticFile("/login", "/app/static/login.html")
-	r.StaticFile("/login/", "/app/static/login.html")
-	r.POST("/api/auth/login", middleware.Login)
-	r.POST("/api/auth/logout", middleware.Logout)
-	r.GET("/api/auth/check", middleware.CheckAuth)
+	r.StaticFile("/login", filepath.Join(cfg.StaticDir, "login.html"))
+	r.StaticFile("/login/", filepath.Join(cfg.StaticDir, "login.html"))
+	r.POST("/api/auth/login", auth.Login)
+	r.POST("/api/auth/login/totp", auth.LoginTOTP)
+	r.POST("/api/auth/refresh", auth.Refresh)
+	r.POST("/api/auth/logout", auth.Logout)
+	r.GET("/api/auth/check", auth.CheckAuth)
+	r.GET("/api/auth/providers", auth.AuthProviders)
+	r.GET("/api/auth/oidc/login", auth.OIDCLogin)
+	r.GET("/api/auth/oidc/callback", auth.OIDCCallback)
 
-	// 埋点和评论（由各自的包注册，公开访问）
+	// 埋点和评论（由各自的包注册，公开访问；评论审核接口在受保护路由中注册）
 	trackingService.RegisterHandlers(r)
 	commentService.RegisterHandlers(r)
 
 	// 静态资源
-	r.StaticFile("/favicon.ico", "/app/static/favicon.ico")
-	r.Static("/static", "/app/static")
+	r.StaticFile("/favicon.ico", filepath.Join(cfg.StaticDir, "favicon.ico"))
+	r.Static("/static", cfg.StaticDir)
 
 	// ============================================
 	// 受保护路由（需要登录）
 	// ============================================
 	admin := r.Group("")
-	admin.Use(middleware.RequireAuth())
+	admin.Use(auth.RequireAuth(), auth.RequireCSRF())
 	{
-		// 管理页面
-		admin.GET("/admin", func(c *gin.Context) {
-			c.File("/app/static/admin.html")
+		// 管理页面（各角色共用，页面内功能由 API 权限控制）
+		admin.GET("/admin", allow("admin:view"), func(c *gin.Context) {
+			c.File(filepath.Join(cfg.StaticDir, "admin.html"))
 		})
-		admin.GET("/admin/", func(c *gin.Context) {
-			c.File("/app/static/admin.html")
+		admin.GET("/admin/", allow("admin:view"), func(c *gin.Context) {
+			c.File(filepath.Join(cfg.StaticDir, "admin.html"))
 		})
-		admin.GET("/analytics", func(c *gin.Context) {
-			c.File("/app/static/analytics.html")
+		admin.GET("/analytics", allow("analytics:read"), func(c *gin.Context) {
+			c.File(filepath.Join(cfg.StaticDir, "analytics.html"))
 		})
 
 		// 文件管理 API
-		admin.GET("/api/files", fileHandler.GetAllFiles)
-		admin.GET("/api/files/*filename", fileHandler.GetFileContent)
-		admin.POST("/api/files", fileHandler.SaveFile)
-		admin.DELETE("/api/files/*filename", fileHandler.DeleteFile)
-		admin.POST("/api/build", fileHandler.BuildSite)
-		admin.POST("/api/upload", fileHandler.UploadFiles)
+		admin.GET("/api/files", allow("files:read"), fileHandler.GetAllFiles)
+		admin.GET("/api/files/*filename", allow("files:read"), fileHandler.GetFileContent)
+		admin.POST("/api/files", allow("files:write"), fileHandler.SaveFile)
+		admin.DELETE("/api/files/*filename", allow("files:delete"), fileHandler.DeleteFile)
+		admin.POST("/api/build", allow("build:run"), fileHandler.BuildSite)
+		admin.POST("/api/upload", allow("files:write"), fileHandler.UploadFiles)
+
+		// 评论审核 API
+		commentService.RegisterAdminHandlers(admin.Group("", allow("comments:manage")))
 
 		// 统计分析 API
-		admin.GET("/api/analytics", analyticsHandler.GetFullStats)
+		admin.GET("/api/analytics", allow("analytics:read"), analyticsHandler.GetFullStats)
+
+		// 当前用户的账号设置（两步验证、API 令牌），只允许通过浏览器登录会话操作
+		account := admin.Group("", middleware.RequireSession())
+		userService.RegisterAccountHandlers(account)
+		tokenService.RegisterHandlers(account)
+
+		// 用户管理 API
+		userService.RegisterHandlers(admin.Group("", allow("users:manage")))
+		sessionService.RegisterHandlers(admin.Group("", allow("users:manage")))
+
+		// 签名密钥管理 API
+		keyStore.RegisterHandlers(admin.Group("", allow("keys:manage")))
+
+		// 登录安全日志和账号解锁 API
+		loginGuard.RegisterHandlers(admin.Group("", allow("security:manage")))
+
+		// 审计日志 API
+		auditService.RegisterHandlers(admin.Group("", allow("audit:read")))
+
+		// 数据保留策略和清理试运行 API
+		retentionService.RegisterHandlers(admin.Group("", allow("retention:read")))
 	}
 
 	return r
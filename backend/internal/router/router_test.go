@@ -0,0 +1,319 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"blog/internal/config"
+	"blog/internal/middleware"
+	"blog/pkg/apitokens"
+	"blog/pkg/audit"
+	"blog/pkg/comments"
+	"blog/pkg/keystore"
+	"blog/pkg/loginguard"
+	"blog/pkg/retention"
+	"blog/pkg/sessions"
+	"blog/pkg/tracking"
+	"blog/pkg/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testSessionID = "test-session"
+	testCSRFToken = "test-csrf-token"
+)
+
+// fakeDriver 只回答认证需要的查询：签名密钥为空（使用配置密钥）、会话有效、CSRF 令牌匹配，
+// 其他查询返回错误，写操作忽略。这样无需数据库即可验证路由上挂载的认证和授权中间件
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct{ query string }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "INSERT INTO login_attempts") {
+		loginAttempts.record(args[1].(string), args[3].(bool), args[4].(string))
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	switch {
+	case strings.Contains(s.query, "WHERE ip_address = $1"):
+		failures := loginAttempts.failures(args[0].(string))
+		oldest := driver.Value(nil)
+		if failures > 0 {
+			oldest = time.Now()
+		}
+		return &fakeRows{columns: []string{"count", "min"}, values: [][]driver.Value{{int64(failures), oldest}}}, nil
+	case strings.Contains(s.query, "WHERE username = $1 AND NOT success"):
+		return &fakeRows{columns: []string{"count", "max"}, values: [][]driver.Value{{int64(0), nil}}}, nil
+	case strings.Contains(s.query, "FROM auth_signing_keys"):
+		return &fakeRows{columns: []string{"kid", "secret", "created_at", "retired_at"}}, nil
+	case strings.Contains(s.query, "FROM auth_sessions s"):
+		return &fakeRows{columns: []string{"exists"}, values: [][]driver.Value{{true}}}, nil
+	case strings.Contains(s.query, "SELECT csrf_hash FROM auth_sessions"):
+		sum := sha256.Sum256([]byte(testCSRFToken))
+		return &fakeRows{columns: []string{"csrf_hash"}, values: [][]driver.Value{{hex.EncodeToString(sum[:])}}}, nil
+	}
+	return nil, errors.New("fake db: unsupported query")
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("routertest", fakeDriver{})
+}
+
+// attemptStore 按 IP 记录登录失败次数，代替 login_attempts 表
+type attemptStore struct {
+	mu      sync.Mutex
+	byIP    map[string]int
+	lastIPs []string
+}
+
+var loginAttempts = &attemptStore{byIP: map[string]int{}}
+
+func (a *attemptStore) record(ip string, success bool, reason string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastIPs = append(a.lastIPs, ip)
+	if !success && reason != loginguard.ReasonThrottled {
+		a.byIP[ip]++
+	}
+}
+
+func (a *attemptStore) failures(ip string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.byIP[ip]
+}
+
+func (a *attemptStore) reset(ip string, failures int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.byIP = map[string]int{ip: failures}
+	a.lastIPs = nil
+}
+
+// newTestRouter 以与 server.New 相同的方式组装路由，服务都连接到 fakeDriver
+func newTestRouter(t *testing.T) (*gin.Engine, *middleware.Auth) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := sql.Open("routertest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyStore, err := keystore.NewKeyStore(db, "test", strings.Repeat("k", 32), middleware.AccessTokenExpiration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userService := users.NewUserService(db)
+	sessionService := sessions.NewSessionService(db)
+	loginGuard := loginguard.NewGuard(db, loginguard.DefaultPolicy())
+	tokenService := apitokens.NewTokenService(db)
+	auth := middleware.NewAuth(userService, keyStore, sessionService, loginGuard, tokenService,
+		middleware.CookieConfig{SameSite: http.SameSiteLaxMode}, nil)
+
+	trackingService := tracking.NewTrackingService(db, tracking.Options{
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+		QueueSize:     100,
+	})
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		trackingService.Close(ctx)
+	})
+
+	// 与默认配置一样信任内网地址段中的反向代理
+	engine := SetupRouter(config.ServerConfig{StaticDir: t.TempDir(), TrustedProxies: []string{"172.16.0.0/12"}}, trackingService,
+		tracking.NewAnalyticsService(db, 0), comments.NewCommentService(db), userService, keyStore,
+		sessionService, loginGuard, tokenService, audit.NewAuditService(db),
+		retention.NewRetentionService(db, retention.Options{}), auth)
+	return engine, auth
+}
+
+// loggedIn 以指定角色的登录会话发送请求，带上有效的 CSRF 令牌
+func loggedIn(t *testing.T, auth *middleware.Auth, req *http.Request, role string) {
+	t.Helper()
+	token, err := auth.GenerateToken(&users.User{ID: 1, Username: role + "-user", Role: role}, testSessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.AddCookie(&http.Cookie{Name: middleware.TokenCookieName, Value: token})
+	req.Header.Set(middleware.CSRFHeaderName, testCSRFToken)
+}
+
+var commentModerationRoutes = []struct {
+	method string
+	path   string
+	body   string
+}{
+	{http.MethodDelete, "/api/admin/comments/1", ""},
+	{http.MethodPut, "/api/admin/comments/1/status", `{"status":"approved"}`},
+}
+
+func TestCommentModerationRequiresLogin(t *testing.T) {
+	engine, _ := newTestRouter(t)
+
+	for _, route := range commentModerationRoutes {
+		req := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("匿名 %s %s = %d，期望 401", route.method, route.path, w.Code)
+		}
+	}
+}
+
+func TestCommentModerationRequiresPermission(t *testing.T) {
+	engine, auth := newTestRouter(t)
+
+	tests := []struct {
+		role    string
+		allowed bool
+	}{
+		{users.RoleAnalyst, false},
+		{users.RoleEditor, true},
+		{users.RoleAdmin, true},
+	}
+	for _, tt := range tests {
+		for _, route := range commentModerationRoutes {
+			req := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+			req.Header.Set("Content-Type", "application/json")
+			loggedIn(t, auth, req, tt.role)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			switch {
+			case !tt.allowed && w.Code != http.StatusForbidden:
+				t.Errorf("%s %s %s = %d，期望 403", tt.role, route.method, route.path, w.Code)
+			case tt.allowed && (w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden):
+				t.Errorf("%s %s %s = %d，期望通过认证和授权", tt.role, route.method, route.path, w.Code)
+			}
+		}
+	}
+}
+
+func TestCommentModerationRequiresCSRF(t *testing.T) {
+	engine, auth := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/comments/1", nil)
+	loggedIn(t, auth, req, users.RoleAdmin)
+	req.Header.Set(middleware.CSRFHeaderName, "wrong-token")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("CSRF 令牌错误时 = %d，期望 403", w.Code)
+	}
+}
+
+// proxied 模拟经 nginx 转发的请求：客户端自带的 X-Forwarded-For 之后追加真实地址
+func proxied(req *http.Request, forged, client string) {
+	req.RemoteAddr = "172.18.0.3:40000"
+	req.Header.Set("X-Forwarded-For", forged+", "+client)
+	req.Header.Set("X-Real-IP", client)
+}
+
+func TestLoginThrottleIgnoresForgedForwardedFor(t *testing.T) {
+	engine, _ := newTestRouter(t)
+	const client = "198.51.100.9"
+	loginAttempts.reset(client, loginguard.DefaultPolicy().IPMaxFailures)
+
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"username":"admin","password":"guess"}`))
+		req.Header.Set("Content-Type", "application/json")
+		proxied(req, fmt.Sprintf("203.0.113.%d", i), client)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("第 %d 次伪造 X-Forwarded-For 后状态码 = %d，期望 429", i+1, w.Code)
+		}
+	}
+
+	// 不经过可信代理的请求忽略转发头，按连接地址计数
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"username":"admin","password":"guess"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = client + ":50000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.99")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("直连时伪造 X-Forwarded-For 后状态码 = %d，期望 429", w.Code)
+	}
+
+	loginAttempts.mu.Lock()
+	defer loginAttempts.mu.Unlock()
+	for _, ip := range loginAttempts.lastIPs {
+		if ip != client {
+			t.Errorf("安全日志记录的 IP = %s，期望 %s", ip, client)
+		}
+	}
+}
+
+func TestPrivacyRateLimitIgnoresForgedForwardedFor(t *testing.T) {
+	engine, _ := newTestRouter(t)
+
+	var codes []int
+	for i := 0; i < 6; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/tracking/forget", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		proxied(req, fmt.Sprintf("203.0.113.%d", i), "198.51.100.20")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	if codes[5] != http.StatusTooManyRequests {
+		t.Errorf("轮换 X-Forwarded-For 后的状态码 = %v，第 6 次期望 429", codes)
+	}
+}
@@ -1,25 +1,44 @@
 package server
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"blog/internal/config"
 	"blog/internal/database"
+	"blog/internal/middleware"
 	"blog/internal/router"
+	"blog/pkg/apitokens"
+	"blog/pkg/audit"
+	"blog/pkg/bots"
 	"blog/pkg/comments"
 	"blog/pkg/filemanager"
+	"blog/pkg/geoip"
+	"blog/pkg/keystore"
+	"blog/pkg/loginguard"
+	"blog/pkg/oidc"
+	"blog/pkg/retention"
+	"blog/pkg/sessions"
 	"blog/pkg/tracking"
+	"blog/pkg/users"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Server 应用服务器
 type Server struct {
-	config *config.Config
-	db     *sql.DB
-	engine *gin.Engine
+	config    *config.Config
+	db        *sql.DB
+	engine    *gin.Engine
+	tracking  *tracking.TrackingService
+	analytics *tracking.AnalyticsService
+	retention *retention.RetentionService
 }
 
 // NewServer 创建新的服务器实例
@@ -30,37 +49,108 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		return nil, err
 	}
 
-	// 初始化跟踪服务（带重试机制）
-	maxRetries := 5
-	var initErr error
-	for i := 0; i < maxRetries; i++ {
-		if initErr = tracking.InitSchema(db); initErr != nil {
-			log.Printf("初始化埋点数据库失败 (尝试 %d/%d): %v", i+1, maxRetries, initErr)
-			time.Sleep(2 * time.Second)
-			continue
-		}
-		break
-	}
-	if initErr != nil {
-		log.Printf("埋点数据库初始化最终失败: %v", initErr)
+	// 执行数据库迁移，多个实例同时启动时通过咨询锁串行执行
+	if err := migrateSchema(db, cfg.Database.AutoMigrate); err != nil {
+		db.Close()
+		return nil, err
 	}
-	trackingService := tracking.NewTrackingService(db)
-	analyticsService := tracking.NewAnalyticsService(db)
 
-	// 初始化评论服务（带重试机制）
-	for i := 0; i < maxRetries; i++ {
-		if initErr = comments.InitSchema(db); initErr != nil {
-			log.Printf("初始化评论数据库失败 (尝试 %d/%d): %v", i+1, maxRetries, initErr)
-			time.Sleep(2 * time.Second)
-			continue
+	// 初始化埋点和评论服务
+	trackingOpts := tracking.Options{
+		BatchSize:     cfg.Tracking.BatchSize,
+		FlushInterval: cfg.Tracking.FlushInterval,
+		QueueSize:     cfg.Tracking.QueueSize,
+
+		PartitionPremake: cfg.Tracking.PartitionPremake,
+	}
+	if cfg.Tracking.SpoolEnabled {
+		trackingOpts.SpoolDir = cfg.Tracking.SpoolDir
+		trackingOpts.SpoolMaxSize = cfg.Tracking.SpoolMaxSize
+	}
+	if cfg.Tracking.GeoIPDatabase != "" {
+		geo, err := geoip.Open(cfg.Tracking.GeoIPDatabase, cfg.Tracking.GeoIPLanguage)
+		if err != nil {
+			db.Close()
+			return nil, err
 		}
-		break
+		meta := geo.Metadata()
+		log.Printf("已加载 GeoIP 数据库: %s（%s，构建于 %s）", cfg.Tracking.GeoIPDatabase,
+			meta.DatabaseType, time.Unix(int64(meta.BuildEpoch), 0).Format("2006-01-02"))
+		trackingOpts.GeoIP = geo
 	}
-	if initErr != nil {
-		log.Printf("评论数据库初始化最终失败: %v", initErr)
+	botDetector, err := bots.NewDetector(cfg.Tracking.BotIPRanges)
+	if err != nil {
+		db.Close()
+		return nil, err
 	}
+	trackingOpts.Bots = botDetector
+	trackingOpts.Cookieless = cfg.Tracking.Cookieless
+	trackingOpts.DNTPolicy = cfg.Tracking.DNTPolicy
+	trackingOpts.GPCPolicy = cfg.Tracking.GPCPolicy
+	trackingService := tracking.NewTrackingService(db, trackingOpts)
+	analyticsService := tracking.NewAnalyticsService(db, cfg.Tracking.RollupInterval)
 	commentService := comments.NewCommentService(db)
 
+	// 初始化用户服务，用户表为空时创建初始管理员
+	userService := users.NewUserService(db)
+	if err := userService.EnsureAdmin(cfg.Auth.AdminUser, cfg.Auth.AdminPassword); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// 初始化 JWT 签名密钥，退役密钥在访问令牌有效期内仍可用于验证
+	keyStore, err := keystore.NewKeyStore(db, cfg.Auth.JWTKeyID, cfg.Auth.JWTSecret, middleware.AccessTokenExpiration)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// 初始化登录会话服务
+	sessionService := sessions.NewSessionService(db)
+
+	// 初始化登录防暴力破解，失败记录保存在数据库中，重启后依然有效
+	policy := loginguard.DefaultPolicy()
+	policy.IPMaxFailures = cfg.Auth.LoginIPMaxFailures
+	policy.LockoutThreshold = cfg.Auth.LoginLockoutThreshold
+	policy.LockoutDuration = cfg.Auth.LoginLockoutDuration
+	loginGuard := loginguard.NewGuard(db, policy)
+
+	// 初始化个人 API 令牌服务
+	tokenService := apitokens.NewTokenService(db)
+
+	// 配置了 OIDC 时启用单点登录
+	var oidcProvider *oidc.Provider
+	if cfg.OIDC.Enabled() {
+		oidcProvider = oidc.NewProvider(oidc.Config{
+			Name:         cfg.OIDC.ProviderName,
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+			GroupsClaim:  cfg.OIDC.GroupsClaim,
+			RoleMappings: []oidc.RoleMapping{
+				{Role: users.RoleAdmin, Groups: cfg.OIDC.AdminGroups},
+				{Role: users.RoleEditor, Groups: cfg.OIDC.EditorGroups},
+				{Role: users.RoleAnalyst, Groups: cfg.OIDC.AnalystGroups},
+			},
+			DefaultRole:    cfg.OIDC.DefaultRole,
+			AllowedDomains: cfg.OIDC.AllowedDomains,
+		})
+		log.Printf("已启用 OIDC 单点登录: %s", cfg.OIDC.Issuer)
+	}
+
+	auth := middleware.NewAuth(userService, keyStore, sessionService, loginGuard, tokenService, middleware.CookieConfig{
+		SameSite: cfg.Auth.CookieSameSite,
+		Secure:   cfg.Auth.CookieSecure,
+	}, oidcProvider)
+
+	// 初始化审计日志
+	auditService := audit.NewAuditService(db)
+
+	// 初始化数据保留任务，按策略定期清理过期的埋点事件和个人信息
+	retentionService := retention.NewRetentionService(db, RetentionOptions(cfg.Retention))
+
 	// 初始化文件管理器
 	if err := filemanager.Init(); err != nil {
 		db.Close()
@@ -68,19 +158,100 @@ func NewServer(cfg *config.Config) (*Server, error) {
 	}
 
 	// 设置路由
-	engine := router.SetupRouter(trackingService, analyticsService, commentService)
+	engine := router.SetupRouter(cfg.Server, trackingService, analyticsService, commentService, userService, keyStore, sessionService, loginGuard, tokenService, auditService, retentionService, auth)
 
 	return &Server{
-		config: cfg,
-		db:     db,
-		engine: engine,
+		config:    cfg,
+		db:        db,
+		engine:    engine,
+		tracking:  trackingService,
+		analytics: analyticsService,
+		retention: retentionService,
 	}, nil
 }
 
-// Start 启动服务器
+// RetentionOptions 将数据保留配置转换为清理任务参数，命令行的 retention 子命令也使用它
+func RetentionOptions(cfg config.RetentionConfig) retention.Options {
+	return retention.Options{
+		TrackEventDays:          cfg.TrackEventDays,
+		TrackEventIPDays:        cfg.TrackEventIPDays,
+		TrackEventUserAgentDays: cfg.TrackEventUserAgentDays,
+		CommentIPDays:           cfg.CommentIPDays,
+		CommentUserAgentDays:    cfg.CommentUserAgentDays,
+		BatchSize:               cfg.BatchSize,
+		Interval:                cfg.Interval,
+		DryRun:                  cfg.DryRun,
+	}
+}
+
+// migrateSchema 执行未完成的数据库迁移；关闭自动迁移时只检查数据库是否已是最新版本
+func migrateSchema(db *sql.DB, autoMigrate bool) error {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if !autoMigrate {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return fmt.Errorf("数据库有 %d 个迁移尚未执行，请先运行 ./blog migrate up", pending)
+		}
+		return nil
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("数据库结构已是最新版本，本次执行了 %d 个迁移", applied)
+	return nil
+}
+
+// Start 启动服务器并阻塞，收到 SIGINT/SIGTERM 后优雅关闭
 func (s *Server) Start() error {
-	log.Printf("服务器启动在端口: %s", s.config.Server.Port)
-	return s.engine.Run(":" + s.config.Server.Port)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{
+		Addr:    ":" + s.config.Server.Port,
+		Handler: s.engine,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("服务器启动在端口: %s", s.config.Server.Port)
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		stop()
+		log.Println("收到退出信号，开始优雅关闭...")
+	}
+
+	// 先停止接收新请求并等待处理中的请求完成，再写入缓冲的埋点事件
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.Server.ShutdownTimeout)
+	defer cancel()
+
+	var shutdownErr error
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("关闭 HTTP 服务失败: %v", err)
+		shutdownErr = err
+	}
+	s.retention.Close()
+	s.analytics.Close()
+	if err := s.tracking.Close(shutdownCtx); err != nil {
+		log.Printf("关闭埋点服务失败: %v", err)
+		shutdownErr = err
+	}
+	log.Println("服务器已关闭")
+	return shutdownErr
 }
 
 // Close 关闭服务器资源
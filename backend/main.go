@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 	"time"
 
 	"blog/internal/config"
@@ -25,6 +26,14 @@ func main() {
 		log.Fatal("加载配置失败:", err)
 	}
 
+	// 命令行子命令，例如: ./blog create-admin -username admin -password xxx
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, os.Args[1:]); err != nil {
+			log.Fatal("执行命令失败:", err)
+		}
+		return
+	}
+
 	// 创建服务器
 	srv, err := server.NewServer(cfg)
 	if err != nil {
@@ -32,8 +41,9 @@ func main() {
 	}
 	defer srv.Close()
 
-	// 启动服务器
+	// 启动服务器，收到退出信号后优雅关闭
 	if err := srv.Start(); err != nil {
-		log.Fatal("服务器启动失败:", err)
+		srv.Close()
+		log.Fatal("服务器异常退出:", err)
 	}
 }
@@ -0,0 +1,84 @@
+package apitokens
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHandlers 注册当前用户管理自己 API 令牌的路由，调用方负责传入已挂载认证中间件的路由组
+func (ts *TokenService) RegisterHandlers(rg *gin.RouterGroup) {
+	group := rg.Group("/api/account/tokens")
+	{
+		group.GET("", ts.handleListTokens)
+		group.POST("", ts.handleCreateToken)
+		group.DELETE("/:id", ts.handleRevokeToken)
+	}
+}
+
+// handleListTokens 处理获取当前用户令牌列表的请求
+func (ts *TokenService) handleListTokens(c *gin.Context) {
+	list, err := ts.ListByUser(c.GetInt64("user_id"))
+	if err != nil {
+		log.Printf("获取 API 令牌列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取令牌列表失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": list,
+		"scopes": ValidScopes,
+	})
+}
+
+// handleCreateToken 处理创建令牌的请求，明文令牌只在响应中返回一次
+func (ts *TokenService) handleCreateToken(c *gin.Context) {
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > MaxExpiresInDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidExpiry.Error()})
+		return
+	}
+
+	token, plain, err := ts.Create(c.GetInt64("user_id"), req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		if errors.Is(err, ErrInvalidScope) || errors.Is(err, ErrInvalidName) || errors.Is(err, ErrInvalidExpiry) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建令牌失败"})
+		return
+	}
+
+	log.Printf("用户 %s 创建了 API 令牌: %s (%s)", c.GetString("username"), token.Name, token.Prefix)
+	c.JSON(http.StatusCreated, gin.H{
+		"token":   plain,
+		"details": token,
+	})
+}
+
+// handleRevokeToken 处理注销令牌的请求
+func (ts *TokenService) handleRevokeToken(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的令牌ID"})
+		return
+	}
+
+	if err := ts.Revoke(c.GetInt64("user_id"), id); err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销令牌失败"})
+		return
+	}
+
+	log.Printf("用户 %s 注销了 API 令牌: %d", c.GetString("username"), id)
+	c.JSON(http.StatusOK, gin.H{"message": "令牌已注销"})
+}
@@ -0,0 +1,61 @@
+package apitokens
+
+import (
+	"time"
+)
+
+// 令牌可授予的权限范围，与路由权限表中的权限名一致
+const (
+	ScopeFilesRead     = "files:read"
+	ScopeFilesWrite    = "files:write"
+	ScopeFilesDelete   = "files:delete"
+	ScopeBuildRun      = "build:run"
+	ScopeAnalyticsRead = "analytics:read"
+)
+
+// ValidScopes 全部可用的权限范围，账号和密钥管理类权限不允许授予 API 令牌
+var ValidScopes = []string{
+	ScopeFilesRead,
+	ScopeFilesWrite,
+	ScopeFilesDelete,
+	ScopeBuildRun,
+	ScopeAnalyticsRead,
+}
+
+// ValidScope 判断权限范围是否有效
+func ValidScope(scope string) bool {
+	for _, s := range ValidScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Token 个人 API 令牌，明文只在创建时返回一次，数据库中只保存哈希
+type Token struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // 令牌前几位，便于用户辨认
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"` // 为空表示永不过期
+}
+
+// TokenOwner 通过令牌认证得到的身份
+type TokenOwner struct {
+	TokenID  int64
+	UserID   int64
+	Username string
+	Role     string
+	Scopes   []string
+}
+
+// CreateTokenRequest 创建令牌请求
+type CreateTokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 表示永不过期，最长 3650 天
+}
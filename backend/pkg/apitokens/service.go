@@ -0,0 +1,225 @@
+package apitokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// TokenPrefix 令牌固定前缀，便于在代码仓库和日志中识别泄露的令牌
+	TokenPrefix = "mbt_"
+	// lastUsedInterval 最近使用时间的更新间隔，避免每个请求都写数据库
+	lastUsedInterval = time.Minute
+	// revokedRetention 已注销或已过期令牌的保留时长
+	revokedRetention = 30 * 24 * time.Hour
+	// MaxExpiresInDays 令牌有效期上限（天），更大的值会使过期时间溢出
+	MaxExpiresInDays = 3650
+)
+
+var (
+	ErrTokenInvalid  = errors.New("API 令牌无效或已过期")
+	ErrTokenNotFound = errors.New("令牌不存在")
+	ErrInvalidScope  = errors.New("无效的权限范围")
+	ErrInvalidName   = errors.New("令牌名称不能为空且不能超过 100 个字符")
+	ErrInvalidExpiry = errors.New("有效期必须在 0 到 3650 天之间，0 表示永不过期")
+)
+
+// TokenService 管理个人 API 令牌
+type TokenService struct {
+	db *sql.DB
+}
+
+// NewTokenService 创建新的 API 令牌服务
+func NewTokenService(db *sql.DB) *TokenService {
+	ts := &TokenService{
+		db: db,
+	}
+
+	// 启动过期令牌清理协程
+	go ts.cleanupLoop()
+
+	return ts
+}
+
+// hashToken 计算令牌哈希，数据库中只保存哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create 为用户创建新令牌，返回令牌信息和只展示一次的明文
+func (ts *TokenService) Create(userID int64, name string, scopes []string, expiresInDays int) (*Token, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, "", ErrInvalidName
+	}
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidScope
+	}
+	if expiresInDays < 0 || expiresInDays > MaxExpiresInDays {
+		return nil, "", ErrInvalidExpiry
+	}
+	seen := make(map[string]bool, len(scopes))
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !ValidScope(scope) {
+			return nil, "", ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	plain := TokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	token := &Token{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plain[:len(TokenPrefix)+8],
+		Scopes:    unique,
+		CreatedAt: now,
+	}
+	if expiresInDays > 0 {
+		expiresAt := now.Add(time.Duration(expiresInDays) * 24 * time.Hour)
+		token.ExpiresAt = &expiresAt
+	}
+
+	err := ts.db.QueryRow(`
+		INSERT INTO api_tokens(user_id, name, prefix, token_hash, scopes, created_at, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, userID, token.Name, token.Prefix, hashToken(plain), strings.Join(unique, ","), now, token.ExpiresAt).Scan(&token.ID)
+	if err != nil {
+		log.Printf("创建 API 令牌失败: %v", err)
+		return nil, "", err
+	}
+
+	return token, plain, nil
+}
+
+// Authenticate 校验令牌明文，返回令牌所属用户及其权限范围
+func (ts *TokenService) Authenticate(plain string) (*TokenOwner, error) {
+	if !strings.HasPrefix(plain, TokenPrefix) {
+		return nil, ErrTokenInvalid
+	}
+
+	var owner TokenOwner
+	var scopes string
+	var expiresAt, lastUsedAt sql.NullTime
+	err := ts.db.QueryRow(`
+		SELECT t.id, t.user_id, u.username, u.role, t.scopes, t.expires_at, t.last_used_at
+		FROM api_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND t.revoked_at IS NULL AND NOT u.disabled
+	`, hashToken(plain)).Scan(&owner.TokenID, &owner.UserID, &owner.Username, &owner.Role,
+		&scopes, &expiresAt, &lastUsedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if expiresAt.Valid && now.After(expiresAt.Time) {
+		return nil, ErrTokenInvalid
+	}
+	owner.Scopes = splitScopes(scopes)
+
+	if !lastUsedAt.Valid || now.Sub(lastUsedAt.Time) > lastUsedInterval {
+		if _, err := ts.db.Exec("UPDATE api_tokens SET last_used_at = $1 WHERE id = $2", now, owner.TokenID); err != nil {
+			log.Printf("更新 API 令牌使用时间失败: %v", err)
+		}
+	}
+
+	return &owner, nil
+}
+
+// ListByUser 获取用户所有未注销的令牌
+func (ts *TokenService) ListByUser(userID int64) ([]Token, error) {
+	rows, err := ts.db.Query(`
+		SELECT id, user_id, name, prefix, scopes, created_at, last_used_at, expires_at
+		FROM api_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]Token, 0)
+	for rows.Next() {
+		var t Token
+		var scopes string
+		var lastUsedAt, expiresAt sql.NullTime
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &scopes, &t.CreatedAt, &lastUsedAt, &expiresAt); err != nil {
+			log.Printf("扫描 API 令牌数据失败: %v", err)
+			continue
+		}
+		t.Scopes = splitScopes(scopes)
+		if lastUsedAt.Valid {
+			t.LastUsedAt = &lastUsedAt.Time
+		}
+		if expiresAt.Valid {
+			t.ExpiresAt = &expiresAt.Time
+		}
+		result = append(result, t)
+	}
+	return result, rows.Err()
+}
+
+// Revoke 注销用户自己的令牌
+func (ts *TokenService) Revoke(userID, tokenID int64) error {
+	result, err := ts.db.Exec(`
+		UPDATE api_tokens SET revoked_at = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL
+	`, time.Now(), tokenID, userID)
+	if err != nil {
+		log.Printf("注销 API 令牌失败: %v", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// splitScopes 将逗号分隔的权限范围拆分为切片
+func splitScopes(scopes string) []string {
+	if scopes == "" {
+		return []string{}
+	}
+	return strings.Split(scopes, ",")
+}
+
+// cleanupLoop 定期删除已注销或已过期超过保留期限的令牌
+func (ts *TokenService) cleanupLoop() {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cutoff := time.Now().Add(-revokedRetention)
+		result, err := ts.db.Exec("DELETE FROM api_tokens WHERE revoked_at < $1 OR expires_at < $1", cutoff)
+		if err != nil {
+			log.Printf("清理过期 API 令牌失败: %v", err)
+			continue
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			log.Printf("已清理 %d 个过期 API 令牌", n)
+		}
+	}
+}
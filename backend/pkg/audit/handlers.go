@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHandlers 注册审计日志查询路由，调用方负责传入已挂载认证和授权中间件的路由组
+func (as *AuditService) RegisterHandlers(rg *gin.RouterGroup) {
+	rg.GET("/api/audit", as.handleListLogs)
+}
+
+// handleListLogs 处理审计日志查询请求
+// 支持参数：actor、action、target（前缀）、from、to（RFC 3339 或 2006-01-02）、page、page_size
+func (as *AuditService) handleListLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+
+	filter := Filter{
+		Actor:  c.Query("actor"),
+		Action: c.Query("action"),
+		Target: c.Query("target"),
+	}
+	var ok bool
+	if filter.From, ok = parseTimeParam(c, "from"); !ok {
+		return
+	}
+	if filter.To, ok = parseTimeParam(c, "to"); !ok {
+		return
+	}
+
+	logs, total, err := as.List(filter, pageSize, (page-1)*pageSize)
+	if err != nil {
+		log.Printf("查询审计日志失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询审计日志失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"items":     logs,
+	})
+}
+
+// parseTimeParam 解析时间查询参数，格式错误时写入 400 响应并返回 false
+func parseTimeParam(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return &t, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时间参数: " + name})
+	return nil, false
+}
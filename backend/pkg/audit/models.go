@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"time"
+)
+
+// 审计动作名称
+const (
+	ActionFileCreate    = "file.create"
+	ActionFileUpdate    = "file.update"
+	ActionFileDelete    = "file.delete"
+	ActionFileRename    = "file.rename"
+	ActionSiteBuild     = "site.build"
+	ActionCommentDelete = "comment.delete"
+	ActionCommentStatus = "comment.status"
+)
+
+// Entry 处理函数通过 Record 提交的审计事件
+type Entry struct {
+	Action     string // 动作，如 file.delete；未显式记录时为 "方法 路由"
+	Target     string // 操作对象，如文件名或评论ID
+	BeforeHash string // 操作前内容的 SHA-256，不存在时为空
+	AfterHash  string // 操作后内容的 SHA-256，删除时为空
+	Details    string // 补充说明
+}
+
+// Log 一条审计日志
+type Log struct {
+	ID         int64     `json:"id"`
+	ActorID    *int64    `json:"actor_id"`    // 操作者用户ID，匿名请求为空
+	Actor      string    `json:"actor"`       // 操作者用户名，保留用户被删除前的名称
+	AuthMethod string    `json:"auth_method"` // 认证方式：session、token 或 anonymous
+	Action     string    `json:"action"`
+	Target     string    `json:"target"`
+	BeforeHash string    `json:"before_hash"`
+	AfterHash  string    `json:"after_hash"`
+	Details    string    `json:"details"`
+	Status     int       `json:"status"` // HTTP 响应状态码
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Filter 审计日志查询条件，空值表示不过滤
+type Filter struct {
+	Actor  string
+	Action string
+	Target string // 前缀匹配
+	From   *time.Time
+	To     *time.Time
+}
@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// entriesKey 请求上下文中暂存审计事件的键
+const entriesKey = "audit_entries"
+
+// AuditService 审计日志服务
+type AuditService struct {
+	db *sql.DB
+}
+
+// NewAuditService 创建新的审计日志服务
+func NewAuditService(db *sql.DB) *AuditService {
+	return &AuditService{
+		db: db,
+	}
+}
+
+// HashContent 计算内容的 SHA-256，用于记录操作前后的内容指纹
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Record 在处理函数中提交审计事件，由 Middleware 在请求结束后统一写入
+// 一个请求可以提交多条事件（如批量上传）
+func Record(c *gin.Context, entry Entry) {
+	var entries []Entry
+	if v, ok := c.Get(entriesKey); ok {
+		entries = v.([]Entry)
+	}
+	c.Set(entriesKey, append(entries, entry))
+}
+
+// Middleware 审计中间件，注册为全局中间件
+// 请求结束后写入处理函数提交的事件；已登录用户的修改类请求即使没有显式提交事件也会记录一条
+func (as *AuditService) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		var entries []Entry
+		if v, ok := c.Get(entriesKey); ok {
+			entries = v.([]Entry)
+		}
+		_, authenticated := c.Get("user_id")
+		if len(entries) == 0 {
+			if !authenticated || isSafeMethod(c.Request.Method) {
+				return
+			}
+			entries = []Entry{{
+				Action: c.Request.Method + " " + c.FullPath(),
+				Target: c.Request.URL.Path,
+			}}
+		}
+
+		base := Log{
+			Actor:      "anonymous",
+			AuthMethod: "anonymous",
+			Status:     c.Writer.Status(),
+			IPAddress:  c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			CreatedAt:  time.Now(),
+		}
+		if authenticated {
+			actorID := c.GetInt64("user_id")
+			base.ActorID = &actorID
+			base.Actor = c.GetString("username")
+			base.AuthMethod = "session"
+			if _, ok := c.Get("api_token_id"); ok {
+				base.AuthMethod = "token"
+			}
+		}
+
+		for _, e := range entries {
+			entry := base
+			entry.Action, entry.Target, entry.Details = e.Action, e.Target, e.Details
+			entry.BeforeHash, entry.AfterHash = e.BeforeHash, e.AfterHash
+			if err := as.insert(&entry); err != nil {
+				// 审计日志写入失败时至少保留在应用日志中
+				log.Printf("写入审计日志失败: %v, entry=%+v", err, entry)
+			}
+		}
+	}
+}
+
+// isSafeMethod 判断请求方法是否不会修改服务端状态
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func (as *AuditService) insert(entry *Log) error {
+	_, err := as.db.Exec(`
+		INSERT INTO audit_log(actor_id, actor, auth_method, action, target, before_hash, after_hash,
+			details, status, ip_address, user_agent, created_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, entry.ActorID, entry.Actor, entry.AuthMethod, entry.Action, entry.Target, entry.BeforeHash, entry.AfterHash,
+		entry.Details, entry.Status, entry.IPAddress, entry.UserAgent, entry.CreatedAt)
+	return err
+}
+
+// List 按条件分页查询审计日志，返回当前页记录和总数
+func (as *AuditService) List(filter Filter, limit, offset int) ([]Log, int, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.Target != "" {
+		add("target LIKE $%d", escapeLike(filter.Target)+"%")
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := as.db.QueryRow("SELECT COUNT(*) FROM audit_log "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, limit, offset)
+	rows, err := as.db.Query(fmt.Sprintf(`
+		SELECT id, actor_id, actor, auth_method, action, target, before_hash, after_hash,
+			details, status, COALESCE(ip_address, ''), COALESCE(user_agent, ''), created_at
+		FROM audit_log
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	result := make([]Log, 0)
+	for rows.Next() {
+		var l Log
+		var actorID sql.NullInt64
+		if err := rows.Scan(&l.ID, &actorID, &l.Actor, &l.AuthMethod, &l.Action, &l.Target, &l.BeforeHash,
+			&l.AfterHash, &l.Details, &l.Status, &l.IPAddress, &l.UserAgent, &l.CreatedAt); err != nil {
+			log.Printf("扫描审计日志失败: %v", err)
+			continue
+		}
+		if actorID.Valid {
+			l.ActorID = &actorID.Int64
+		}
+		result = append(result, l)
+	}
+	return result, total, rows.Err()
+}
+
+// escapeLike 转义 LIKE 模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,130 @@
+package bots
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// 判断为机器人的依据
+const (
+	ReasonUserAgent = "user_agent" // User-Agent 匹配已知特征
+	ReasonIP        = "ip"         // 来自已知爬虫的地址段
+	ReasonHeadless  = "headless"   // 无头浏览器或自动化工具的迹象
+)
+
+// Request 判断请求是否来自机器人所需的信息
+type Request struct {
+	UserAgent string
+	IP        string
+	Header    http.Header // 原始请求头，为 nil 时跳过基于请求头的判断
+	WebDriver bool        // 客户端上报的 navigator.webdriver，自动化工具控制的浏览器为 true
+}
+
+// Result 识别结果
+type Result struct {
+	IsBot  bool
+	Name   string // 机器人名称，如 Googlebot、curl
+	Reason string
+}
+
+type namedPrefix struct {
+	name   string
+	prefix netip.Prefix
+}
+
+// Detector 根据 User-Agent、IP 地址段和无头浏览器特征识别机器人流量，可以并发使用
+type Detector struct {
+	ranges []namedPrefix
+}
+
+// NewDetector 创建识别器，extraRanges 为额外的机器人地址段（CIDR 或单个 IP），如内部监控服务的出口地址
+func NewDetector(extraRanges []string) (*Detector, error) {
+	d := &Detector{}
+	for _, r := range crawlerRanges {
+		for _, cidr := range r.cidrs {
+			d.ranges = append(d.ranges, namedPrefix{r.name, netip.MustParsePrefix(cidr)})
+		}
+	}
+	for _, value := range extraRanges {
+		prefix, err := parsePrefix(value)
+		if err != nil {
+			return nil, err
+		}
+		d.ranges = append(d.ranges, namedPrefix{"Custom", prefix})
+	}
+	return d, nil
+}
+
+func parsePrefix(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("无效的机器人地址段: %q", value)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("无效的机器人地址段: %q", value)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Detect 判断请求是否来自机器人，依次检查 User-Agent、IP 地址段和无头浏览器特征
+func (d *Detector) Detect(r Request) Result {
+	ua := strings.ToLower(strings.TrimSpace(r.UserAgent))
+	if ua == "" {
+		return Result{IsBot: true, Name: "Empty User-Agent", Reason: ReasonUserAgent}
+	}
+	if name := matchUserAgent(ua); name != "" {
+		return Result{IsBot: true, Name: name, Reason: ReasonUserAgent}
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.IP)); err == nil {
+		addr = addr.Unmap()
+		for _, p := range d.ranges {
+			if p.prefix.Contains(addr) {
+				return Result{IsBot: true, Name: p.name, Reason: ReasonIP}
+			}
+		}
+	}
+
+	if r.WebDriver {
+		return Result{IsBot: true, Name: "WebDriver", Reason: ReasonHeadless}
+	}
+	if r.Header != nil {
+		// 新版无头 Chrome 的 User-Agent 与普通 Chrome 相同，但客户端提示中仍带有 HeadlessChrome
+		if strings.Contains(strings.ToLower(r.Header.Get("Sec-CH-UA")), "headless") {
+			return Result{IsBot: true, Name: "HeadlessChrome", Reason: ReasonHeadless}
+		}
+		// 真实浏览器的请求都带 Accept-Language，伪装成浏览器的脚本通常不带
+		if strings.HasPrefix(ua, "mozilla/") && r.Header.Get("Accept-Language") == "" {
+			return Result{IsBot: true, Name: "Headless", Reason: ReasonHeadless}
+		}
+	}
+	return Result{}
+}
+
+// matchUserAgent 返回 User-Agent（已转为小写）匹配的机器人名称，不匹配时返回空字符串
+func matchUserAgent(ua string) string {
+	for _, s := range signatures {
+		for _, token := range s.tokens {
+			if strings.Contains(ua, token) {
+				return s.name
+			}
+		}
+	}
+
+	for _, exception := range genericExceptions {
+		ua = strings.ReplaceAll(ua, exception, "")
+	}
+	for _, token := range genericTokens {
+		if strings.Contains(ua, token) {
+			return "Other"
+		}
+	}
+	return ""
+}
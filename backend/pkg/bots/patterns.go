@@ -0,0 +1,103 @@
+package bots
+
+// 已知机器人的特征库。新增爬虫或监控服务时在这里追加，按列表顺序匹配，越具体的特征越靠前。
+// token 为 User-Agent 中的小写子串。
+
+// signature 一类机器人的 User-Agent 特征
+type signature struct {
+	name   string
+	tokens []string
+}
+
+var signatures = []signature{
+	// 搜索引擎
+	{"Googlebot", []string{"googlebot", "google-inspectiontool", "googleother", "adsbot-google", "mediapartners-google", "apis-google", "feedfetcher-google", "storebot-google", "google-read-aloud"}},
+	{"Bingbot", []string{"bingbot", "bingpreview", "msnbot", "adidxbot"}},
+	{"Baiduspider", []string{"baiduspider"}},
+	{"YandexBot", []string{"yandexbot", "yandexmobilebot", "yandeximages", "yandexmetrika"}},
+	{"Sogou", []string{"sogou web spider", "sogou inst spider", "sogou spider"}},
+	{"360Spider", []string{"360spider", "haosouspider"}},
+	{"Bytespider", []string{"bytespider"}},
+	{"YisouSpider", []string{"yisouspider"}},
+	{"PetalBot", []string{"petalbot"}},
+	{"DuckDuckBot", []string{"duckduckbot", "duckassistbot"}},
+	{"Applebot", []string{"applebot"}},
+	{"Yahoo Slurp", []string{"yahoo! slurp"}},
+	{"SeznamBot", []string{"seznambot"}},
+
+	// AI 爬虫
+	{"GPTBot", []string{"gptbot", "chatgpt-user", "oai-searchbot"}},
+	{"ClaudeBot", []string{"claudebot", "claude-web", "anthropic-ai"}},
+	{"PerplexityBot", []string{"perplexitybot", "perplexity-user"}},
+	{"CCBot", []string{"ccbot"}},
+	{"Amazonbot", []string{"amazonbot"}},
+	{"Meta", []string{"meta-externalagent", "meta-externalfetcher", "facebookexternalhit", "facebookcatalog"}},
+
+	// 社交平台和聊天软件的链接预览
+	{"Twitterbot", []string{"twitterbot"}},
+	{"LinkedInBot", []string{"linkedinbot"}},
+	{"Slackbot", []string{"slackbot", "slack-imgproxy"}},
+	{"Discordbot", []string{"discordbot"}},
+	{"TelegramBot", []string{"telegrambot"}},
+	{"WhatsApp", []string{"whatsapp/"}},
+	{"Pinterestbot", []string{"pinterestbot"}},
+
+	// SEO 工具
+	{"AhrefsBot", []string{"ahrefsbot", "ahrefssiteaudit"}},
+	{"SemrushBot", []string{"semrushbot", "siteauditbot"}},
+	{"MJ12bot", []string{"mj12bot"}},
+	{"DotBot", []string{"dotbot"}},
+	{"DataForSeoBot", []string{"dataforseobot"}},
+	{"BLEXBot", []string{"blexbot"}},
+	{"SerpstatBot", []string{"serpstatbot"}},
+
+	// 可用性监控和性能测试
+	{"UptimeRobot", []string{"uptimerobot"}},
+	{"Pingdom", []string{"pingdom"}},
+	{"StatusCake", []string{"statuscake"}},
+	{"Site24x7", []string{"site24x7"}},
+	{"Better Stack", []string{"betteruptime", "better stack", "betterstack"}},
+	{"Uptime Kuma", []string{"uptime-kuma"}},
+	{"HetrixTools", []string{"hetrixtools"}},
+	{"Freshping", []string{"freshping"}},
+	{"Jetpack", []string{"jetmon"}},
+	{"Datadog", []string{"datadogsynthetics"}},
+	{"New Relic", []string{"newrelicpinger"}},
+	{"Lighthouse", []string{"chrome-lighthouse", "lighthouse"}},
+	{"GTmetrix", []string{"gtmetrix"}},
+
+	// 无头浏览器和自动化工具
+	{"HeadlessChrome", []string{"headlesschrome"}},
+	{"PhantomJS", []string{"phantomjs"}},
+	{"Selenium", []string{"selenium"}},
+
+	// 命令行工具和 HTTP 客户端库
+	{"curl", []string{"curl/"}},
+	{"Wget", []string{"wget/"}},
+	{"Python", []string{"python-requests", "python-urllib", "python-httpx", "aiohttp", "httpx/"}},
+	{"Go", []string{"go-http-client"}},
+	{"Java", []string{"java/", "okhttp", "apache-httpclient"}},
+	{"Node.js", []string{"node-fetch", "axios/", "undici"}},
+	{"Scrapy", []string{"scrapy"}},
+	{"Perl", []string{"libwww-perl"}},
+	{"Postman", []string{"postmanruntime"}},
+}
+
+// genericTokens 通用的机器人关键字，在具体特征都不匹配时使用
+var genericTokens = []string{"bot", "crawler", "crawl", "spider", "slurp", "scraper", "fetcher", "monitor", "headless"}
+
+// genericExceptions 包含通用关键字的真实浏览器或设备
+var genericExceptions = []string{"cubot"}
+
+// ipRange 只通过 IP 识别的已知爬虫地址段（伪造 User-Agent 的流量也能识别）
+type ipRange struct {
+	name  string
+	cidrs []string
+}
+
+var crawlerRanges = []ipRange{
+	{"Googlebot", []string{"66.249.64.0/19"}},
+	{"Bingbot", []string{"157.55.39.0/24", "207.46.13.0/24", "40.77.167.0/24"}},
+	{"Baiduspider", []string{"180.76.15.0/24", "220.181.108.0/24"}},
+	{"UptimeRobot", []string{"69.162.124.224/28", "63.143.42.240/28"}},
+}
@@ -8,19 +8,23 @@ import (
 	"strconv"
 	"strings"
 
+	"blog/pkg/audit"
+
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterHandlers 注册评论系统相关的路由处理函数
+// RegisterHandlers 注册评论系统的公开路由（发表和查看评论）
 func (cs *CommentService) RegisterHandlers(r *gin.Engine) {
 	// 创建评论
 	r.POST("/api/comments", cs.handleAddComment)
 
 	// 获取文章评论
 	r.GET("/api/comments/:articleId", cs.handleGetCommentsByArticle)
+}
 
-	// 管理API，可以增加授权中间件
-	admin := r.Group("/api/admin/comments")
+// RegisterAdminHandlers 注册评论审核路由，调用方负责传入已挂载认证和授权中间件的路由组
+func (cs *CommentService) RegisterAdminHandlers(rg *gin.RouterGroup) {
+	admin := rg.Group("/api/admin/comments")
 	{
 		admin.DELETE("/:id", cs.handleDeleteComment)
 		admin.PUT("/:id/status", cs.handleUpdateCommentStatus)
@@ -60,6 +64,12 @@ func (cs *CommentService) handleAddComment(c *gin.Context) {
 	// 记录文章ID，便于调试中文问题
 	log.Printf("收到评论请求，文章ID: %s", req.ArticleID)
 
+	// 前端埋点的设备指纹，访客请求删除个人数据时据此找到评论；超长的值不是有效指纹
+	deviceID := strings.TrimSpace(c.GetHeader("X-Device-Fingerprint"))
+	if len(deviceID) > 100 {
+		deviceID = ""
+	}
+
 	// 创建评论对象
 	comment := &Comment{
 		ArticleID: req.ArticleID,
@@ -70,6 +80,7 @@ func (cs *CommentService) handleAddComment(c *gin.Context) {
 		ReplyTo:   req.ReplyTo,
 		IPAddress: c.ClientIP(),
 		UserAgent: c.Request.UserAgent(),
+		DeviceID:  deviceID,
 	}
 
 	// 添加评论
@@ -146,11 +157,19 @@ func (cs *CommentService) handleDeleteComment(c *gin.Context) {
 		return
 	}
 
+	// 记录被删除评论的内容指纹，便于事后核对
+	entry := audit.Entry{Action: audit.ActionCommentDelete, Target: "comment:" + idStr}
+	if comment, err := cs.GetComment(id); err == nil {
+		entry.BeforeHash = audit.HashContent(comment.Content)
+		entry.Details = fmt.Sprintf("文章: %s, 昵称: %s", comment.ArticleID, comment.Nickname)
+	}
+
 	if err := cs.DeleteComment(id); err != nil {
 		log.Printf("删除评论失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除评论失败"})
 		return
 	}
+	audit.Record(c, entry)
 
 	c.JSON(http.StatusOK, gin.H{"message": "评论已成功删除"})
 }
@@ -179,11 +198,19 @@ func (cs *CommentService) handleUpdateCommentStatus(c *gin.Context) {
 		return
 	}
 
+	entry := audit.Entry{Action: audit.ActionCommentStatus, Target: "comment:" + idStr, Details: "-> " + status}
+	if comment, err := cs.GetComment(id); err == nil {
+		entry.BeforeHash = audit.HashContent(comment.Content)
+		entry.AfterHash = entry.BeforeHash
+		entry.Details = comment.Status + " -> " + status
+	}
+
 	if err := cs.UpdateCommentStatus(id, status); err != nil {
 		log.Printf("更新评论状态失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新评论状态失败"})
 		return
 	}
+	audit.Record(c, entry)
 
 	c.JSON(http.StatusOK, gin.H{"message": "评论状态已更新"})
 }
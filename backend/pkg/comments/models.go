@@ -16,6 +16,7 @@ type Comment struct {
 	Status    string    `json:"status"`     // 状态
 	ReplyTo   *int      `json:"reply_to"`   // 回复的评论ID
 	UserAgent string    `json:"user_agent"` // 用户代理
+	DeviceID  string    `json:"device_id"`  // 前端埋点的设备指纹，用于按设备删除个人数据
 }
 
 // CommentRequest 代表评论请求
@@ -18,59 +18,15 @@ func NewCommentService(db *sql.DB) *CommentService {
 	}
 }
 
-// Init 初始化评论系统数据库表
-func (cs *CommentService) Init() error {
-	// 创建评论表
-	_, err := cs.db.Exec(`
-		CREATE TABLE IF NOT EXISTS comments (
-			id SERIAL PRIMARY KEY,
-			article_id VARCHAR(100) NOT NULL,
-			nickname VARCHAR(100) NOT NULL,
-			email VARCHAR(100),
-			content TEXT NOT NULL,
-			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			ip_address VARCHAR(50),
-			status VARCHAR(20) DEFAULT 'approved',
-			reply_to INTEGER DEFAULT NULL,
-			user_agent TEXT
-		)
-	`)
-	if err != nil {
-		log.Printf("创建评论表失败: %v", err)
-		return err
-	}
-
-	// 创建索引
-	_, err = cs.db.Exec(`
-		CREATE INDEX IF NOT EXISTS idx_comments_article_id ON comments(article_id);
-		CREATE INDEX IF NOT EXISTS idx_comments_created_at ON comments(created_at);
-		CREATE INDEX IF NOT EXISTS idx_comments_status ON comments(status);
-		CREATE INDEX IF NOT EXISTS idx_comments_reply_to ON comments(reply_to);
-	`)
-	if err != nil {
-		log.Printf("创建评论索引失败: %v", err)
-		return err
-	}
-
-	log.Println("评论系统数据库初始化成功")
-	return nil
-}
-
 // AddComment 添加一条评论
 func (cs *CommentService) AddComment(comment *Comment) (int, error) {
-	// 设置客户端编码为UTF8
-	_, err := cs.db.Exec("SET client_encoding = 'UTF8'")
-	if err != nil {
-		log.Printf("设置客户端编码失败: %v", err)
-	}
-
 	var id int
-	err = cs.db.QueryRow(`
-		INSERT INTO comments(article_id, nickname, email, content, created_at, ip_address, status, reply_to, user_agent)
-		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	err := cs.db.QueryRow(`
+		INSERT INTO comments(article_id, nickname, email, content, created_at, ip_address, status, reply_to, user_agent, device_id)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))
 		RETURNING id
 	`, comment.ArticleID, comment.Nickname, comment.Email, comment.Content,
-		time.Now(), comment.IPAddress, comment.Status, comment.ReplyTo, comment.UserAgent).Scan(&id)
+		time.Now(), comment.IPAddress, comment.Status, comment.ReplyTo, comment.UserAgent, comment.DeviceID).Scan(&id)
 
 	if err != nil {
 		log.Printf("添加评论失败: %v", err)
@@ -82,17 +38,12 @@ func (cs *CommentService) AddComment(comment *Comment) (int, error) {
 
 // GetCommentsByArticle 获取文章的评论
 func (cs *CommentService) GetCommentsByArticle(articleID string) ([]Comment, error) {
-	// 设置客户端编码为UTF8
-	_, err := cs.db.Exec("SET client_encoding = 'UTF8'")
-	if err != nil {
-		log.Printf("设置客户端编码失败: %v", err)
-	}
-
 	log.Printf("执行查询，参数: articleID=%s", articleID)
 
 	// 构建SQL
 	query := `
-		SELECT id, article_id, nickname, email, content, created_at, ip_address, status, reply_to, user_agent
+		SELECT id, article_id, nickname, COALESCE(email, ''), content, created_at,
+			COALESCE(ip_address, ''), status, reply_to, COALESCE(user_agent, '')
 		FROM comments
 		WHERE article_id = $1 AND status = 'approved'
 		ORDER BY created_at DESC
@@ -169,6 +120,24 @@ func (cs *CommentService) BuildCommentTree(comments []Comment) []CommentResponse
 	return rootComments
 }
 
+// GetComment 根据ID获取评论
+func (cs *CommentService) GetComment(id int) (*Comment, error) {
+	var comment Comment
+	var email, ipAddress, userAgent sql.NullString
+	err := cs.db.QueryRow(`
+		SELECT id, article_id, nickname, email, content, created_at, ip_address, status, reply_to, user_agent
+		FROM comments WHERE id = $1
+	`, id).Scan(&comment.ID, &comment.ArticleID, &comment.Nickname, &email, &comment.Content,
+		&comment.CreatedAt, &ipAddress, &comment.Status, &comment.ReplyTo, &userAgent)
+	if err != nil {
+		return nil, err
+	}
+	comment.Email = email.String
+	comment.IPAddress = ipAddress.String
+	comment.UserAgent = userAgent.String
+	return &comment, nil
+}
+
 // DeleteComment 删除评论
 func (cs *CommentService) DeleteComment(id int) error {
 	_, err := cs.db.Exec("DELETE FROM comments WHERE id = $1", id)
@@ -120,11 +120,17 @@ func GetFileContent(filename string) (string, error) {
 	return string(content), nil
 }
 
-// SaveFile 保存文件
-func SaveFile(filename string, content string) error {
+// NormalizeFilename 补全文章文件扩展名，与 SaveFile 实际写入的文件名一致
+func NormalizeFilename(filename string) string {
 	if !strings.HasSuffix(filename, ".md") && !strings.HasSuffix(filename, ".markdown") {
 		filename = filename + ".md"
 	}
+	return filename
+}
+
+// SaveFile 保存文件
+func SaveFile(filename string, content string) error {
+	filename = NormalizeFilename(filename)
 
 	fullPath, _, err := resolvePath(filename)
 	if err != nil {
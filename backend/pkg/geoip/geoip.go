@@ -0,0 +1,86 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// Location IP 地址对应的地理位置，查不到的字段为空
+type Location struct {
+	Country string // ISO 3166-1 国家代码，如 CN
+	Region  string // 一级行政区（省、州）名称
+	City    string
+}
+
+// DB 离线 GeoIP 数据库，支持 MaxMind GeoIP2/GeoLite2 和 DB-IP 的 City、Country 格式的 MMDB 文件。
+// 文件在打开时全部读入内存，查询不访问磁盘，可以并发使用
+type DB struct {
+	reader    *mmdbReader
+	languages []string
+}
+
+// Open 打开 MMDB 文件，地名优先使用 language（如 zh-CN），没有该语言时使用英文
+func Open(path, language string) (*DB, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 GeoIP 数据库失败: %w", err)
+	}
+	reader, err := newMMDBReader(buf)
+	if err != nil {
+		return nil, fmt.Errorf("打开 GeoIP 数据库 %s 失败: %w", path, err)
+	}
+
+	db := &DB{reader: reader}
+	if language != "" {
+		db.languages = append(db.languages, language)
+	}
+	if language != "en" {
+		db.languages = append(db.languages, "en")
+	}
+	return db, nil
+}
+
+// Metadata 返回数据库的元数据
+func (db *DB) Metadata() Metadata {
+	return db.reader.meta
+}
+
+// ErrInvalidIP 传给 Lookup 的不是有效的 IP 地址
+var ErrInvalidIP = errors.New("无效的 IP 地址")
+
+// Lookup 查询 IP 地址的地理位置，私有地址等数据库中没有的地址返回空的 Location
+func (db *DB) Lookup(ip string) (Location, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return Location{}, fmt.Errorf("%w: %q", ErrInvalidIP, ip)
+	}
+
+	value, err := db.reader.lookup(addr)
+	if err != nil || value == nil {
+		return Location{}, err
+	}
+	record := asMap(value)
+
+	loc := Location{
+		Country: asString(asMap(record["country"])["iso_code"]),
+		City:    db.name(asMap(record["city"])),
+	}
+	if subdivisions, ok := record["subdivisions"].([]any); ok && len(subdivisions) > 0 {
+		loc.Region = db.name(asMap(subdivisions[0]))
+	}
+	return loc, nil
+}
+
+// name 按语言偏好返回 names 中的地名
+func (db *DB) name(place map[string]any) string {
+	names := asMap(place["names"])
+	for _, lang := range db.languages {
+		if name := asString(names[lang]); name != "" {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,419 @@
+package geoip
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "重新生成 testdata 中的样例 MMDB 文件")
+
+const fixturePath = "testdata/test-city.mmdb"
+
+// names 生成地名映射，参数依次为语言和名称
+func names(pairs ...string) map[string]any {
+	m := make(map[string]any, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		m[pairs[i]] = pairs[i+1]
+	}
+	return m
+}
+
+// buildFixture 生成样例数据库，网段和记录与 GeoIP2-City 的结构一致：
+//
+//	1.2.3.0/24     中国 浙江省 杭州（国家记录通过指针共用）
+//	1.2.4.0/24     中国 北京市 北京
+//	8.8.8.0/24     美国 California Mountain View（只有英文地名）
+//	81.2.69.0/24   英国，没有省和城市
+//	2001:db8::/32  德国 Bayern München（德文和英文地名）
+func buildFixture(ipVersion, recordSize int) []byte {
+	w := newMMDBWriter(ipVersion, recordSize)
+	cn := w.addData(map[string]any{
+		"geoname_id": uint32Value(1814991),
+		"iso_code":   "CN",
+		"names":      names("de", "China", "en", "China", "zh-CN", "中国"),
+	})
+	w.insert("1.2.3.0/24", w.addData(map[string]any{
+		"city":    map[string]any{"geoname_id": uint32Value(1808926), "names": names("en", "Hangzhou", "zh-CN", "杭州")},
+		"country": pointerTo(cn),
+		"location": map[string]any{
+			"accuracy_radius": uint16Value(50),
+			"latitude":        30.2936,
+			"longitude":       120.1614,
+		},
+		"subdivisions": []any{map[string]any{"iso_code": "ZJ", "names": names("en", "Zhejiang", "zh-CN", "浙江省")}},
+	}))
+	w.insert("1.2.4.0/24", w.addData(map[string]any{
+		"city":         map[string]any{"names": names("en", "Beijing", "zh-CN", "北京")},
+		"country":      pointerTo(cn),
+		"subdivisions": []any{map[string]any{"iso_code": "BJ", "names": names("en", "Beijing", "zh-CN", "北京市")}},
+	}))
+	w.insert("8.8.8.0/24", w.addData(map[string]any{
+		"city":         map[string]any{"names": names("en", "Mountain View")},
+		"country":      map[string]any{"iso_code": "US", "names": names("en", "United States", "zh-CN", "美国")},
+		"subdivisions": []any{map[string]any{"iso_code": "CA", "names": names("en", "California")}},
+		"traits":       map[string]any{"is_anycast": true},
+	}))
+	w.insert("81.2.69.0/24", w.addData(map[string]any{
+		"country": map[string]any{"iso_code": "GB", "names": names("en", "United Kingdom")},
+		"location": map[string]any{
+			"latitude":   51.5142,
+			"longitude":  -0.0931,
+			"metro_code": int32(-1),
+		},
+		// 超过 29 字节的字符串使用扩展长度
+		"traits": map[string]any{"note": strings.Repeat("长度超过 285 字节的说明。", 20)},
+	}))
+	if ipVersion == 6 {
+		w.insert("2001:db8::/32", w.addData(map[string]any{
+			"city":         map[string]any{"names": names("de", "München", "en", "Munich")},
+			"country":      map[string]any{"iso_code": "DE", "names": names("de", "Deutschland", "en", "Germany")},
+			"subdivisions": []any{map[string]any{"iso_code": "BY", "names": names("de", "Bayern", "en", "Bavaria")}},
+		}))
+	}
+	return w.bytes()
+}
+
+func TestFixtureUpToDate(t *testing.T) {
+	want := buildFixture(6, 28)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(fixturePath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fixturePath, want, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := os.ReadFile(fixturePath)
+	if err != nil {
+		t.Fatalf("读取样例数据库失败（可使用 go test -run TestFixtureUpToDate -update 生成）: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("样例数据库与 buildFixture 不一致，请执行 go test -run TestFixtureUpToDate -update 重新生成")
+	}
+}
+
+func TestOpenMetadata(t *testing.T) {
+	db, err := Open(fixturePath, "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := db.Metadata()
+	if meta.DatabaseType != "GeoIP2-City-Test" || meta.Description != "MBlog test database" ||
+		meta.IPVersion != 6 || meta.RecordSize != 28 || meta.BuildEpoch != 1760000000 || meta.NodeCount == 0 {
+		t.Errorf("metadata = %+v", meta)
+	}
+	if strings.Join(meta.Languages, ",") != "de,en,zh-CN" {
+		t.Errorf("languages = %v", meta.Languages)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		language string
+		ip       string
+		want     Location
+	}{
+		{"zh-CN", "1.2.3.4", Location{"CN", "浙江省", "杭州"}},
+		{"zh-CN", "1.2.3.255", Location{"CN", "浙江省", "杭州"}},
+		{"zh-CN", "::ffff:1.2.3.4", Location{"CN", "浙江省", "杭州"}},
+		{"zh-CN", " 1.2.4.8 ", Location{"CN", "北京市", "北京"}},
+		{"zh-CN", "81.2.69.160", Location{Country: "GB"}},
+		{"zh-CN", "2001:db8::1", Location{"DE", "Bavaria", "Munich"}},
+		{"zh-CN", "2001:db8:ffff:ffff::", Location{"DE", "Bavaria", "Munich"}},
+
+		// 没有所选语言的地名时使用英文
+		{"zh-CN", "8.8.8.8", Location{"US", "California", "Mountain View"}},
+		{"de", "2001:db8::1", Location{"DE", "Bayern", "München"}},
+		{"de", "1.2.3.4", Location{"CN", "Zhejiang", "Hangzhou"}},
+		{"en", "1.2.3.4", Location{"CN", "Zhejiang", "Hangzhou"}},
+		{"", "1.2.3.4", Location{"CN", "Zhejiang", "Hangzhou"}},
+		{"fr", "8.8.8.8", Location{"US", "California", "Mountain View"}},
+
+		// 数据库中没有的地址
+		{"zh-CN", "1.2.5.1", Location{}},
+		{"zh-CN", "10.0.0.1", Location{}},
+		{"zh-CN", "127.0.0.1", Location{}},
+		{"zh-CN", "2001:db9::1", Location{}},
+		{"zh-CN", "::1", Location{}},
+	}
+
+	dbs := map[string]*DB{}
+	for _, tt := range tests {
+		db, ok := dbs[tt.language]
+		if !ok {
+			var err error
+			if db, err = Open(fixturePath, tt.language); err != nil {
+				t.Fatal(err)
+			}
+			dbs[tt.language] = db
+		}
+		got, err := db.Lookup(tt.ip)
+		if err != nil {
+			t.Errorf("Lookup(%q) [%s] 出错: %v", tt.ip, tt.language, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Lookup(%q) [%s] = %+v，期望 %+v", tt.ip, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestLookupInvalidIP(t *testing.T) {
+	db, err := Open(fixturePath, "zh-CN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ip := range []string{"", "not-an-ip", "1.2.3", "1.2.3.4:80"} {
+		if _, err := db.Lookup(ip); err == nil {
+			t.Errorf("Lookup(%q) 应当返回错误", ip)
+		}
+	}
+}
+
+func TestOpenMissingFile(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.mmdb"), "zh-CN"); err == nil {
+		t.Fatal("文件不存在时应当返回错误")
+	}
+}
+
+// TestRecordSizes 各种记录长度和 IP 版本的数据库都能正确查询
+func TestRecordSizes(t *testing.T) {
+	for _, ipVersion := range []int{4, 6} {
+		for _, recordSize := range []int{24, 28, 32} {
+			reader, err := newMMDBReader(buildFixture(ipVersion, recordSize))
+			if err != nil {
+				t.Fatalf("IPv%d/%d 位: %v", ipVersion, recordSize, err)
+			}
+			db := &DB{reader: reader, languages: []string{"zh-CN", "en"}}
+
+			if got, err := db.Lookup("1.2.3.4"); err != nil || got != (Location{"CN", "浙江省", "杭州"}) {
+				t.Errorf("IPv%d/%d 位: Lookup(1.2.3.4) = %+v, %v", ipVersion, recordSize, got, err)
+			}
+			if got, err := db.Lookup("8.8.4.4"); err != nil || got != (Location{}) {
+				t.Errorf("IPv%d/%d 位: Lookup(8.8.4.4) = %+v, %v", ipVersion, recordSize, got, err)
+			}
+			want := Location{}
+			if ipVersion == 6 {
+				want = Location{"DE", "Bavaria", "Munich"}
+			}
+			if got, err := db.Lookup("2001:db8::1"); err != nil || got != want {
+				t.Errorf("IPv%d/%d 位: Lookup(2001:db8::1) = %+v, %v", ipVersion, recordSize, got, err)
+			}
+		}
+	}
+}
+
+// TestReadNode 用超过 24 位的记录值检查各种记录长度的解码，28 位记录的中间字节由左右两条记录共用
+func TestReadNode(t *testing.T) {
+	tests := []struct {
+		recordSize  int
+		tree        []byte
+		left, right uint32
+	}{
+		{24, []byte{0xAB, 0xCD, 0xEF, 0x12, 0x34, 0x56}, 0xABCDEF, 0x123456},
+		{28, []byte{0xBC, 0xDE, 0xF1, 0xAF, 0xED, 0xCB, 0xA9}, 0x0ABCDEF1, 0x0FEDCBA9},
+		{32, []byte{0xDE, 0xAD, 0xBE, 0xEF, 0x01, 0x23, 0x45, 0x67}, 0xDEADBEEF, 0x01234567},
+	}
+	for _, tt := range tests {
+		// 第二个节点验证节点偏移的计算
+		tree := append(make([]byte, len(tt.tree)), tt.tree...)
+		r := &mmdbReader{meta: Metadata{RecordSize: tt.recordSize, NodeCount: 2}, tree: tree}
+		if got := r.readNode(1, 0); got != tt.left {
+			t.Errorf("%d 位左记录 = %#x，期望 %#x", tt.recordSize, got, tt.left)
+		}
+		if got := r.readNode(1, 1); got != tt.right {
+			t.Errorf("%d 位右记录 = %#x，期望 %#x", tt.recordSize, got, tt.right)
+		}
+		if !bytes.Equal(encodeRecords(tt.left, tt.right, tt.recordSize), tt.tree) {
+			t.Errorf("%d 位记录的测试编码与规范不一致", tt.recordSize)
+		}
+	}
+}
+
+func TestDecodeTypes(t *testing.T) {
+	var buf bytes.Buffer
+	encodeValue(&buf, map[string]any{
+		"bytes":  []byte{1, 2, 3},
+		"double": 1.5,
+		"false":  false,
+		"float":  float32(2.5),
+		"int32":  int32(-42),
+		"long":   strings.Repeat("x", 70000),
+		"true":   true,
+		"u16":    uint16Value(0),
+		"u32":    uint32Value(4294967295),
+		"u64":    uint64Value(1 << 40),
+	})
+	value, next, err := decoder{buf.Bytes()}.decode(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != buf.Len() {
+		t.Errorf("next = %d，期望 %d", next, buf.Len())
+	}
+	m := asMap(value)
+	if !bytes.Equal(m["bytes"].([]byte), []byte{1, 2, 3}) || m["double"] != 1.5 || m["false"] != false ||
+		m["float"] != float32(2.5) || m["int32"] != int32(-42) || len(asString(m["long"])) != 70000 ||
+		m["true"] != true || m["u16"] != uint64(0) || m["u32"] != uint64(4294967295) || m["u64"] != uint64(1<<40) {
+		t.Errorf("decode = %v", m)
+	}
+}
+
+func TestDecodePointers(t *testing.T) {
+	// 覆盖 1-4 字节的指针编码
+	for _, target := range []int{5, 2047, 2048, 526335, 526336, 600000} {
+		var buf bytes.Buffer
+		buf.Write(make([]byte, target))
+		encodeValue(&buf, "target")
+		start := buf.Len()
+		encodeValue(&buf, []any{pointerTo(target), "after"})
+
+		value, _, err := decoder{buf.Bytes()}.decode(start, 0)
+		if err != nil {
+			t.Fatalf("指针 %d: %v", target, err)
+		}
+		items, _ := value.([]any)
+		if len(items) != 2 || items[0] != "target" || items[1] != "after" {
+			t.Errorf("指针 %d: decode = %v", target, value)
+		}
+	}
+}
+
+// TestCorruptDatabases 损坏的文件返回错误而不是 panic
+func TestCorruptDatabases(t *testing.T) {
+	valid := buildFixture(6, 28)
+	metaStart := bytes.LastIndex(valid, metadataMarker)
+
+	withMetadata := func(t *testing.T, meta map[string]any) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		buf.Write(valid[:metaStart])
+		buf.Write(metadataMarker)
+		encodeValue(&buf, meta)
+		return buf.Bytes()
+	}
+	baseMeta := func() map[string]any {
+		return map[string]any{
+			"binary_format_major_version": uint16Value(2),
+			"ip_version":                  uint16Value(6),
+			"node_count":                  uint32Value(10),
+			"record_size":                 uint16Value(28),
+		}
+	}
+
+	openErrors := map[string][]byte{
+		"空文件":   {},
+		"没有元数据": bytes.Repeat([]byte{0xAB}, 1024),
+		"元数据截断": valid[:metaStart+len(metadataMarker)+3],
+		"元数据不是映射": func() []byte {
+			var buf bytes.Buffer
+			buf.Write(valid[:metaStart])
+			buf.Write(metadataMarker)
+			encodeValue(&buf, "not a map")
+			return buf.Bytes()
+		}(),
+	}
+	for name, mutate := range map[string]func(map[string]any){
+		"不支持的格式版本":   func(m map[string]any) { m["binary_format_major_version"] = uint16Value(3) },
+		"不支持的记录长度":   func(m map[string]any) { m["record_size"] = uint16Value(20) },
+		"不支持的 IP 版本": func(m map[string]any) { m["ip_version"] = uint16Value(5) },
+		"搜索树超出文件":    func(m map[string]any) { m["node_count"] = uint32Value(1 << 30) },
+	} {
+		meta := baseMeta()
+		mutate(meta)
+		openErrors[name] = withMetadata(t, meta)
+	}
+	for name, buf := range openErrors {
+		if _, err := newMMDBReader(buf); err == nil {
+			t.Errorf("%s: 应当返回错误", name)
+		}
+	}
+
+	// 数据区损坏：打开成功，查询返回错误
+	lookupErrors := map[string]func(w *mmdbWriter){
+		"指针循环":    func(w *mmdbWriter) { w.insert("1.2.3.0/24", w.addData(pointerTo(0))) },
+		"记录超出数据区": func(w *mmdbWriter) { w.addData("x"); w.insert("1.2.3.0/24", 1000) },
+		"指针超出数据区": func(w *mmdbWriter) { w.insert("1.2.3.0/24", w.addData(pointerTo(1<<20))) },
+		"映射的键不是字符串": func(w *mmdbWriter) {
+			offset := w.data.Len()
+			w.data.Write([]byte{typeMap<<5 | 1})
+			encodeValue(&w.data, uint16Value(1))
+			encodeValue(&w.data, "value")
+			w.insert("1.2.3.0/24", offset)
+		},
+		"元素个数越界": func(w *mmdbWriter) {
+			offset := w.data.Len()
+			w.data.Write([]byte{typeMap<<5 | 31, 0xFF, 0xFF, 0xFF})
+			w.insert("1.2.3.0/24", offset)
+		},
+		"字符串长度越界": func(w *mmdbWriter) {
+			offset := w.data.Len()
+			w.data.Write([]byte{typeString<<5 | 30, 0xFF, 0xFF})
+			w.insert("1.2.3.0/24", offset)
+		},
+		"无效的数据类型": func(w *mmdbWriter) {
+			offset := w.data.Len()
+			w.data.Write([]byte{0, 20})
+			w.insert("1.2.3.0/24", offset)
+		},
+	}
+	for name, build := range lookupErrors {
+		w := newMMDBWriter(4, 24)
+		build(w)
+		reader, err := newMMDBReader(w.bytes())
+		if err != nil {
+			t.Errorf("%s: 打开失败: %v", name, err)
+			continue
+		}
+		db := &DB{reader: reader, languages: []string{"en"}}
+		if _, err := db.Lookup("1.2.3.4"); !errors.Is(err, errInvalidDatabase) {
+			t.Errorf("%s: Lookup 返回 %v，期望 errInvalidDatabase", name, err)
+		}
+	}
+}
+
+// TestTruncatedAndRandomlyCorrupted 任意截断或随机改写字节后打开和查询都不会 panic
+func TestTruncatedAndRandomlyCorrupted(t *testing.T) {
+	valid := buildFixture(6, 28)
+	ips := []string{"1.2.3.4", "1.2.4.8", "8.8.8.8", "81.2.69.160", "2001:db8::1", "10.0.0.1", "::1"}
+
+	try := func(buf []byte) {
+		reader, err := newMMDBReader(buf)
+		if err != nil {
+			return
+		}
+		db := &DB{reader: reader, languages: []string{"zh-CN", "en"}}
+		for _, ip := range ips {
+			db.Lookup(ip)
+		}
+	}
+
+	for n := 0; n < len(valid); n++ {
+		buf := bytes.Clone(valid[:n])
+		if _, err := newMMDBReader(buf); err == nil {
+			t.Errorf("截断到 %d 字节时应当返回错误", n)
+		}
+	}
+
+	// 截掉数据区末尾但保留元数据
+	metaStart := bytes.LastIndex(valid, metadataMarker)
+	for cut := 1; cut < 64; cut++ {
+		buf := append(bytes.Clone(valid[:metaStart-cut]), valid[metaStart:]...)
+		try(buf)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 3000; i++ {
+		buf := bytes.Clone(valid)
+		for j := 0; j <= rng.Intn(4); j++ {
+			buf[rng.Intn(len(buf))] = byte(rng.Intn(256))
+		}
+		try(buf)
+	}
+}
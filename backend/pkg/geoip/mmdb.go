@@ -0,0 +1,379 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+)
+
+// MMDB 文件格式的读取实现，格式说明见 https://maxmind.github.io/MaxMind-DB/
+//
+// 文件由三部分组成：二叉搜索树（按 IP 地址的每一位向左或向右查找）、数据区和文件末尾的元数据。
+// 搜索树的叶子记录指向数据区中的一条记录，记录以自描述的类型编码保存（类似 MessagePack），可以互相引用。
+
+// metadataMarker 元数据前的标记，元数据位于文件末尾 128KB 以内
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// 数据区中字段的类型
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeArray     = 11
+	typeContainer = 12
+	typeEnd       = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+// maxDecodeDepth 嵌套和指针跳转的最大深度，防止损坏的文件导致无限递归
+const maxDecodeDepth = 64
+
+var errInvalidDatabase = errors.New("MMDB 文件已损坏")
+
+// Metadata MMDB 文件的元数据
+type Metadata struct {
+	DatabaseType string
+	Description  string   // 英文描述
+	Languages    []string // 数据中包含的名称语言
+	BuildEpoch   uint64   // 构建时间（Unix 时间戳）
+	IPVersion    int
+	NodeCount    uint32
+	RecordSize   int
+}
+
+// mmdbReader 读取内存中的 MMDB 文件
+type mmdbReader struct {
+	meta      Metadata
+	tree      []byte
+	data      decoder
+	ipv4Start uint32 // IPv6 数据库中 ::/96 对应的节点，IPv4 地址从这里开始查找
+}
+
+func newMMDBReader(buf []byte) (*mmdbReader, error) {
+	start := bytes.LastIndex(buf, metadataMarker)
+	if start < 0 {
+		return nil, errors.New("不是有效的 MMDB 文件：找不到元数据")
+	}
+
+	value, _, err := decoder{buf[start+len(metadataMarker):]}.decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("解析 MMDB 元数据失败: %w", err)
+	}
+	raw, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("解析 MMDB 元数据失败: %w", errInvalidDatabase)
+	}
+
+	meta := Metadata{
+		DatabaseType: asString(raw["database_type"]),
+		Description:  asString(asMap(raw["description"])["en"]),
+		BuildEpoch:   asUint(raw["build_epoch"]),
+		IPVersion:    int(asUint(raw["ip_version"])),
+		NodeCount:    uint32(asUint(raw["node_count"])),
+		RecordSize:   int(asUint(raw["record_size"])),
+	}
+	if languages, ok := raw["languages"].([]any); ok {
+		for _, lang := range languages {
+			meta.Languages = append(meta.Languages, asString(lang))
+		}
+	}
+	if version := asUint(raw["binary_format_major_version"]); version != 2 {
+		return nil, fmt.Errorf("不支持的 MMDB 格式版本: %d", version)
+	}
+	switch meta.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("不支持的 MMDB 记录长度: %d", meta.RecordSize)
+	}
+	if meta.IPVersion != 4 && meta.IPVersion != 6 {
+		return nil, fmt.Errorf("不支持的 MMDB IP 版本: %d", meta.IPVersion)
+	}
+
+	// 搜索树之后是 16 字节的分隔符，然后是数据区
+	treeSize := int(meta.NodeCount) * meta.RecordSize / 4
+	if treeSize+16 > start {
+		return nil, fmt.Errorf("MMDB 搜索树超出文件范围: %w", errInvalidDatabase)
+	}
+
+	r := &mmdbReader{
+		meta: meta,
+		tree: buf[:treeSize],
+		data: decoder{buf[treeSize+16 : start]},
+	}
+	if meta.IPVersion == 6 {
+		node := uint32(0)
+		for i := 0; i < 96 && node < meta.NodeCount; i++ {
+			node = r.readNode(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// readNode 读取节点 node 的左（bit 为 0）或右记录
+func (r *mmdbReader) readNode(node uint32, bit byte) uint32 {
+	offset := int(node) * r.meta.RecordSize / 4
+	b := r.tree[offset:]
+	switch r.meta.RecordSize {
+	case 24:
+		b = b[int(bit)*3:]
+		return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+	case 28:
+		// 两条记录共用中间一个字节，高 4 位属于左记录，低 4 位属于右记录
+		if bit == 0 {
+			return uint32(b[3]&0xF0)<<20 | uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+		}
+		return uint32(b[3]&0x0F)<<24 | uint32(b[4])<<16 | uint32(b[5])<<8 | uint32(b[6])
+	default:
+		return binary.BigEndian.Uint32(b[int(bit)*4:])
+	}
+}
+
+// lookup 返回 addr 对应的数据记录，数据库中没有该地址时返回 nil
+func (r *mmdbReader) lookup(addr netip.Addr) (any, error) {
+	addr = addr.Unmap()
+
+	var ip []byte
+	node := uint32(0)
+	if addr.Is4() {
+		a := addr.As4()
+		ip = a[:]
+		node = r.ipv4Start
+	} else {
+		if r.meta.IPVersion == 4 {
+			return nil, nil
+		}
+		a := addr.As16()
+		ip = a[:]
+	}
+
+	for i := 0; i < len(ip)*8 && node < r.meta.NodeCount; i++ {
+		bit := (ip[i/8] >> (7 - i%8)) & 1
+		node = r.readNode(node, bit)
+	}
+
+	switch {
+	case node == r.meta.NodeCount:
+		return nil, nil
+	case node < r.meta.NodeCount:
+		return nil, fmt.Errorf("MMDB 搜索树没有终止: %w", errInvalidDatabase)
+	}
+
+	// 叶子记录减去节点数和分隔符长度后是数据区中的偏移
+	offset := int(node-r.meta.NodeCount) - 16
+	value, _, err := r.data.decode(offset, 0)
+	return value, err
+}
+
+// decoder 解码数据区中的字段，指针是相对于 buf 起点的偏移
+type decoder struct {
+	buf []byte
+}
+
+// decode 解码 offset 处的字段，返回字段的值和下一个字段的偏移
+func (d decoder) decode(offset, depth int) (any, int, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, fmt.Errorf("MMDB 数据嵌套过深: %w", errInvalidDatabase)
+	}
+	if offset < 0 || offset >= len(d.buf) {
+		return nil, 0, fmt.Errorf("MMDB 数据偏移越界: %w", errInvalidDatabase)
+	}
+
+	ctrl := d.buf[offset]
+	offset++
+	typ := int(ctrl >> 5)
+
+	if typ == typePointer {
+		target, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(target, depth+1)
+		return value, next, err
+	}
+
+	if typ == typeExtended {
+		if offset >= len(d.buf) {
+			return nil, 0, fmt.Errorf("MMDB 数据类型不完整: %w", errInvalidDatabase)
+		}
+		typ = 7 + int(d.buf[offset])
+		offset++
+	}
+
+	size, offset, err := d.size(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 每个元素至少占一个字节，元素个数超过剩余长度说明文件已损坏，避免按损坏的长度分配内存
+	if (typ == typeMap || typ == typeArray) && size > len(d.buf)-offset {
+		return nil, 0, fmt.Errorf("MMDB 元素个数越界: %w", errInvalidDatabase)
+	}
+
+	switch typ {
+	case typeMap:
+		m := make(map[string]any, size)
+		for i := 0; i < size; i++ {
+			key, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("MMDB 映射的键不是字符串: %w", errInvalidDatabase)
+			}
+			value, next, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[k] = value
+			offset = next
+		}
+		return m, offset, nil
+
+	case typeArray:
+		items := make([]any, 0, size)
+		for i := 0; i < size; i++ {
+			value, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, value)
+			offset = next
+		}
+		return items, offset, nil
+
+	case typeBool:
+		// 布尔值保存在长度字段中，没有数据
+		return size != 0, offset, nil
+	}
+
+	if offset+size > len(d.buf) {
+		return nil, 0, fmt.Errorf("MMDB 数据长度越界: %w", errInvalidDatabase)
+	}
+	payload := d.buf[offset : offset+size]
+	next := offset + size
+
+	switch typ {
+	case typeString:
+		return string(payload), next, nil
+	case typeBytes:
+		return bytes.Clone(payload), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("MMDB double 长度错误: %w", errInvalidDatabase)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("MMDB float 长度错误: %w", errInvalidDatabase)
+		}
+		return math.Float32frombits(binary.BigEndian.Uint32(payload)), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("MMDB 整数长度错误: %w", errInvalidDatabase)
+		}
+		return uintFromBytes(payload), next, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("MMDB 整数长度错误: %w", errInvalidDatabase)
+		}
+		return int32(uint32(uintFromBytes(payload))), next, nil
+	case typeUint128:
+		if size > 16 {
+			return nil, 0, fmt.Errorf("MMDB 整数长度错误: %w", errInvalidDatabase)
+		}
+		return new(big.Int).SetBytes(payload), next, nil
+	default:
+		return nil, 0, fmt.Errorf("MMDB 数据类型 %d 无效: %w", typ, errInvalidDatabase)
+	}
+}
+
+// size 解析控制字节中的长度，长度 29-31 表示后续 1-3 个字节保存实际长度
+func (d decoder) size(ctrl byte, offset int) (int, int, error) {
+	size := int(ctrl & 0x1f)
+	if size < 29 {
+		return size, offset, nil
+	}
+
+	n := size - 28
+	if offset+n > len(d.buf) {
+		return 0, 0, fmt.Errorf("MMDB 数据长度不完整: %w", errInvalidDatabase)
+	}
+	extra := int(uintFromBytes(d.buf[offset : offset+n]))
+	switch size {
+	case 29:
+		size = 29 + extra
+	case 30:
+		size = 285 + extra
+	default:
+		size = 65821 + extra
+	}
+	return size, offset + n, nil
+}
+
+// pointer 解析指针，返回指向的偏移和指针之后下一个字段的偏移
+func (d decoder) pointer(ctrl byte, offset int) (int, int, error) {
+	n := int((ctrl>>3)&0x3) + 1
+	if offset+n > len(d.buf) {
+		return 0, 0, fmt.Errorf("MMDB 指针不完整: %w", errInvalidDatabase)
+	}
+	value := int(uintFromBytes(d.buf[offset : offset+n]))
+	prefix := int(ctrl & 0x7)
+
+	var target int
+	switch n {
+	case 1:
+		target = prefix<<8 | value
+	case 2:
+		target = (prefix<<16 | value) + 2048
+	case 3:
+		target = (prefix<<24 | value) + 526336
+	default:
+		target = value
+	}
+	return target, offset + n, nil
+}
+
+func uintFromBytes(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func asString(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func asUint(v any) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int32:
+		if n >= 0 {
+			return uint64(n)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,290 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/netip"
+	"sort"
+)
+
+// mmdbWriter 按 MMDB 格式规范生成测试用的数据库，与读取实现相互独立，
+// 用于生成 testdata 中的样例文件以及各种记录长度、损坏文件的测试数据
+
+// pointerTo 在数据区中写入指向已写入记录的指针
+type pointerTo int
+
+// uint16Value、uint32Value、uint64Value 指定整数的编码类型
+type (
+	uint16Value uint16
+	uint32Value uint32
+	uint64Value uint64
+)
+
+type mmdbWriter struct {
+	ipVersion    int
+	recordSize   int
+	databaseType string
+	languages    []string
+	buildEpoch   uint64
+
+	data     bytes.Buffer
+	networks []writerNetwork
+}
+
+type writerNetwork struct {
+	prefix netip.Prefix
+	offset int // 数据区中的偏移
+}
+
+func newMMDBWriter(ipVersion, recordSize int) *mmdbWriter {
+	return &mmdbWriter{
+		ipVersion:    ipVersion,
+		recordSize:   recordSize,
+		databaseType: "GeoIP2-City-Test",
+		languages:    []string{"de", "en", "zh-CN"},
+		buildEpoch:   1760000000,
+	}
+}
+
+// addData 写入一条数据记录，返回它在数据区中的偏移，可以用 pointerTo 引用
+func (w *mmdbWriter) addData(v any) int {
+	offset := w.data.Len()
+	encodeValue(&w.data, v)
+	return offset
+}
+
+// insert 把网段映射到数据区中偏移为 offset 的记录，IPv6 数据库中 IPv4 网段保存在 ::/96 之下
+func (w *mmdbWriter) insert(prefix string, offset int) {
+	p := netip.MustParsePrefix(prefix)
+	if w.ipVersion == 6 && p.Addr().Is4() {
+		a4 := p.Addr().As4()
+		var a16 [16]byte
+		copy(a16[12:], a4[:])
+		p = netip.PrefixFrom(netip.AddrFrom16(a16), p.Bits()+96)
+	}
+	w.networks = append(w.networks, writerNetwork{prefix: p, offset: offset})
+}
+
+// writerNode 搜索树节点，子节点为 nil 且 leaf 为 -1 表示该分支没有数据
+type writerNode struct {
+	children [2]*writerNode
+	leaf     [2]int
+}
+
+func newWriterNode() *writerNode {
+	return &writerNode{leaf: [2]int{-1, -1}}
+}
+
+// bytes 返回完整的 MMDB 文件
+func (w *mmdbWriter) bytes() []byte {
+	root := newWriterNode()
+	bitCount := 32
+	if w.ipVersion == 6 {
+		bitCount = 128
+	}
+	for _, n := range w.networks {
+		ip := n.prefix.Addr().AsSlice()
+		if len(ip)*8 != bitCount {
+			panic(fmt.Sprintf("网段 %s 与数据库的 IP 版本不符", n.prefix))
+		}
+		node := root
+		for i := 0; i < n.prefix.Bits(); i++ {
+			bit := (ip[i/8] >> (7 - i%8)) & 1
+			if i == n.prefix.Bits()-1 {
+				node.leaf[bit] = n.offset
+				node.children[bit] = nil
+				break
+			}
+			if node.children[bit] == nil {
+				node.children[bit] = newWriterNode()
+			}
+			node = node.children[bit]
+		}
+	}
+
+	// 按广度优先编号，根节点为 0
+	var nodes []*writerNode
+	index := map[*writerNode]int{}
+	queue := []*writerNode{root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		index[n] = len(nodes)
+		nodes = append(nodes, n)
+		for _, c := range n.children {
+			if c != nil {
+				queue = append(queue, c)
+			}
+		}
+	}
+	nodeCount := len(nodes)
+
+	var tree bytes.Buffer
+	for _, n := range nodes {
+		var records [2]uint32
+		for bit := 0; bit < 2; bit++ {
+			switch {
+			case n.children[bit] != nil:
+				records[bit] = uint32(index[n.children[bit]])
+			case n.leaf[bit] >= 0:
+				records[bit] = uint32(nodeCount + 16 + n.leaf[bit])
+			default:
+				records[bit] = uint32(nodeCount)
+			}
+		}
+		tree.Write(encodeRecords(records[0], records[1], w.recordSize))
+	}
+
+	var out bytes.Buffer
+	out.Write(tree.Bytes())
+	out.Write(make([]byte, 16))
+	out.Write(w.data.Bytes())
+	out.Write(metadataMarker)
+	languages := make([]any, len(w.languages))
+	for i, lang := range w.languages {
+		languages[i] = lang
+	}
+	encodeValue(&out, map[string]any{
+		"binary_format_major_version": uint16Value(2),
+		"binary_format_minor_version": uint16Value(0),
+		"build_epoch":                 uint64Value(w.buildEpoch),
+		"database_type":               w.databaseType,
+		"description":                 map[string]any{"en": "MBlog test database"},
+		"ip_version":                  uint16Value(w.ipVersion),
+		"languages":                   languages,
+		"node_count":                  uint32Value(nodeCount),
+		"record_size":                 uint16Value(w.recordSize),
+	})
+	return out.Bytes()
+}
+
+// encodeRecords 按记录长度编码一个节点的左右两条记录
+func encodeRecords(left, right uint32, recordSize int) []byte {
+	switch recordSize {
+	case 24:
+		return []byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)}
+	case 28:
+		return []byte{
+			byte(left >> 16), byte(left >> 8), byte(left),
+			byte((left>>24)&0x0F)<<4 | byte((right>>24)&0x0F),
+			byte(right >> 16), byte(right >> 8), byte(right),
+		}
+	case 32:
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint32(b, left)
+		binary.BigEndian.PutUint32(b[4:], right)
+		return b
+	}
+	panic(fmt.Sprintf("不支持的记录长度: %d", recordSize))
+}
+
+// encodeValue 按 MMDB 数据区格式编码一个值，映射的键按字典序写入，保证输出稳定
+func encodeValue(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case pointerTo:
+		encodePointer(buf, int(v))
+	case string:
+		writeControl(buf, typeString, len(v))
+		buf.WriteString(v)
+	case []byte:
+		writeControl(buf, typeBytes, len(v))
+		buf.Write(v)
+	case float64:
+		writeControl(buf, typeDouble, 8)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case float32:
+		writeControl(buf, typeFloat, 4)
+		binary.Write(buf, binary.BigEndian, math.Float32bits(v))
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+		writeControl(buf, typeBool, size)
+	case uint16Value:
+		writeUint(buf, typeUint16, uint64(v))
+	case uint32Value:
+		writeUint(buf, typeUint32, uint64(v))
+	case uint64Value:
+		writeUint(buf, typeUint64, uint64(v))
+	case int32:
+		writeControl(buf, typeInt32, 4)
+		binary.Write(buf, binary.BigEndian, v)
+	case []any:
+		writeControl(buf, typeArray, len(v))
+		for _, item := range v {
+			encodeValue(buf, item)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		writeControl(buf, typeMap, len(v))
+		for _, k := range keys {
+			encodeValue(buf, k)
+			encodeValue(buf, v[k])
+		}
+	default:
+		panic(fmt.Sprintf("不支持的类型 %T", v))
+	}
+}
+
+// writeUint 以最少的字节数编码无符号整数，0 的长度为 0
+func writeUint(buf *bytes.Buffer, typ int, v uint64) {
+	var b []byte
+	for ; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	writeControl(buf, typ, len(b))
+	buf.Write(b)
+}
+
+// writeControl 写入控制字节：高 3 位为类型（扩展类型为 0，随后一个字节为类型减 7），低 5 位及其后的字节为长度
+func writeControl(buf *bytes.Buffer, typ, size int) {
+	var sizeBits byte
+	var extra []byte
+	switch {
+	case size < 29:
+		sizeBits = byte(size)
+	case size < 285:
+		sizeBits, extra = 29, []byte{byte(size - 29)}
+	case size < 65821:
+		n := size - 285
+		sizeBits, extra = 30, []byte{byte(n >> 8), byte(n)}
+	default:
+		n := size - 65821
+		sizeBits, extra = 31, []byte{byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+
+	if typ <= typeMap {
+		buf.WriteByte(byte(typ)<<5 | sizeBits)
+	} else {
+		buf.WriteByte(sizeBits)
+		buf.WriteByte(byte(typ - 7))
+	}
+	buf.Write(extra)
+}
+
+// encodePointer 按目标偏移选择最短的指针编码
+func encodePointer(buf *bytes.Buffer, target int) {
+	switch {
+	case target < 2048:
+		buf.WriteByte(typePointer<<5 | byte(target>>8))
+		buf.WriteByte(byte(target))
+	case target < 526336:
+		v := target - 2048
+		buf.WriteByte(typePointer<<5 | 1<<3 | byte(v>>16))
+		buf.Write([]byte{byte(v >> 8), byte(v)})
+	case target < 134744064:
+		v := target - 526336
+		buf.WriteByte(typePointer<<5 | 2<<3 | byte(v>>24))
+		buf.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+	default:
+		buf.WriteByte(typePointer<<5 | 3<<3)
+		binary.Write(buf, binary.BigEndian, uint32(target))
+	}
+}
@@ -0,0 +1,38 @@
+package keystore
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHandlers 注册签名密钥管理路由，调用方负责传入已挂载认证和授权中间件的路由组
+func (ks *KeyStore) RegisterHandlers(rg *gin.RouterGroup) {
+	group := rg.Group("/api/auth/keys")
+	{
+		group.GET("", ks.handleListKeys)
+		group.POST("/rotate", ks.handleRotateKey)
+	}
+}
+
+// handleListKeys 处理获取密钥列表的请求
+func (ks *KeyStore) handleListKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, ks.ListKeys())
+}
+
+// handleRotateKey 处理轮换签名密钥的请求
+func (ks *KeyStore) handleRotateKey(c *gin.Context) {
+	key, err := ks.Rotate()
+	if err != nil {
+		log.Printf("轮换签名密钥失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "轮换签名密钥失败"})
+		return
+	}
+
+	log.Printf("用户 %s 轮换了签名密钥: %s", c.GetString("username"), key.KID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "签名密钥已轮换，旧令牌在过期前仍然有效",
+		"kid":     key.KID,
+	})
+}
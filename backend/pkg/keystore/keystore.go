@@ -0,0 +1,277 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// SecretLength 自动生成的签名密钥长度（字节）
+	SecretLength = 32
+	// reloadInterval 从数据库重新加载密钥的间隔，用于多实例之间同步轮换结果
+	reloadInterval = 1 * time.Minute
+	// missReloadInterval 遇到未知 kid 时按需重载的最小间隔，避免伪造 kid 打满数据库
+	missReloadInterval = 10 * time.Second
+)
+
+var ErrUnknownKey = errors.New("未知的签名密钥")
+
+// Key 代表一个 JWT 签名密钥
+type Key struct {
+	KID       string     `json:"kid"`
+	Secret    []byte     `json:"-"`
+	Source    string     `json:"source"` // config: 来自配置; database: 轮换生成
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at"` // 退役后仅用于验证旧令牌
+	Current   bool       `json:"current"`    // 当前用于签发新令牌
+}
+
+// KeyStore 管理 JWT 签名密钥，支持多把密钥同时有效和在线轮换
+type KeyStore struct {
+	db          *sql.DB
+	configKey   *Key
+	gracePeriod time.Duration // 密钥退役后继续用于验证的时长，应不短于令牌有效期
+
+	mu         sync.RWMutex
+	keys       map[string]*Key
+	current    string
+	lastReload time.Time
+	// configRetired 配置密钥已退役且超过宽限期，仅用于避免重复打印日志
+	configRetired bool
+}
+
+// NewKeyStore 创建密钥库
+// configKID/configSecret 来自配置文件或环境变量，可为空；
+// 配置与数据库中都没有可用密钥时会自动生成一把并写入数据库
+func NewKeyStore(db *sql.DB, configKID, configSecret string, gracePeriod time.Duration) (*KeyStore, error) {
+	ks := &KeyStore{
+		db:          db,
+		gracePeriod: gracePeriod,
+		keys:        make(map[string]*Key),
+	}
+	if configSecret != "" {
+		ks.configKey = &Key{
+			KID:    configKID,
+			Secret: []byte(configSecret),
+			Source: "config",
+		}
+	}
+
+	if err := ks.reload(); err != nil {
+		return nil, err
+	}
+
+	ks.mu.RLock()
+	hasCurrent := ks.current != ""
+	ks.mu.RUnlock()
+	if !hasCurrent {
+		log.Println("未配置 JWT 签名密钥，自动生成新密钥")
+		if _, err := ks.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	// 启动后台重载协程
+	go ks.reloadLoop()
+
+	return ks, nil
+}
+
+// SigningKey 返回当前用于签发令牌的密钥
+func (ks *KeyStore) SigningKey() (string, []byte, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[ks.current]
+	if !ok {
+		return "", nil, ErrUnknownKey
+	}
+	return key.KID, key.Secret, nil
+}
+
+// VerificationKey 根据 kid 返回用于验证令牌的密钥
+func (ks *KeyStore) VerificationKey(kid string) ([]byte, error) {
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	stale := time.Since(ks.lastReload) > missReloadInterval
+	ks.mu.RUnlock()
+	if ok {
+		return key.Secret, nil
+	}
+
+	// 其他实例可能刚完成轮换，按需重载一次
+	if stale {
+		if err := ks.reload(); err != nil {
+			log.Printf("重新加载签名密钥失败: %v", err)
+		}
+		ks.mu.RLock()
+		key, ok = ks.keys[kid]
+		ks.mu.RUnlock()
+		if ok {
+			return key.Secret, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// ListKeys 返回所有仍然有效的密钥（不含密钥内容）
+func (ks *KeyStore) ListKeys() []Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	result := make([]Key, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		k := *key
+		k.Secret = nil
+		k.Current = k.KID == ks.current
+		result = append(result, k)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+// Rotate 生成新密钥作为签发密钥，旧密钥退役但在宽限期内仍可验证已签发的令牌
+func (ks *KeyStore) Rotate() (*Key, error) {
+	secret := make([]byte, SecretLength)
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	key := &Key{
+		KID:       now.Format("20060102150405") + "-" + hex.EncodeToString(suffix),
+		Secret:    secret,
+		Source:    "database",
+		CreatedAt: now,
+	}
+
+	tx, err := ks.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE auth_signing_keys SET retired_at = $1 WHERE retired_at IS NULL", now); err != nil {
+		return nil, fmt.Errorf("退役旧密钥失败: %w", err)
+	}
+	if _, err := tx.Exec("INSERT INTO auth_signing_keys(kid, secret, created_at) VALUES($1, $2, $3)",
+		key.KID, base64.StdEncoding.EncodeToString(secret), now); err != nil {
+		return nil, fmt.Errorf("保存新密钥失败: %w", err)
+	}
+	// 清理已超过宽限期的密钥
+	if _, err := tx.Exec("DELETE FROM auth_signing_keys WHERE retired_at < $1", now.Add(-ks.gracePeriod)); err != nil {
+		return nil, fmt.Errorf("清理过期密钥失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := ks.reload(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("签名密钥已轮换，新 kid: %s", key.KID)
+	return key, nil
+}
+
+// reload 从数据库加载仍在宽限期内的密钥，并与配置密钥合并
+func (ks *KeyStore) reload() error {
+	rows, err := ks.db.Query(`
+		SELECT kid, secret, created_at, retired_at
+		FROM auth_signing_keys
+		WHERE retired_at IS NULL OR retired_at > $1
+		ORDER BY created_at DESC
+	`, time.Now().Add(-ks.gracePeriod))
+	if err != nil {
+		return fmt.Errorf("加载签名密钥失败: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]*Key)
+	current := ""
+	var oldest time.Time
+	for rows.Next() {
+		var key Key
+		var encoded string
+		var retiredAt sql.NullTime
+		if err := rows.Scan(&key.KID, &encoded, &key.CreatedAt, &retiredAt); err != nil {
+			return err
+		}
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			log.Printf("签名密钥 %s 格式错误，已跳过: %v", key.KID, err)
+			continue
+		}
+		key.Secret = secret
+		key.Source = "database"
+		if retiredAt.Valid {
+			key.RetiredAt = &retiredAt.Time
+		} else if current == "" {
+			// 按创建时间倒序，第一把未退役的密钥即当前签发密钥
+			current = key.KID
+		}
+		if oldest.IsZero() || key.CreatedAt.Before(oldest) {
+			oldest = key.CreatedAt
+		}
+		keys[key.KID] = &key
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// 数据库中没有轮换出的密钥时，配置密钥用于签发。
+	// 第一次轮换即视为配置密钥退役，退役时间不晚于仍在宽限期内的最早一把数据库密钥的创建时间，
+	// 宽限期过后不再用于验证，泄露的 JWT_SECRET 可以通过轮换淘汰
+	configRetired := false
+	if ks.configKey != nil {
+		if _, exists := keys[ks.configKey.KID]; !exists {
+			switch {
+			case current == "":
+				keys[ks.configKey.KID] = ks.configKey
+				current = ks.configKey.KID
+			case time.Since(oldest) < ks.gracePeriod:
+				key := *ks.configKey
+				key.RetiredAt = &oldest
+				keys[key.KID] = &key
+			default:
+				configRetired = true
+			}
+		}
+	}
+
+	ks.mu.Lock()
+	if configRetired && !ks.configRetired {
+		log.Printf("配置中的签名密钥 %s 已被轮换出的密钥取代并超过宽限期，不再用于验证令牌，可从配置中移除 JWT_SECRET", ks.configKey.KID)
+	}
+	ks.configRetired = configRetired
+	ks.keys = keys
+	ks.current = current
+	ks.lastReload = time.Now()
+	ks.mu.Unlock()
+	return nil
+}
+
+// reloadLoop 定期从数据库重新加载密钥
+func (ks *KeyStore) reloadLoop() {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := ks.reload(); err != nil {
+			log.Printf("定时加载签名密钥失败: %v", err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package loginguard
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// 登录尝试结果原因
+const (
+	ReasonSuccess     = ""
+	ReasonBadPassword = "bad_password"
+	ReasonBadTOTP     = "bad_totp"
+	ReasonDisabled    = "disabled"
+	ReasonThrottled   = "throttled"
+	ReasonAdminUnlock = "admin_unlock"
+)
+
+// attemptRetention 登录尝试记录保留时长
+const attemptRetention = 30 * 24 * time.Hour
+
+// BlockedError 表示当前请求被限流或账号被锁定
+type BlockedError struct {
+	RetryAfter time.Duration
+	Locked     bool // true 表示账号被临时锁定，false 表示触发退避或 IP 限流
+}
+
+func (e *BlockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("账号已被临时锁定，请在 %s 后重试", formatWait(e.RetryAfter))
+	}
+	return fmt.Sprintf("尝试过于频繁，请在 %s 后重试", formatWait(e.RetryAfter))
+}
+
+// formatWait 将等待时间格式化为便于阅读的中文描述
+func formatWait(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d 秒", int(d.Seconds()+0.999))
+	}
+	return fmt.Sprintf("%d 分钟", int(d.Minutes()+0.999))
+}
+
+// Guard 登录暴力破解防护，失败记录持久化在数据库中，重启后依然有效
+type Guard struct {
+	db     *sql.DB
+	policy Policy
+}
+
+// NewGuard 创建登录防护服务
+func NewGuard(db *sql.DB, policy Policy) *Guard {
+	g := &Guard{
+		db:     db,
+		policy: policy,
+	}
+
+	// 启动过期记录清理协程
+	go g.cleanupLoop()
+
+	return g
+}
+
+// normalizeUsername 统一用户名大小写和空白，避免通过变体绕过计数
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// Check 在校验密码之前调用，判断该 IP 和用户名当前是否允许尝试登录
+func (g *Guard) Check(ip, username string) error {
+	now := time.Now()
+
+	// 1. IP 滑动窗口限流
+	var ipFailures int
+	var oldest sql.NullTime
+	err := g.db.QueryRow(`
+		SELECT COUNT(*), MIN(created_at)
+		FROM login_attempts
+		WHERE ip_address = $1 AND NOT success AND reason != $3 AND created_at > $2
+	`, ip, now.Add(-g.policy.IPWindow), ReasonThrottled).Scan(&ipFailures, &oldest)
+	if err != nil {
+		return err
+	}
+	if ipFailures >= g.policy.IPMaxFailures && oldest.Valid {
+		return &BlockedError{RetryAfter: oldest.Time.Add(g.policy.IPWindow).Sub(now)}
+	}
+
+	// 2. 用户名连续失败次数（自上次成功登录以来）
+	failures, lastFailure, err := g.consecutiveFailures(normalizeUsername(username), now)
+	if err != nil {
+		return err
+	}
+
+	// 达到阈值后临时锁定
+	if failures >= g.policy.LockoutThreshold {
+		if wait := lastFailure.Add(g.policy.LockoutDuration).Sub(now); wait > 0 {
+			return &BlockedError{RetryAfter: wait, Locked: true}
+		}
+		return nil
+	}
+
+	// 指数退避：每多失败一次，等待时间翻倍
+	if failures >= g.policy.BackoffAfter {
+		if wait := lastFailure.Add(g.backoff(failures)).Sub(now); wait > 0 {
+			return &BlockedError{RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// backoff 计算连续失败 n 次后需要等待的时间
+func (g *Guard) backoff(failures int) time.Duration {
+	wait := g.policy.BackoffBase
+	for i := g.policy.BackoffAfter; i < failures && wait < g.policy.BackoffMax; i++ {
+		wait *= 2
+	}
+	if wait > g.policy.BackoffMax {
+		wait = g.policy.BackoffMax
+	}
+	return wait
+}
+
+// consecutiveFailures 返回用户名自上次成功登录以来的失败次数和最近一次失败时间
+func (g *Guard) consecutiveFailures(username string, now time.Time) (int, time.Time, error) {
+	var count int
+	var last sql.NullTime
+	err := g.db.QueryRow(`
+		SELECT COUNT(*), MAX(created_at)
+		FROM login_attempts
+		WHERE username = $1 AND NOT success AND reason != $3
+		  AND created_at > GREATEST($2, COALESCE(
+			(SELECT MAX(created_at) FROM login_attempts WHERE username = $1 AND success),
+			'-infinity'::timestamp))
+	`, username, now.Add(-g.policy.FailureMemory), ReasonThrottled).Scan(&count, &last)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return count, last.Time, nil
+}
+
+// RecordFailure 记录一次失败的登录尝试并写入安全日志
+// 被限流拒绝的尝试（ReasonThrottled）只写日志，不计入失败次数，避免锁定时间被无限延长
+func (g *Guard) RecordFailure(ip, username, userAgent, reason string) {
+	username = normalizeUsername(username)
+	log.Printf("[安全] 登录失败: username=%s, ip=%s, reason=%s, ua=%s", username, ip, reason, userAgent)
+	g.record(ip, username, userAgent, false, reason)
+}
+
+// RecordSuccess 记录一次成功的登录，清零该用户名的连续失败次数
+func (g *Guard) RecordSuccess(ip, username, userAgent string) {
+	g.record(ip, normalizeUsername(username), userAgent, true, ReasonSuccess)
+}
+
+// Unlock 由管理员解除用户名锁定，写入一条成功记录以清零连续失败次数
+func (g *Guard) Unlock(username, operator string) {
+	log.Printf("[安全] 用户 %s 解除了账号锁定: username=%s", operator, username)
+	g.record("", normalizeUsername(username), "", true, ReasonAdminUnlock)
+}
+
+func (g *Guard) record(ip, username, userAgent string, success bool, reason string) {
+	if len(username) > 64 {
+		username = username[:64]
+	}
+	_, err := g.db.Exec(`
+		INSERT INTO login_attempts(username, ip_address, user_agent, success, reason, created_at)
+		VALUES($1, $2, $3, $4, $5, $6)
+	`, username, ip, userAgent, success, reason, time.Now())
+	if err != nil {
+		log.Printf("写入登录安全日志失败: %v", err)
+	}
+}
+
+// ListAttempts 分页查询登录安全日志，onlyFailures 为 true 时只返回失败记录
+func (g *Guard) ListAttempts(username string, onlyFailures bool, limit, offset int) ([]LoginAttempt, error) {
+	query := `
+		SELECT id, username, ip_address, COALESCE(user_agent, ''), success, reason, created_at
+		FROM login_attempts
+		WHERE ($1 = '' OR username = $1) AND (NOT $2 OR NOT success)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := g.db.Query(query, normalizeUsername(username), onlyFailures, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]LoginAttempt, 0)
+	for rows.Next() {
+		var a LoginAttempt
+		if err := rows.Scan(&a.ID, &a.Username, &a.IPAddress, &a.UserAgent, &a.Success, &a.Reason, &a.CreatedAt); err != nil {
+			log.Printf("扫描登录安全日志失败: %v", err)
+			continue
+		}
+		result = append(result, a)
+	}
+	return result, rows.Err()
+}
+
+// cleanupLoop 定期删除超过保留期限的登录尝试记录
+func (g *Guard) cleanupLoop() {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := g.db.Exec("DELETE FROM login_attempts WHERE created_at < $1", time.Now().Add(-attemptRetention)); err != nil {
+			log.Printf("清理登录安全日志失败: %v", err)
+		}
+	}
+}
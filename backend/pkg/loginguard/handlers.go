@@ -0,0 +1,50 @@
+package loginguard
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHandlers 注册登录安全日志和解锁路由，调用方负责传入已挂载认证和授权中间件的路由组
+func (g *Guard) RegisterHandlers(rg *gin.RouterGroup) {
+	group := rg.Group("/api/security")
+	{
+		group.GET("/login-attempts", g.handleListAttempts)
+		group.DELETE("/lockouts/:username", g.handleUnlock)
+	}
+}
+
+// handleListAttempts 处理查询登录安全日志的请求
+func (g *Guard) handleListAttempts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+	onlyFailures := c.Query("failures") == "1" || c.Query("failures") == "true"
+
+	attempts, err := g.ListAttempts(c.Query("username"), onlyFailures, pageSize, (page-1)*pageSize)
+	if err != nil {
+		log.Printf("查询登录安全日志失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询登录安全日志失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":      page,
+		"page_size": pageSize,
+		"items":     attempts,
+	})
+}
+
+// handleUnlock 处理解除账号锁定的请求
+func (g *Guard) handleUnlock(c *gin.Context) {
+	g.Unlock(c.Param("username"), c.GetString("username"))
+	c.JSON(http.StatusOK, gin.H{"message": "账号已解锁"})
+}
@@ -0,0 +1,49 @@
+package loginguard
+
+import (
+	"time"
+)
+
+// LoginAttempt 代表一次登录尝试，同时作为安全日志
+type LoginAttempt struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason"` // 失败原因，例如 bad_password、bad_totp、locked
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Policy 登录限流策略
+type Policy struct {
+	// 单个 IP 在滑动窗口内允许的最大失败次数
+	IPMaxFailures int
+	IPWindow      time.Duration
+
+	// 同一用户名连续失败达到 BackoffAfter 次后开始指数退避
+	BackoffAfter int
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+
+	// 同一用户名连续失败达到 LockoutThreshold 次后临时锁定
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+
+	// 连续失败次数的统计范围，超过该时间的失败记录不再计入
+	FailureMemory time.Duration
+}
+
+// DefaultPolicy 返回默认限流策略
+func DefaultPolicy() Policy {
+	return Policy{
+		IPMaxFailures:    30,
+		IPWindow:         15 * time.Minute,
+		BackoffAfter:     3,
+		BackoffBase:      1 * time.Second,
+		BackoffMax:       1 * time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  30 * time.Minute,
+		FailureMemory:    24 * time.Hour,
+	}
+}
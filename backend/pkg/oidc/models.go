@@ -0,0 +1,63 @@
+package oidc
+
+// Config OpenID Connect 身份提供方配置
+type Config struct {
+	Name         string // 显示在登录页按钮上的名称
+	Issuer       string // 签发方地址，用于发现 /.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string // 公共客户端（仅 PKCE）可留空
+	RedirectURL  string // 回调地址，须与身份提供方中登记的一致
+	Scopes       []string
+
+	// GroupsClaim 携带用户组的声明名称，ID Token 中没有时会尝试从 UserInfo 接口读取
+	GroupsClaim string
+	// AllowedDomains 允许登录的邮箱域名，为空表示不限制
+	AllowedDomains []string
+	// RoleMappings 按顺序匹配用户组，取第一个命中的角色；都未命中时使用 DefaultRole，为空则拒绝登录
+	RoleMappings []RoleMapping
+	DefaultRole  string
+}
+
+// RoleMapping 用户组到本地角色的映射
+type RoleMapping struct {
+	Role   string
+	Groups []string
+}
+
+// Identity 身份提供方验证后的用户身份
+type Identity struct {
+	Subject string   // 身份提供方中的唯一用户ID（sub）
+	Email   string   // 已验证的邮箱
+	Name    string   // 显示名称
+	Groups  []string // 所属用户组
+}
+
+// metadata 发现文档中用到的字段
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// tokenResponse 令牌端点响应
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	TokenType        string `json:"token_type"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// jsonWebKey JWKS 中的单个公钥
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
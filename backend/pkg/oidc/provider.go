@@ -0,0 +1,407 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval 遇到未知 kid 时重新拉取公钥的最小间隔，防止被恶意令牌触发频繁请求
+const jwksRefreshInterval = time.Minute
+
+var (
+	ErrEmailNotVerified = errors.New("身份提供方未确认该邮箱")
+	ErrDomainNotAllowed = errors.New("该邮箱域名不允许登录")
+	ErrNoRole           = errors.New("该账号所在的用户组没有后台访问权限")
+)
+
+// Provider OpenID Connect 授权码 + PKCE 登录流程的客户端
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu            sync.Mutex
+	metadata      *metadata
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// NewProvider 创建身份提供方客户端，发现文档在首次使用时加载，启动时身份提供方不可用也不影响服务
+func NewProvider(cfg Config) *Provider {
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	return &Provider{
+		config: cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name 返回身份提供方的显示名称
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// RandomString 生成 URL 安全的随机字符串，用于 state、nonce 和 PKCE code_verifier
+func RandomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// codeChallenge 计算 PKCE S256 code_challenge
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// discover 加载并缓存发现文档
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var m metadata
+	if err := p.getJSON(ctx, p.config.Issuer+"/.well-known/openid-configuration", "", &m); err != nil {
+		return nil, fmt.Errorf("加载 OIDC 发现文档失败: %w", err)
+	}
+	if strings.TrimRight(m.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("发现文档中的 issuer 不匹配: %s", m.Issuer)
+	}
+	if m.AuthorizationEndpoint == "" || m.TokenEndpoint == "" || m.JWKSURI == "" {
+		return nil, fmt.Errorf("发现文档缺少必要的端点")
+	}
+	p.metadata = &m
+	return p.metadata, nil
+}
+
+// AuthCodeURL 生成跳转到身份提供方的授权地址
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	m, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge(verifier))
+	params.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(m.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return m.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange 用授权码换取令牌，校验 ID Token 后返回用户身份
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	m, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("client_id", p.config.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求令牌端点失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("解析令牌端点响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("令牌端点返回错误: %d %s %s", resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("令牌端点未返回 id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// ID Token 不含用户组或邮箱时，从 UserInfo 接口补充
+	if m.UserinfoEndpoint != "" && token.AccessToken != "" &&
+		(claims[p.config.GroupsClaim] == nil || claims["email"] == nil) {
+		var info jwt.MapClaims
+		if err := p.getJSON(ctx, m.UserinfoEndpoint, token.AccessToken, &info); err != nil {
+			log.Printf("读取 OIDC UserInfo 失败: %v", err)
+		} else if info["sub"] == claims["sub"] {
+			for _, key := range []string{p.config.GroupsClaim, "email", "email_verified", "name"} {
+				if claims[key] == nil && info[key] != nil {
+					claims[key] = info[key]
+				}
+			}
+		}
+	}
+
+	return p.identityFromClaims(claims)
+}
+
+// verifyIDToken 校验 ID Token 的签名、签发方、受众、有效期和 nonce
+func (p *Provider) verifyIDToken(ctx context.Context, idToken, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID Token 校验失败: %w", err)
+	}
+
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, fmt.Errorf("ID Token nonce 不匹配")
+	}
+	// 多个受众时 azp 必须是本客户端
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.config.ClientID {
+			return nil, fmt.Errorf("ID Token azp 不匹配")
+		}
+	}
+	return claims, nil
+}
+
+// identityFromClaims 从声明中提取身份，并检查邮箱验证状态和域名
+func (p *Provider) identityFromClaims(claims jwt.MapClaims) (*Identity, error) {
+	identity := &Identity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	identity.Email = strings.ToLower(strings.TrimSpace(identity.Email))
+	if identity.Subject == "" || identity.Email == "" {
+		return nil, fmt.Errorf("身份信息缺少 sub 或 email")
+	}
+
+	// 部分身份提供方以字符串形式返回 email_verified
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		if !v {
+			return nil, ErrEmailNotVerified
+		}
+	case string:
+		if v != "true" {
+			return nil, ErrEmailNotVerified
+		}
+	default:
+		return nil, ErrEmailNotVerified
+	}
+
+	if len(p.config.AllowedDomains) > 0 {
+		_, domain, _ := strings.Cut(identity.Email, "@")
+		allowed := false
+		for _, d := range p.config.AllowedDomains {
+			if strings.EqualFold(d, domain) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, ErrDomainNotAllowed
+		}
+	}
+
+	switch v := claims[p.config.GroupsClaim].(type) {
+	case string:
+		identity.Groups = []string{v}
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				identity.Groups = append(identity.Groups, s)
+			}
+		}
+	}
+	return identity, nil
+}
+
+// RoleFor 根据用户组映射本地角色
+func (p *Provider) RoleFor(identity *Identity) (string, error) {
+	member := make(map[string]bool, len(identity.Groups))
+	for _, g := range identity.Groups {
+		member[g] = true
+	}
+	for _, mapping := range p.config.RoleMappings {
+		for _, g := range mapping.Groups {
+			if member[g] {
+				return mapping.Role, nil
+			}
+		}
+	}
+	if p.config.DefaultRole != "" {
+		return p.config.DefaultRole, nil
+	}
+	return "", ErrNoRole
+}
+
+// publicKey 根据 kid 返回验证 ID Token 的公钥，未知 kid 时重新拉取 JWKS
+func (p *Provider) publicKey(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+	}
+	if p.metadata == nil {
+		return nil, fmt.Errorf("发现文档尚未加载")
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	p.keysFetchedAt = time.Now()
+	if err := p.getJSON(ctx, p.metadata.JWKSURI, "", &set); err != nil {
+		return nil, fmt.Errorf("加载 JWKS 失败: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := parseJSONWebKey(k)
+		if err != nil {
+			log.Printf("跳过无法解析的 JWKS 公钥 %s: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	p.keys = keys
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+}
+
+// lookupKey 查找已缓存的公钥，令牌未指定 kid 且只有一个公钥时直接使用该公钥
+func (p *Provider) lookupKey(kid string) interface{} {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+// parseJSONWebKey 将 JWK 转换为 RSA 或 ECDSA 公钥
+func parseJSONWebKey(k jsonWebKey) (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的曲线: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("公钥不在曲线上")
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("不支持的密钥类型: %s", k.Kty)
+}
+
+// getJSON 发送 GET 请求并解析 JSON 响应，bearer 非空时携带访问令牌
+func (p *Provider) getJSON(ctx context.Context, endpoint, bearer string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s 返回状态码 %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,414 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "mblog"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "https://blog.example.com/api/auth/oidc/callback"
+	testKeyID        = "test-key"
+)
+
+// mockIssuer 本地模拟的身份提供方，实现发现文档、JWKS、令牌端点（校验 PKCE）和 UserInfo
+type mockIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu sync.Mutex
+	// codes 授权码 -> 授权时的 code_challenge 和 nonce
+	codes map[string]authorization
+	// claims 修改签发的 ID Token 声明，为 nil 时使用默认声明
+	claims func(claims jwt.MapClaims)
+	// userinfo UserInfo 接口返回的声明
+	userinfo jwt.MapClaims
+	// issuer 发现文档中的 issuer，为空时使用服务地址
+	issuer string
+	// signingKey 签发 ID Token 的私钥，为 nil 时使用 JWKS 中公布的密钥
+	signingKey *rsa.PrivateKey
+}
+
+type authorization struct {
+	challenge string
+	nonce     string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{t: t, key: key, codes: make(map[string]authorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.handleDiscovery)
+	mux.HandleFunc("/jwks", m.handleJWKS)
+	mux.HandleFunc("/token", m.handleToken)
+	mux.HandleFunc("/userinfo", m.handleUserinfo)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockIssuer) provider(cfg Config) *Provider {
+	cfg.Issuer = m.server.URL
+	cfg.ClientID = testClientID
+	cfg.ClientSecret = testClientSecret
+	cfg.RedirectURL = testRedirectURL
+	return NewProvider(cfg)
+}
+
+// authorize 模拟用户在身份提供方完成登录：解析授权地址，登记授权码
+func (m *mockIssuer) authorize(authURL string) string {
+	m.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	q := u.Query()
+	if got := u.Scheme + "://" + u.Host + u.Path; got != m.server.URL+"/authorize" {
+		m.t.Fatalf("授权地址 = %s", got)
+	}
+	for key, want := range map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"code_challenge_method": "S256",
+	} {
+		if got := q.Get(key); got != want {
+			m.t.Fatalf("授权参数 %s = %q，期望 %q", key, got, want)
+		}
+	}
+	if q.Get("state") == "" || q.Get("nonce") == "" || q.Get("code_challenge") == "" {
+		m.t.Fatalf("授权地址缺少 state、nonce 或 code_challenge: %s", authURL)
+	}
+
+	code, err := RandomString()
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	m.mu.Lock()
+	m.codes[code] = authorization{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	m.mu.Unlock()
+	return code
+}
+
+func (m *mockIssuer) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	issuer := m.issuer
+	if issuer == "" {
+		issuer = m.server.URL
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 issuer,
+		"authorization_endpoint": m.server.URL + "/authorize",
+		"token_endpoint":         m.server.URL + "/token",
+		"userinfo_endpoint":      m.server.URL + "/userinfo",
+		"jwks_uri":               m.server.URL + "/jwks",
+	})
+}
+
+func (m *mockIssuer) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	pub := m.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (m *mockIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok || clientID != testClientID || secret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != testRedirectURL {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	m.mu.Lock()
+	auth, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+	if !ok || codeChallenge(r.PostForm.Get("code_verifier")) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "invalid_grant", "error_description": "code or code_verifier mismatch",
+		})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            m.server.URL,
+		"sub":            "user-1",
+		"aud":            testClientID,
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          auth.nonce,
+		"email":          "Alice@Example.com",
+		"email_verified": true,
+		"name":           "Alice",
+	}
+	if m.claims != nil {
+		m.claims(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	key := m.key
+	if m.signingKey != nil {
+		key = m.signingKey
+	}
+	idToken, err := token.SignedString(key)
+	if err != nil {
+		m.t.Error(err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": "access-" + r.PostForm.Get("code"),
+		"id_token":     idToken,
+		"token_type":   "Bearer",
+	})
+}
+
+func (m *mockIssuer) handleUserinfo(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer access-") {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	m.mu.Lock()
+	info := m.userinfo
+	m.mu.Unlock()
+	if info == nil {
+		info = jwt.MapClaims{"sub": "user-1"}
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// login 走完一次授权码流程，返回 Exchange 的结果
+func login(t *testing.T, m *mockIssuer, p *Provider) (*Identity, error) {
+	t.Helper()
+	ctx := context.Background()
+	state, _ := RandomString()
+	nonce, _ := RandomString()
+	verifier, _ := RandomString()
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code := m.authorize(authURL)
+	return p.Exchange(ctx, code, verifier, nonce)
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockIssuer(t)
+	m.userinfo = jwt.MapClaims{"sub": "user-1", "groups": []string{"blog-admins", "staff"}}
+	p := m.provider(Config{})
+
+	identity, err := login(t, m, p)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.Subject != "user-1" || identity.Email != "alice@example.com" || identity.Name != "Alice" {
+		t.Errorf("identity = %+v", identity)
+	}
+	// ID Token 中没有用户组，从 UserInfo 补充
+	if strings.Join(identity.Groups, ",") != "blog-admins,staff" {
+		t.Errorf("groups = %v", identity.Groups)
+	}
+}
+
+func TestExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		claims  func(jwt.MapClaims)
+		wantErr error
+	}{
+		{
+			name:   "签发方不匹配",
+			claims: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		},
+		{
+			name:   "受众不匹配",
+			claims: func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		},
+		{
+			name:   "已过期",
+			claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		},
+		{
+			name:   "缺少过期时间",
+			claims: func(c jwt.MapClaims) { delete(c, "exp") },
+		},
+		{
+			name:   "nonce 不匹配",
+			claims: func(c jwt.MapClaims) { c["nonce"] = "replayed" },
+		},
+		{
+			name:   "缺少 nonce",
+			claims: func(c jwt.MapClaims) { delete(c, "nonce") },
+		},
+		{
+			name: "多个受众且 azp 不是本客户端",
+			claims: func(c jwt.MapClaims) {
+				c["aud"] = []string{testClientID, "other-client"}
+				c["azp"] = "other-client"
+			},
+		},
+		{
+			name:   "多个受众且缺少 azp",
+			claims: func(c jwt.MapClaims) { c["aud"] = []string{testClientID, "other-client"} },
+		},
+		{
+			name:    "邮箱未验证",
+			claims:  func(c jwt.MapClaims) { c["email_verified"] = false },
+			wantErr: ErrEmailNotVerified,
+		},
+		{
+			name:    "缺少 email_verified",
+			claims:  func(c jwt.MapClaims) { delete(c, "email_verified") },
+			wantErr: ErrEmailNotVerified,
+		},
+		{
+			name:    "邮箱域名不允许",
+			config:  Config{AllowedDomains: []string{"corp.example.com"}},
+			wantErr: ErrDomainNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockIssuer(t)
+			m.claims = tt.claims
+			identity, err := login(t, m, m.provider(tt.config))
+			if err == nil {
+				t.Fatalf("期望失败，得到 %+v", identity)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v，期望 %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExchangeAcceptsAZP(t *testing.T) {
+	m := newMockIssuer(t)
+	m.claims = func(c jwt.MapClaims) {
+		c["aud"] = []string{testClientID, "other-client"}
+		c["azp"] = testClientID
+		c["email_verified"] = "true"
+		c["groups"] = "blog-editors"
+	}
+	identity, err := login(t, m, m.provider(Config{AllowedDomains: []string{"EXAMPLE.com"}}))
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if len(identity.Groups) != 1 || identity.Groups[0] != "blog-editors" {
+		t.Errorf("groups = %v", identity.Groups)
+	}
+}
+
+func TestExchangeWrongVerifier(t *testing.T) {
+	m := newMockIssuer(t)
+	p := m.provider(Config{})
+	ctx := context.Background()
+
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := m.authorize(authURL)
+	if _, err := p.Exchange(ctx, code, "another-verifier", "nonce"); err == nil ||
+		!strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("err = %v，期望令牌端点拒绝 code_verifier", err)
+	}
+	// 授权码只能使用一次
+	if _, err := p.Exchange(ctx, code, "verifier", "nonce"); err == nil {
+		t.Fatal("重复使用授权码应当失败")
+	}
+}
+
+func TestExchangeForgedSignature(t *testing.T) {
+	m := newMockIssuer(t)
+	forged, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 以 JWKS 中公布的 kid 签名，但使用另一把私钥
+	m.signingKey = forged
+	if identity, err := login(t, m, m.provider(Config{})); err == nil {
+		t.Fatalf("签名不匹配的 ID Token 应当被拒绝，得到 %+v", identity)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	m := newMockIssuer(t)
+	m.issuer = "https://other.example.com"
+	if _, err := m.provider(Config{}).AuthCodeURL(context.Background(), "s", "n", "v"); err == nil {
+		t.Fatal("发现文档中的 issuer 不匹配时应当失败")
+	}
+}
+
+func TestRoleFor(t *testing.T) {
+	p := NewProvider(Config{
+		RoleMappings: []RoleMapping{
+			{Role: "admin", Groups: []string{"blog-admins"}},
+			{Role: "editor", Groups: []string{"blog-editors", "writers"}},
+		},
+	})
+	tests := []struct {
+		groups []string
+		want   string
+	}{
+		{[]string{"writers", "blog-admins"}, "admin"},
+		{[]string{"writers"}, "editor"},
+		{[]string{"staff"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		role, err := p.RoleFor(&Identity{Groups: tt.groups})
+		if role != tt.want {
+			t.Errorf("RoleFor(%v) = %q，期望 %q", tt.groups, role, tt.want)
+		}
+		if tt.want == "" && !errors.Is(err, ErrNoRole) {
+			t.Errorf("RoleFor(%v) err = %v，期望 ErrNoRole", tt.groups, err)
+		}
+	}
+
+	p.config.DefaultRole = "analyst"
+	if role, err := p.RoleFor(&Identity{Groups: []string{"staff"}}); err != nil || role != "analyst" {
+		t.Errorf("默认角色 = %q, %v", role, err)
+	}
+}
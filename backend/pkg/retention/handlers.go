@@ -0,0 +1,42 @@
+package retention
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHandlers 注册数据保留管理路由，调用方负责传入已挂载认证和授权中间件的路由组
+func (rs *RetentionService) RegisterHandlers(rg *gin.RouterGroup) {
+	rg.GET("/api/retention", rs.handleStatus)
+	rg.GET("/api/retention/preview", rs.handlePreview)
+}
+
+// handleStatus 返回已启用的保留策略和最近一次清理结果
+func (rs *RetentionService) handleStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"policies":   rs.Policies(),
+		"interval":   rs.opts.Interval.String(),
+		"batch_size": rs.opts.BatchSize,
+		"dry_run":    rs.opts.DryRun,
+		"last_run":   rs.LastRun(),
+	})
+}
+
+// handlePreview 试运行全部策略，返回每条策略将清理的行数，不修改任何数据
+func (rs *RetentionService) handlePreview(c *gin.Context) {
+	run, err := rs.Run(c.Request.Context(), true)
+	if errors.Is(err, ErrRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": "数据清理任务正在执行，请稍后再试"})
+		return
+	}
+	if run == nil {
+		log.Printf("数据清理试运行失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据清理试运行失败"})
+		return
+	}
+	// 单条策略的错误已记录在对应结果中
+	c.JSON(http.StatusOK, run)
+}
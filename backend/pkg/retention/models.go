@@ -0,0 +1,56 @@
+package retention
+
+import (
+	"time"
+)
+
+// 清理动作
+const (
+	ActionDelete  = "delete"  // 删除整行
+	ActionNullify = "nullify" // 将指定字段置空
+)
+
+// Options 数据保留任务的配置，各天数为 0 表示不启用对应策略
+type Options struct {
+	TrackEventDays          int
+	TrackEventIPDays        int
+	TrackEventUserAgentDays int
+	CommentIPDays           int
+	CommentUserAgentDays    int
+
+	BatchSize int           // 每批删除或更新的行数
+	Interval  time.Duration // 定时执行的间隔，为 0 时不启动定时任务
+	DryRun    bool          // 定时任务只统计不清理
+}
+
+// Policy 一条保留策略：created_at 早于保留期的行被删除，或将 Set 中的字段置空
+type Policy struct {
+	Name   string `json:"name"` // 如 track_event、comments.ip_address
+	Table  string `json:"table"`
+	Action string `json:"action"`
+	Days   int    `json:"days"`
+
+	set            string // 置空策略的 SET 子句
+	pending        string // 置空策略中仍需清理的行的条件
+	dropPartitions bool   // 删除策略先整体删除过期的月分区
+}
+
+// Result 一条策略的执行结果
+type Result struct {
+	Policy     string   `json:"policy"`
+	Action     string   `json:"action"`
+	DryRun     bool     `json:"dry_run"`
+	Cutoff     string   `json:"cutoff"`               // 早于该时间的数据被清理
+	Rows       int64    `json:"rows"`                 // 已清理的行数，试运行时为将被清理的行数
+	Partitions []string `json:"partitions,omitempty"` // 整体删除的月分区
+	Batches    int      `json:"batches"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Run 一次清理任务的执行记录
+type Run struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DryRun     bool      `json:"dry_run"`
+	Results    []Result  `json:"results"`
+}
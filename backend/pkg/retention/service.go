@@ -0,0 +1,314 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"blog/pkg/tracking"
+)
+
+// retentionLockKey 清理任务的咨询锁，多个实例同时运行时只有一个实例执行清理
+const retentionLockKey int64 = 0x6d626c6f6772 // "mblogr"
+
+// ErrRunning 其他实例或请求正在执行清理
+var ErrRunning = errors.New("数据清理任务正在执行")
+
+// RetentionService 按保留策略清理过期数据：删除过期的埋点事件，置空过期的 IP 地址和用户代理。
+// 每批只处理 BatchSize 行并单独提交，避免长时间持有锁或产生大事务
+type RetentionService struct {
+	db       *sql.DB
+	opts     Options
+	policies []Policy
+
+	mu      sync.Mutex
+	lastRun *Run
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewRetentionService 创建数据保留服务，配置了策略和执行间隔时启动定时清理
+func NewRetentionService(db *sql.DB, opts Options) *RetentionService {
+	rs := &RetentionService{
+		db:       db,
+		opts:     opts,
+		policies: buildPolicies(opts),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	if opts.Interval > 0 && len(rs.policies) > 0 {
+		go rs.loop()
+	} else {
+		close(rs.stopped)
+	}
+	return rs
+}
+
+// buildPolicies 根据配置生成保留策略，删除整行的策略排在前面，减少后续置空的行数
+func buildPolicies(opts Options) []Policy {
+	var policies []Policy
+	if opts.TrackEventDays > 0 {
+		policies = append(policies, Policy{
+			Name: "track_event", Table: "track_event", Action: ActionDelete, Days: opts.TrackEventDays,
+			dropPartitions: true,
+		})
+	}
+	if opts.TrackEventIPDays > 0 {
+		policies = append(policies, Policy{
+			Name: "track_event.ip_address", Table: "track_event", Action: ActionNullify, Days: opts.TrackEventIPDays,
+			set:     "ip_address = NULL",
+			pending: "ip_address IS NOT NULL",
+		})
+	}
+	if opts.TrackEventUserAgentDays > 0 {
+		// 埋点中间件还会把用户代理复制到 metadata.user_agent，一并清除
+		policies = append(policies, Policy{
+			Name: "track_event.user_agent", Table: "track_event", Action: ActionNullify, Days: opts.TrackEventUserAgentDays,
+			set:     "user_agent = NULL, metadata = metadata - 'user_agent'",
+			pending: "(user_agent IS NOT NULL OR metadata ? 'user_agent')",
+		})
+	}
+	if opts.CommentIPDays > 0 {
+		policies = append(policies, Policy{
+			Name: "comments.ip_address", Table: "comments", Action: ActionNullify, Days: opts.CommentIPDays,
+			set:     "ip_address = NULL",
+			pending: "ip_address IS NOT NULL",
+		})
+	}
+	if opts.CommentUserAgentDays > 0 {
+		policies = append(policies, Policy{
+			Name: "comments.user_agent", Table: "comments", Action: ActionNullify, Days: opts.CommentUserAgentDays,
+			set:     "user_agent = NULL",
+			pending: "user_agent IS NOT NULL",
+		})
+	}
+	return policies
+}
+
+// Policies 返回已启用的保留策略
+func (rs *RetentionService) Policies() []Policy {
+	return rs.policies
+}
+
+// LastRun 返回最近一次清理的执行记录，尚未执行过时为 nil
+func (rs *RetentionService) LastRun() *Run {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.lastRun
+}
+
+// Close 停止定时清理，正在执行的批次会被中止
+func (rs *RetentionService) Close() {
+	select {
+	case <-rs.stop:
+	default:
+		close(rs.stop)
+	}
+	<-rs.stopped
+}
+
+// loop 按间隔定期执行清理
+func (rs *RetentionService) loop() {
+	defer close(rs.stopped)
+
+	ticker := time.NewTicker(rs.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-ticker.C:
+			rs.runScheduled()
+		}
+	}
+}
+
+// runScheduled 执行一次定时清理，服务关闭时中止
+func (rs *RetentionService) runScheduled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-rs.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if _, err := rs.Run(ctx, rs.opts.DryRun); err != nil && !errors.Is(err, ErrRunning) && ctx.Err() == nil {
+		log.Printf("数据清理失败: %v", err)
+	}
+}
+
+// Run 依次执行全部保留策略，dryRun 时只统计将被清理的行数。
+// 单条策略失败不影响其他策略，返回的错误汇总了所有失败
+func (rs *RetentionService) Run(ctx context.Context, dryRun bool) (*Run, error) {
+	conn, err := rs.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", retentionLockKey).Scan(&locked); err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrRunning
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", retentionLockKey)
+
+	run := &Run{StartedAt: time.Now(), DryRun: dryRun}
+	var errs []error
+	for _, policy := range rs.policies {
+		result, err := rs.apply(ctx, policy, dryRun)
+		if err != nil {
+			result.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", policy.Name, err))
+		}
+		run.Results = append(run.Results, result)
+		logResult(result)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	run.FinishedAt = time.Now()
+
+	rs.mu.Lock()
+	rs.lastRun = run
+	rs.mu.Unlock()
+	return run, errors.Join(errs...)
+}
+
+// apply 执行一条策略
+func (rs *RetentionService) apply(ctx context.Context, policy Policy, dryRun bool) (Result, error) {
+	result := Result{Policy: policy.Name, Action: policy.Action, DryRun: dryRun}
+
+	// 截止时间按数据库会话时区计算，与 created_at 写入的本地时间一致
+	var cutoff time.Time
+	if err := rs.db.QueryRowContext(ctx,
+		"SELECT LOCALTIMESTAMP - make_interval(days => $1)", policy.Days,
+	).Scan(&cutoff); err != nil {
+		return result, err
+	}
+	result.Cutoff = cutoff.Format("2006-01-02 15:04:05")
+
+	condition := "created_at < $1"
+	if policy.pending != "" {
+		condition += " AND " + policy.pending
+	}
+
+	// 整月都已过期的分区直接删除，比逐行删除快得多且不会产生表膨胀
+	if policy.dropPartitions {
+		partitions, rows, err := tracking.DropPartitionsBefore(ctx, rs.db, cutoff, dryRun)
+		result.Partitions = partitions
+		if !dryRun {
+			result.Rows += rows
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if dryRun {
+		var rows int64
+		err := rs.db.QueryRowContext(ctx,
+			fmt.Sprintf("SELECT count(*) FROM %s WHERE %s", policy.Table, condition), cutoff,
+		).Scan(&rows)
+		result.Rows = rows
+		return result, err
+	}
+
+	tables, err := rs.leafTables(ctx, policy.Table)
+	if err != nil {
+		return result, err
+	}
+	for _, table := range tables {
+		var stmt string
+		if policy.Action == ActionDelete {
+			stmt = fmt.Sprintf("DELETE FROM ONLY %s WHERE ctid = ANY(ARRAY(SELECT ctid FROM ONLY %s WHERE %s LIMIT $2))",
+				table, table, condition)
+		} else {
+			stmt = fmt.Sprintf("UPDATE ONLY %s SET %s WHERE ctid = ANY(ARRAY(SELECT ctid FROM ONLY %s WHERE %s LIMIT $2))",
+				table, policy.set, table, condition)
+		}
+
+		for {
+			res, err := rs.db.ExecContext(ctx, stmt, cutoff, rs.opts.BatchSize)
+			if err != nil {
+				return result, err
+			}
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return result, err
+			}
+			if rows > 0 {
+				result.Batches++
+				result.Rows += rows
+			}
+			if rows < int64(rs.opts.BatchSize) {
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// leafTables 返回实际存放数据的表：分区表返回其全部分区，普通表返回自身。
+// 按分区逐个处理时可以使用 ctid 定位行，避免每批都扫描整个过期范围
+func (rs *RetentionService) leafTables(ctx context.Context, table string) ([]string, error) {
+	rows, err := rs.db.QueryContext(ctx, `
+		SELECT c.oid::regclass::text
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = to_regclass($1)
+		ORDER BY 1
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		tables = []string{table}
+	}
+	return tables, nil
+}
+
+func logResult(r Result) {
+	switch {
+	case r.Error != "":
+		log.Printf("数据清理 %s 失败（已处理 %d 行）: %s", r.Policy, r.Rows, r.Error)
+	case r.DryRun:
+		log.Printf("数据清理试运行 %s: 早于 %s 的 %d 行将被%s", r.Policy, r.Cutoff, r.Rows, actionName(r.Action))
+	case r.Rows > 0:
+		log.Printf("数据清理 %s: 已%s早于 %s 的 %d 行，共 %d 批，整体删除分区 %v", r.Policy, actionName(r.Action), r.Cutoff, r.Rows, r.Batches, r.Partitions)
+	}
+}
+
+// actionName 清理动作的中文名称
+func actionName(action string) string {
+	if action == ActionDelete {
+		return "删除"
+	}
+	return "置空"
+}
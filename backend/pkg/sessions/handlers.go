@@ -0,0 +1,82 @@
+package sessions
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHandlers 注册会话管理路由，调用方负责传入已挂载认证和授权中间件的路由组
+func (ss *SessionService) RegisterHandlers(rg *gin.RouterGroup) {
+	// 某个用户的全部会话
+	rg.GET("/api/users/:id/sessions", ss.handleListUserSessions)
+	// 退出该用户的所有登录（"全部登出"）
+	rg.DELETE("/api/users/:id/sessions", ss.handleRevokeUserSessions)
+	// 注销单个会话
+	rg.DELETE("/api/sessions/:sid", ss.handleRevokeSession)
+}
+
+// handleListUserSessions 处理获取用户有效会话列表的请求
+func (ss *SessionService) handleListUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	list, err := ss.ListActiveByUser(userID)
+	if err != nil {
+		log.Printf("获取会话列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取会话列表失败"})
+		return
+	}
+
+	// 标记发起请求的会话，便于前端提示
+	current := c.GetString("session_id")
+	result := make([]gin.H, 0, len(list))
+	for _, s := range list {
+		result = append(result, gin.H{
+			"id":           s.ID,
+			"user_id":      s.UserID,
+			"ip_address":   s.IPAddress,
+			"user_agent":   s.UserAgent,
+			"created_at":   s.CreatedAt,
+			"last_seen_at": s.LastSeenAt,
+			"expires_at":   s.ExpiresAt,
+			"current":      s.ID == current,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// handleRevokeUserSessions 处理注销用户全部会话的请求
+func (ss *SessionService) handleRevokeUserSessions(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	count, err := ss.RevokeAllForUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销会话失败"})
+		return
+	}
+
+	log.Printf("用户 %s 注销了用户 %d 的全部会话，共 %d 个", c.GetString("username"), userID, count)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已退出该用户的所有登录",
+		"revoked": count,
+	})
+}
+
+// handleRevokeSession 处理注销单个会话的请求
+func (ss *SessionService) handleRevokeSession(c *gin.Context) {
+	if err := ss.Revoke(c.Param("sid")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销会话失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "会话已注销"})
+}
@@ -0,0 +1,17 @@
+package sessions
+
+import (
+	"time"
+)
+
+// Session 代表一次登录会话，ID 同时作为访问令牌的 jti
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     int64      `json:"user_id"`
+	IPAddress  string     `json:"ip_address"`   // 登录时的 IP
+	UserAgent  string     `json:"user_agent"`   // 登录时的用户代理
+	CreatedAt  time.Time  `json:"created_at"`   // 登录时间
+	LastSeenAt time.Time  `json:"last_seen_at"` // 最近一次刷新令牌的时间
+	ExpiresAt  time.Time  `json:"expires_at"`   // 刷新令牌过期时间
+	RevokedAt  *time.Time `json:"revoked_at"`   // 注销时间
+}
@@ -0,0 +1,302 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// RefreshTokenExpiration 刷新令牌有效期，每次轮换后顺延
+	RefreshTokenExpiration = 7 * 24 * time.Hour
+	// rotationGracePeriod 刷新令牌轮换后，旧令牌在该时间内仍可换取访问令牌
+	// 用于兼容页面并发请求同时触发刷新的情况，超过后再使用旧令牌视为泄露
+	rotationGracePeriod = 30 * time.Second
+)
+
+var ErrSessionInvalid = errors.New("会话无效或已过期")
+
+// SessionService 管理登录会话和刷新令牌
+type SessionService struct {
+	db *sql.DB
+}
+
+// NewSessionService 创建新的会话服务
+func NewSessionService(db *sql.DB) *SessionService {
+	ss := &SessionService{
+		db: db,
+	}
+
+	// 启动过期会话清理协程
+	go ss.cleanupLoop()
+
+	return ss
+}
+
+// randomToken 生成指定字节数的随机字符串
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashSecret 计算刷新令牌密文的哈希，数据库中只保存哈希
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitRefreshToken 将 "会话ID.密文" 格式的刷新令牌拆分
+func splitRefreshToken(token string) (string, string, bool) {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || id == "" || secret == "" {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+// Create 为用户创建新会话，返回会话和刷新令牌
+func (ss *SessionService) Create(userID int64, ipAddress, userAgent string) (*Session, string, error) {
+	id, err := randomToken(18)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:         id,
+		UserID:     userID,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(RefreshTokenExpiration),
+	}
+
+	_, err = ss.db.Exec(`
+		INSERT INTO auth_sessions(id, user_id, refresh_hash, ip_address, user_agent, created_at, last_seen_at, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6, $6, $7)
+	`, session.ID, session.UserID, hashSecret(secret), session.IPAddress, session.UserAgent, now, session.ExpiresAt)
+	if err != nil {
+		log.Printf("创建会话失败: %v", err)
+		return nil, "", err
+	}
+
+	return session, session.ID + "." + secret, nil
+}
+
+// Rotate 使用刷新令牌换取新的刷新令牌
+// 在轮换宽限期内重复使用上一个令牌时，返回的新刷新令牌为空，调用方应保留浏览器中已有的令牌；
+// 超过宽限期后再使用旧令牌视为令牌泄露，整个会话会被注销
+func (ss *SessionService) Rotate(refreshToken string) (*Session, string, error) {
+	id, secret, ok := splitRefreshToken(refreshToken)
+	if !ok {
+		return nil, "", ErrSessionInvalid
+	}
+
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+
+	var session Session
+	var currentHash string
+	var previousHash sql.NullString
+	var rotatedAt, revokedAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT id, user_id, refresh_hash, previous_refresh_hash, rotated_at,
+			ip_address, user_agent, created_at, last_seen_at, expires_at, revoked_at
+		FROM auth_sessions
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(&session.ID, &session.UserID, &currentHash, &previousHash, &rotatedAt,
+		&session.IPAddress, &session.UserAgent, &session.CreatedAt, &session.LastSeenAt,
+		&session.ExpiresAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrSessionInvalid
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	if revokedAt.Valid || now.After(session.ExpiresAt) {
+		return nil, "", ErrSessionInvalid
+	}
+
+	hash := hashSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(currentHash)) != 1 {
+		if previousHash.Valid && rotatedAt.Valid && now.Sub(rotatedAt.Time) < rotationGracePeriod &&
+			subtle.ConstantTimeCompare([]byte(hash), []byte(previousHash.String)) == 1 {
+			return &session, "", nil
+		}
+
+		// 旧令牌被重复使用，说明令牌可能已泄露，注销整个会话
+		log.Printf("检测到刷新令牌重复使用，注销会话: session=%s, user_id=%d", session.ID, session.UserID)
+		if _, err := tx.Exec("UPDATE auth_sessions SET revoked_at = $1 WHERE id = $2", now, session.ID); err != nil {
+			return nil, "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrSessionInvalid
+	}
+
+	newSecret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(RefreshTokenExpiration)
+	_, err = tx.Exec(`
+		UPDATE auth_sessions
+		SET refresh_hash = $1, previous_refresh_hash = $2, rotated_at = $3, last_seen_at = $3, expires_at = $4
+		WHERE id = $5
+	`, hashSecret(newSecret), currentHash, now, session.ExpiresAt, session.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, "", err
+	}
+
+	return &session, session.ID + "." + newSecret, nil
+}
+
+// IsActive 判断会话是否仍然有效（未注销、未过期且用户未被禁用）
+func (ss *SessionService) IsActive(id string) (bool, error) {
+	var exists bool
+	err := ss.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM auth_sessions s
+			JOIN users u ON u.id = s.user_id
+			WHERE s.id = $1 AND s.revoked_at IS NULL AND s.expires_at > $2 AND NOT u.disabled
+		)
+	`, id, time.Now()).Scan(&exists)
+	return exists, err
+}
+
+// IssueCSRFToken 为会话生成新的 CSRF 令牌，数据库中只保存哈希，旧令牌随即失效
+func (ss *SessionService) IssueCSRFToken(id string) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	result, err := ss.db.Exec("UPDATE auth_sessions SET csrf_hash = $1 WHERE id = $2 AND revoked_at IS NULL",
+		hashSecret(token), id)
+	if err != nil {
+		return "", err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return "", ErrSessionInvalid
+	}
+	return token, nil
+}
+
+// VerifyCSRFToken 校验请求携带的 CSRF 令牌是否属于该会话
+func (ss *SessionService) VerifyCSRFToken(id, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	var stored sql.NullString
+	err := ss.db.QueryRow("SELECT csrf_hash FROM auth_sessions WHERE id = $1", id).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !stored.Valid {
+		return false, nil
+	}
+	return subtle.ConstantTimeCompare([]byte(hashSecret(token)), []byte(stored.String)) == 1, nil
+}
+
+// Revoke 注销单个会话
+func (ss *SessionService) Revoke(id string) error {
+	_, err := ss.db.Exec("UPDATE auth_sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL", time.Now(), id)
+	if err != nil {
+		log.Printf("注销会话失败: %v", err)
+	}
+	return err
+}
+
+// RevokeByRefreshToken 根据刷新令牌注销会话，令牌格式错误时忽略
+func (ss *SessionService) RevokeByRefreshToken(refreshToken string) error {
+	id, _, ok := splitRefreshToken(refreshToken)
+	if !ok {
+		return nil
+	}
+	return ss.Revoke(id)
+}
+
+// RevokeAllForUser 注销用户的全部会话，返回被注销的数量
+func (ss *SessionService) RevokeAllForUser(userID int64) (int64, error) {
+	result, err := ss.db.Exec("UPDATE auth_sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		time.Now(), userID)
+	if err != nil {
+		log.Printf("注销用户会话失败: %v", err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListActiveByUser 获取用户所有有效会话
+func (ss *SessionService) ListActiveByUser(userID int64) ([]Session, error) {
+	rows, err := ss.db.Query(`
+		SELECT id, user_id, ip_address, user_agent, created_at, last_seen_at, expires_at
+		FROM auth_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC
+	`, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]Session, 0)
+	for rows.Next() {
+		var s Session
+		var ip, ua sql.NullString
+		if err := rows.Scan(&s.ID, &s.UserID, &ip, &ua, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			log.Printf("扫描会话数据失败: %v", err)
+			continue
+		}
+		s.IPAddress = ip.String
+		s.UserAgent = ua.String
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// cleanupLoop 定期删除已过期或已注销超过一天的会话
+func (ss *SessionService) cleanupLoop() {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cutoff := time.Now().Add(-24 * time.Hour)
+		result, err := ss.db.Exec("DELETE FROM auth_sessions WHERE expires_at < $1 OR revoked_at < $1", cutoff)
+		if err != nil {
+			log.Printf("清理过期会话失败: %v", err)
+			continue
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			log.Printf("已清理 %d 个过期会话", n)
+		}
+	}
+}
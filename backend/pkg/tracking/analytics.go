@@ -3,42 +3,103 @@ package tracking
 import (
 	"database/sql"
 	"log"
+	"math"
 	"net/url"
 	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 // AnalyticsService 处理统计分析
 type AnalyticsService struct {
 	db *sql.DB
+
+	// 日汇总协程，见 rollup.go
+	rollupInterval time.Duration
+	rollupStop     chan struct{}
+	rollupStopped  chan struct{}
 }
 
-// NewAnalyticsService 创建新的统计服务
-func NewAnalyticsService(db *sql.DB) *AnalyticsService {
-	return &AnalyticsService{db: db}
+// NewAnalyticsService 创建新的统计服务，rollupInterval 大于 0 时启动日汇总任务
+func NewAnalyticsService(db *sql.DB, rollupInterval time.Duration) *AnalyticsService {
+	s := &AnalyticsService{
+		db:             db,
+		rollupInterval: rollupInterval,
+		rollupStop:     make(chan struct{}),
+		rollupStopped:  make(chan struct{}),
+	}
+
+	if rollupInterval > 0 {
+		go s.rollupLoop()
+	} else {
+		close(s.rollupStopped)
+	}
+	return s
 }
 
-// StatsResponse 统计数据响应结构
+// Close 停止日汇总任务，正在汇总的日期会回滚，下次启动时重新汇总
+func (s *AnalyticsService) Close() {
+	select {
+	case <-s.rollupStop:
+	default:
+		close(s.rollupStop)
+	}
+	<-s.rollupStopped
+}
+
+// StatsResponse 统计数据响应结构，除在线人数外都只统计查询窗口内的数据
 type StatsResponse struct {
-	Overview  OverviewStats   `json:"overview"`
-	Trend     []DailyStats    `json:"trend"`
-	TopPages  []PageStats     `json:"top_pages"`
-	Devices   []CategoryStats `json:"devices"`
-	Browsers  []CategoryStats `json:"browsers"`
-	OS        []CategoryStats `json:"os"`
-	Locations []CategoryStats `json:"locations"`
+	Range      StatsRange      `json:"range"`
+	Overview   OverviewStats   `json:"overview"`
+	Bots       BotStats        `json:"bots"`
+	Comparison ComparisonStats `json:"comparison"`
+	Trend      []TrendStats    `json:"trend"`
+	TopPages   []PageStats     `json:"top_pages"`
+	Devices    []CategoryStats `json:"devices"`
+	Browsers   []CategoryStats `json:"browsers"`
+	OS         []CategoryStats `json:"os"`
+	Locations  []CategoryStats `json:"locations"`
+}
+
+// StatsRange 实际使用的查询窗口 [From, To)
+type StatsRange struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Granularity string `json:"granularity"`
+	TZ          string `json:"tz"`
+	IncludeBots bool   `json:"include_bots"`
 }
 
 type OverviewStats struct {
-	TotalPV     int64 `json:"total_pv"`
-	TotalUV     int64 `json:"total_uv"`
-	TodayPV     int64 `json:"today_pv"`
-	TodayUV     int64 `json:"today_uv"`
-	YesterdayPV int64 `json:"yesterday_pv"`
-	YesterdayUV int64 `json:"yesterday_uv"`
-	OnlineUsers int64 `json:"online_users"` // 过去5分钟活跃
+	PV          int64 `json:"pv"`
+	UV          int64 `json:"uv"`
+	Events      int64 `json:"events"`       // 全部事件数
+	OnlineUsers int64 `json:"online_users"` // 过去5分钟活跃，不受查询窗口影响
+}
+
+// BotStats 窗口内的机器人流量，无论统计中是否包含机器人都单独列出
+type BotStats struct {
+	PV     int64           `json:"pv"`
+	Events int64           `json:"events"`
+	Top    []CategoryStats `json:"top"` // 按机器人名称统计的事件数
+}
+
+// ComparisonStats 上一周期（紧邻查询窗口之前、长度相同）的数据，
+// *Change 为本期相对上一周期的变化比例，上一周期为 0 时为 null
+type ComparisonStats struct {
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	PV           int64    `json:"pv"`
+	UV           int64    `json:"uv"`
+	Events       int64    `json:"events"`
+	PVChange     *float64 `json:"pv_change"`
+	UVChange     *float64 `json:"uv_change"`
+	EventsChange *float64 `json:"events_change"`
 }
 
-type DailyStats struct {
+// TrendStats 一个时间桶的 PV 和 UV，Date 为时间桶的标签
+type TrendStats struct {
 	Date string `json:"date"`
 	PV   int64  `json:"pv"`
 	UV   int64  `json:"uv"`
@@ -56,137 +117,332 @@ type CategoryStats struct {
 	Value int64  `json:"value"`
 }
 
-// GetFullStats 获取所有统计数据
-func (s *AnalyticsService) GetFullStats() (*StatsResponse, error) {
-	resp := &StatsResponse{}
+// GetFullStats 获取查询窗口内的所有统计数据
+// 已汇总的日期读取日汇总表，之后的日期（通常只有今天）查询原始事件，两部分合并后返回
+func (s *AnalyticsService) GetFullStats(q StatsQuery) (*StatsResponse, error) {
+	prevFrom, prevTo := q.Previous()
+	resp := &StatsResponse{
+		Range: StatsRange{
+			From:        q.From.In(q.Location).Format(time.RFC3339),
+			To:          q.To.In(q.Location).Format(time.RFC3339),
+			Granularity: q.Granularity,
+			TZ:          q.Location.String(),
+			IncludeBots: q.IncludeBots,
+		},
+		Comparison: ComparisonStats{
+			From: prevFrom.In(q.Location).Format(time.RFC3339),
+			To:   prevTo.In(q.Location).Format(time.RFC3339),
+		},
+	}
 	var err error
 
-	if resp.Overview, err = s.getOverviewStats(); err != nil {
+	boundary, err := s.rollupBoundary()
+	if err != nil {
+		log.Printf("获取汇总进度失败，改为全部查询原始数据: %v", err)
+		boundary = time.Time{}
+	}
+	filter := botFilter(q.IncludeBots)
+	current := splitWindow(q.From, q.To, boundary, true, filter)
+
+	if resp.Overview, err = s.getOverviewStats(current); err != nil {
 		log.Printf("获取概览数据失败: %v", err)
 	}
 
-	if resp.Trend, err = s.getTrendStats(7); err != nil {
+	if previous, err := s.getOverviewStats(splitWindow(prevFrom, prevTo, boundary, true, filter)); err != nil {
+		log.Printf("获取上一周期数据失败: %v", err)
+	} else {
+		c := &resp.Comparison
+		c.PV, c.UV, c.Events = previous.PV, previous.UV, previous.Events
+		c.PVChange = change(resp.Overview.PV, previous.PV)
+		c.UVChange = change(resp.Overview.UV, previous.UV)
+		c.EventsChange = change(resp.Overview.Events, previous.Events)
+	}
+
+	// 在线用户 (过去5分钟)
+	onlineQuery := `
+		SELECT COUNT(DISTINCT session_id) 
+		FROM track_event 
+		WHERE created_at >= NOW() - INTERVAL '5 minutes'` + filter
+	s.db.QueryRow(onlineQuery).Scan(&resp.Overview.OnlineUsers)
+
+	// 机器人流量单独统计
+	bots := splitWindow(q.From, q.To, boundary, true, onlyBots)
+	if botOverview, err := s.getOverviewStats(bots); err != nil {
+		log.Printf("获取机器人流量失败: %v", err)
+	} else {
+		resp.Bots.PV, resp.Bots.Events = botOverview.PV, botOverview.Events
+	}
+	if resp.Bots.Top, err = s.getDimensionStats(bots, "bot"); err != nil {
+		log.Printf("获取机器人统计失败: %v", err)
+	}
+
+	if resp.Trend, err = s.getTrendStats(q, boundary); err != nil {
 		log.Printf("获取趋势数据失败: %v", err)
 	}
 
-	if resp.TopPages, err = s.getTopPages(10); err != nil {
+	if resp.TopPages, err = s.getTopPages(current, q.Limit); err != nil {
 		log.Printf("获取热门页面失败: %v", err)
 	}
 
-	if resp.Devices, err = s.getCategoryStats("device_type"); err != nil {
+	if resp.Devices, err = s.getDimensionStats(current, "device_type"); err != nil {
 		log.Printf("获取设备统计失败: %v", err)
 	}
 
-	// 从 user_agent 或 platform 字段统计
-	if resp.OS, err = s.getCategoryStats("platform"); err != nil {
+	if resp.OS, err = s.getDimensionStats(current, "platform"); err != nil {
 		log.Printf("获取系统统计失败: %v", err)
 	}
 
-	// 浏览器统计需要解析 user_agent，这里简化处理，假设 metadata 中有 browser 字段
-	// 或者我们直接查询 metadata->>'browser'
-	if resp.Browsers, err = s.getMetadataStats("browser"); err != nil {
+	// 浏览器由埋点中间件解析后保存在 metadata.browser 中
+	if resp.Browsers, err = s.getDimensionStats(current, "browser"); err != nil {
 		log.Printf("获取浏览器统计失败: %v", err)
 	}
 
+	// 地区由写入时的 GeoIP 查询得到，未配置 GeoIP 数据库时为空
+	if resp.Locations, err = s.getDimensionStats(current, "location"); err != nil {
+		log.Printf("获取地区统计失败: %v", err)
+	}
+
 	return resp, nil
 }
 
-func (s *AnalyticsService) getOverviewStats() (OverviewStats, error) {
-	var stats OverviewStats
+// change 返回 current 相对 previous 的变化比例，保留四位小数
+func change(current, previous int64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	v := math.Round(float64(current-previous)/float64(previous)*10000) / 10000
+	return &v
+}
 
-	// 总计 - PV 只统计页面访问事件，UV 统计所有唯一会话
-	s.db.QueryRow(`
-		SELECT COUNT(*), COUNT(DISTINCT session_id) 
-		FROM track_event 
-		WHERE event_type = 'PAGEVIEW'
-	`).Scan(&stats.TotalPV, &stats.TotalUV)
+// rollupBoundary 返回原始数据的起始日期：早于该日期的数据读取日汇总表，从该日期起查询原始事件。
+// 尚未汇总过时返回零值，全部查询原始事件
+func (s *AnalyticsService) rollupBoundary() (time.Time, error) {
+	var boundary sql.NullString
+	err := s.db.QueryRow(`
+		SELECT to_char(max(day) + 1, 'YYYY-MM-DD') FROM analytics_daily_site
+	`).Scan(&boundary)
+	if err != nil || !boundary.Valid {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(dateLayout, boundary.String, chinaLocation)
+}
 
-	// 今日 (使用数据库时间，注意时区)
-	todayQuery := `
-		SELECT COUNT(*), COUNT(DISTINCT session_id) 
-		FROM track_event 
-		WHERE created_at >= CURRENT_DATE
-		  AND event_type = 'PAGEVIEW'`
-	s.db.QueryRow(todayQuery).Scan(&stats.TodayPV, &stats.TodayUV)
+// 区分机器人流量的查询条件，track_event 和日汇总表都有 is_bot 列
+const (
+	excludeBots = " AND NOT is_bot"
+	onlyBots    = " AND is_bot"
+)
 
-	// 昨日
-	yesterdayQuery := `
-		SELECT COUNT(*), COUNT(DISTINCT session_id) 
-		FROM track_event 
-		WHERE created_at >= CURRENT_DATE - INTERVAL '1 day' 
-		AND created_at < CURRENT_DATE
-		AND event_type = 'PAGEVIEW'`
-	s.db.QueryRow(yesterdayQuery).Scan(&stats.YesterdayPV, &stats.YesterdayUV)
+func botFilter(includeBots bool) string {
+	if includeBots {
+		return ""
+	}
+	return excludeBots
+}
 
-	// 在线用户 (过去5分钟)
-	onlineQuery := `
-		SELECT COUNT(DISTINCT session_id) 
-		FROM track_event 
-		WHERE created_at >= NOW() - INTERVAL '5 minutes'`
-	s.db.QueryRow(onlineQuery).Scan(&stats.OnlineUsers)
+// window 拆分后的查询窗口：[rollupFrom, rollupTo) 的日期读取日汇总表，[rawFrom, rawTo) 查询原始事件。
+// 日期和时间都是 created_at 所在时区的本地值，filter 是附加到每个查询中的机器人流量条件
+type window struct {
+	rollupFrom, rollupTo string
+	rawFrom, rawTo       string
+	filter               string
+}
+
+// splitWindow 在汇总进度 boundary 处拆开 [from, to)。
+// 日汇总按 created_at 所在时区的自然日划分，窗口的起止不是该时区的零点或 useRollup 为 false 时全部查询原始事件
+func splitWindow(from, to, boundary time.Time, useRollup bool, filter string) window {
+	cut := from
+	if useRollup && isMidnight(from, chinaLocation) && isMidnight(to, chinaLocation) && boundary.After(from) {
+		cut = boundary
+		if cut.After(to) {
+			cut = to
+		}
+	}
+	return window{
+		rollupFrom: from.In(chinaLocation).Format(dateLayout),
+		rollupTo:   cut.In(chinaLocation).Format(dateLayout),
+		rawFrom:    dbTimestamp(cut),
+		rawTo:      dbTimestamp(to),
+		filter:     filter,
+	}
+}
+
+// dbTimestamp 把 t 格式化为 created_at 所在时区的本地时间
+func dbTimestamp(t time.Time) string {
+	return t.In(chinaLocation).Format("2006-01-02 15:04:05.999999")
+}
+
+// getOverviewStats 窗口内的 PV、UV 和事件数，PV 只统计页面访问事件
+func (s *AnalyticsService) getOverviewStats(w window) (OverviewStats, error) {
+	var stats OverviewStats
+
+	if err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(pv), 0), COALESCE(SUM(events), 0) FROM analytics_daily_site
+		WHERE day >= $1::date AND day < $2::date`+w.filter, w.rollupFrom, w.rollupTo).Scan(&stats.PV, &stats.Events); err != nil {
+		return stats, err
+	}
+	var rawPV, rawEvents int64
+	if err := s.db.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE event_type = 'PAGEVIEW'), COUNT(*) FROM track_event
+		WHERE created_at >= $1::timestamp AND created_at < $2::timestamp`+w.filter, w.rawFrom, w.rawTo).Scan(&rawPV, &rawEvents); err != nil {
+		return stats, err
+	}
+	stats.PV += rawPV
+	stats.Events += rawEvents
+
+	uv, err := s.loadSketches(`
+		SELECT uv_sketch FROM analytics_daily_site WHERE day >= $1::date AND day < $2::date`+w.filter, w.rollupFrom, w.rollupTo)
+	if err != nil {
+		return stats, err
+	}
+	if err := s.addSessions(uv, `
+		SELECT DISTINCT session_id FROM track_event
+		WHERE created_at >= $1::timestamp AND created_at < $2::timestamp
+		  AND event_type = 'PAGEVIEW' AND session_id IS NOT NULL`+w.filter, w.rawFrom, w.rawTo); err != nil {
+		return stats, err
+	}
+	stats.UV = uv.Count()
 
 	return stats, nil
 }
 
-func (s *AnalyticsService) getTrendStats(days int) ([]DailyStats, error) {
-	query := `
-		SELECT 
-			TO_CHAR(created_at, 'YYYY-MM-DD') as date,
-			COUNT(*) as pv,
-			COUNT(DISTINCT session_id) as uv
+// getTrendStats 窗口内每个时间桶的 PV 和 UV，没有访问的时间桶为 0，按时间升序。
+// 日汇总只能组成与其自然日边界一致的时间桶，按小时统计或时区与 created_at 不一致时全部查询原始事件
+func (s *AnalyticsService) getTrendStats(q StatsQuery, boundary time.Time) ([]TrendStats, error) {
+	buckets := q.buckets()
+	useRollup := q.Granularity != "hour"
+	for _, b := range buckets[1:] {
+		if !isMidnight(b, chinaLocation) {
+			useRollup = false
+		}
+	}
+	w := splitWindow(q.From, q.To, boundary, useRollup, botFilter(q.IncludeBots))
+
+	counters := make(map[string]*counter, len(buckets))
+	for _, b := range buckets {
+		counters[q.label(b)] = &counter{uv: NewHLL()}
+	}
+
+	rows, err := s.db.Query(`
+		SELECT to_char(day, 'YYYY-MM-DD'), pv, uv_sketch
+		FROM analytics_daily_site
+		WHERE day >= $1::date AND day < $2::date`+w.filter, w.rollupFrom, w.rollupTo)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var date string
+		var pv int64
+		var sketch []byte
+		if err := rows.Scan(&date, &pv, &sketch); err != nil {
+			continue
+		}
+		day, err := time.ParseInLocation(dateLayout, date, chinaLocation)
+		if err != nil {
+			continue
+		}
+		if c, ok := counters[q.label(q.truncate(day))]; ok {
+			c.count += pv
+			c.uv.Merge(mergeSketches([][]byte{sketch}))
+		}
+	}
+	rows.Close()
+
+	// 数据库按 时间桶 × 会话 去重后返回，时间桶按查询时区划分
+	rows, err = s.db.Query(`
+		SELECT
+			to_char(date_trunc($3, (created_at AT TIME ZONE $4) AT TIME ZONE $5), $6),
+			COALESCE(session_id, ''),
+			COUNT(*)
 		FROM track_event
-		WHERE created_at >= CURRENT_DATE - INTERVAL '7 days'
-		  AND event_type = 'PAGEVIEW'
-		GROUP BY date
-		ORDER BY date ASC
-	`
-	rows, err := s.db.Query(query)
+		WHERE created_at >= $1::timestamp AND created_at < $2::timestamp
+		  AND event_type = 'PAGEVIEW'`+w.filter+`
+		GROUP BY 1, 2
+	`, w.rawFrom, w.rawTo, q.Granularity, dbTimeZone, q.Location.String(), granularities[q.Granularity].pgFormat)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-
-	results := make([]DailyStats, 0)
 	for rows.Next() {
-		var d DailyStats
-		if err := rows.Scan(&d.Date, &d.PV, &d.UV); err != nil {
+		var label, session string
+		var n int64
+		if err := rows.Scan(&label, &session, &n); err != nil {
 			continue
 		}
-		results = append(results, d)
+		if c, ok := counters[label]; ok {
+			c.add(session, n)
+		}
 	}
-	return results, nil
+
+	results := make([]TrendStats, 0, len(buckets))
+	for _, b := range buckets {
+		label := q.label(b)
+		c, ok := counters[label]
+		if !ok {
+			continue // 夏令时结束时重复的小时已合并到第一个同名时间桶
+		}
+		delete(counters, label)
+		results = append(results, TrendStats{Date: label, PV: c.count, UV: c.uv.Count()})
+	}
+	return results, rows.Err()
 }
 
-func (s *AnalyticsService) getTopPages(limit int) ([]PageStats, error) {
-	// 排除静态资源、管理页面和损坏的数据（包含?的记录）
+// pageFilter 排除静态资源、管理页面和损坏的数据（包含?的记录）
+const pageFilter = `
+	page_path NOT LIKE '/static/%' 
+	AND page_path NOT LIKE '/admin%'
+	AND page_path NOT LIKE '%?%'
+	AND page_path != ''
+	AND page_path != '/'`
+
+func (s *AnalyticsService) getTopPages(w window, limit int) ([]PageStats, error) {
 	query := `
-		SELECT 
-			page_path, 
-			COUNT(*) as pv,
-			COUNT(DISTINCT session_id) as uv
-		FROM track_event
-		WHERE page_path NOT LIKE '/static/%' 
-		  AND page_path NOT LIKE '/admin%'
-		  AND page_path NOT LIKE '%?%'
-		  AND page_path != ''
-		  AND page_path != '/'
-		  AND event_type = 'PAGEVIEW'
+		SELECT page_path, SUM(pv) AS pv
+		FROM (
+			SELECT page_path, SUM(pv) AS pv
+			FROM analytics_daily_page
+			WHERE day >= $1::date AND day < $2::date AND ` + pageFilter + w.filter + `
+			GROUP BY page_path
+			UNION ALL
+			SELECT page_path, COUNT(*)
+			FROM track_event
+			WHERE created_at >= $3::timestamp AND created_at < $4::timestamp
+			  AND event_type = 'PAGEVIEW' AND ` + pageFilter + w.filter + `
+			GROUP BY page_path
+		) pages
 		GROUP BY page_path
 		ORDER BY pv DESC
-		LIMIT $1
+		LIMIT $5
 	`
-	rows, err := s.db.Query(query, limit)
+	rows, err := s.db.Query(query, w.rollupFrom, w.rollupTo, w.rawFrom, w.rawTo, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	results := make([]PageStats, 0)
+	var paths []string
 	for rows.Next() {
 		var p PageStats
-		if err := rows.Scan(&p.Path, &p.PV, &p.UV); err != nil {
+		if err := rows.Scan(&p.Path, &p.PV); err != nil {
 			continue
 		}
+		paths = append(paths, p.Path)
+		results = append(results, p)
+	}
+	rows.Close()
+
+	// 只为排名靠前的页面合并访客估算器
+	uv, err := s.pageVisitors(w, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		p := &results[i]
+		if h, ok := uv[p.Path]; ok {
+			p.UV = h.Count()
+		}
 		// URL解码页面路径，将 %E6%95%B0 这样的编码转为中文
 		if decoded, err := url.QueryUnescape(p.Path); err == nil {
 			p.Path = decoded
@@ -194,47 +450,84 @@ func (s *AnalyticsService) getTopPages(limit int) ([]PageStats, error) {
 		// 去掉 .html 后缀
 		p.Path = strings.TrimSuffix(p.Path, ".html")
 		p.Title = p.Path // 暂时用路径作为标题
-		results = append(results, p)
 	}
 	return results, nil
 }
 
-func (s *AnalyticsService) getCategoryStats(column string) ([]CategoryStats, error) {
-	// 动态列名查询需要小心 SQL 注入，但这里是内部调用，column 是受控的
-	query := "SELECT " + column + ", COUNT(*) as count FROM track_event WHERE " + column + " IS NOT NULL AND " + column + " != '' GROUP BY " + column + " ORDER BY count DESC LIMIT 10"
+// pageVisitors 返回每个页面合并了汇总数据和原始数据的访客估算器
+func (s *AnalyticsService) pageVisitors(w window, paths []string) (map[string]*HLL, error) {
+	result := make(map[string]*HLL, len(paths))
+	for _, path := range paths {
+		result[path] = NewHLL()
+	}
+	if len(paths) == 0 {
+		return result, nil
+	}
 
-	rows, err := s.db.Query(query)
+	rows, err := s.db.Query(`
+		SELECT page_path, uv_sketch FROM analytics_daily_page
+		WHERE day >= $1::date AND day < $2::date AND page_path = ANY($3)`+w.filter, w.rollupFrom, w.rollupTo, pq.Array(paths))
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	for rows.Next() {
+		var path string
+		var sketch []byte
+		if err := rows.Scan(&path, &sketch); err != nil {
+			continue
+		}
+		result[path].Merge(mergeSketches([][]byte{sketch}))
+	}
+	rows.Close()
 
-	results := make([]CategoryStats, 0)
+	rows, err = s.db.Query(`
+		SELECT DISTINCT page_path, session_id FROM track_event
+		WHERE created_at >= $1::timestamp AND created_at < $2::timestamp AND event_type = 'PAGEVIEW'
+		  AND page_path = ANY($3) AND session_id IS NOT NULL`+w.filter, w.rawFrom, w.rawTo, pq.Array(paths))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 	for rows.Next() {
-		var c CategoryStats
-		if err := rows.Scan(&c.Name, &c.Value); err != nil {
+		var path, session string
+		if err := rows.Scan(&path, &session); err != nil {
 			continue
 		}
-		results = append(results, c)
+		result[path].Add(session)
 	}
-	return results, nil
+	return result, rows.Err()
 }
 
-func (s *AnalyticsService) getMetadataStats(key string) ([]CategoryStats, error) {
-	// 查询 JSONB 字段
+// getDimensionStats 按维度（device_type、platform、browser、location、bot）统计窗口内的事件数，取前 10 项
+func (s *AnalyticsService) getDimensionStats(w window, dimension string) ([]CategoryStats, error) {
+	var expr string
+	for _, d := range rollupDimensions {
+		if d.name == dimension {
+			expr = d.expr
+		}
+	}
+
+	// expr 来自固定的维度列表，不是用户输入
 	query := `
-		SELECT 
-			metadata->>$1 as name, 
-			COUNT(*) as count 
-		FROM track_event 
-		WHERE metadata->>$1 IS NOT NULL 
-		  AND metadata->>$1 != '' 
-		GROUP BY name 
-		ORDER BY count DESC 
+		SELECT value, SUM(events) AS count
+		FROM (
+			SELECT value, SUM(events) AS events
+			FROM analytics_daily_dimension
+			WHERE day >= $1::date AND day < $2::date AND dimension = $5` + w.filter + `
+			GROUP BY value
+			UNION ALL
+			SELECT ` + expr + `, COUNT(*)
+			FROM track_event
+			WHERE created_at >= $3::timestamp AND created_at < $4::timestamp
+			  AND ` + expr + ` IS NOT NULL AND ` + expr + ` != ''` + w.filter + `
+			GROUP BY 1
+		) dims
+		GROUP BY value
+		ORDER BY count DESC
 		LIMIT 10
 	`
 
-	rows, err := s.db.Query(query, key)
+	rows, err := s.db.Query(query, w.rollupFrom, w.rollupTo, w.rawFrom, w.rawTo, dimension)
 	if err != nil {
 		return nil, err
 	}
@@ -250,3 +543,40 @@ func (s *AnalyticsService) getMetadataStats(key string) ([]CategoryStats, error)
 	}
 	return results, nil
 }
+
+// loadSketches 合并查询返回的全部访客估算器
+func (s *AnalyticsService) loadSketches(query string, args ...any) (*HLL, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sketches [][]byte
+	for rows.Next() {
+		var sketch []byte
+		if err := rows.Scan(&sketch); err != nil {
+			return nil, err
+		}
+		sketches = append(sketches, sketch)
+	}
+	return mergeSketches(sketches), rows.Err()
+}
+
+// addSessions 把查询返回的会话ID加入估算器
+func (s *AnalyticsService) addSessions(h *HLL, query string, args ...any) error {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session string
+		if err := rows.Scan(&session); err != nil {
+			return err
+		}
+		h.Add(session)
+	}
+	return rows.Err()
+}
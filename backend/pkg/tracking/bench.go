@@ -0,0 +1,145 @@
+package tracking
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 写入性能基准：在单独的基准测试表中对比逐条预编译插入（旧实现）与 COPY（当前实现）的吞吐量。
+// 通过 ./blog bench-tracking 运行，不会读写 track_event 中的数据。
+
+// benchTable 基准测试使用的表，结构和索引与 track_event 相同，测试结束后删除
+const benchTable = "track_event_bench"
+
+// BenchmarkResult 一种写入方式在一个批大小下的结果
+type BenchmarkResult struct {
+	Method    string
+	BatchSize int
+	Events    int
+	Elapsed   time.Duration
+}
+
+// EventsPerSecond 每秒写入的事件数
+func (r BenchmarkResult) EventsPerSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Events) / r.Elapsed.Seconds()
+}
+
+// benchMethod 一种批量写入方式
+type benchMethod struct {
+	name   string
+	insert func(db *sql.DB, table string, events []*UnpartitionedTrackEvent) error
+}
+
+var benchMethods = []benchMethod{
+	{"prepared", insertPrepared},
+	{"copy", copyEvents},
+}
+
+// Benchmark 对每个批大小分别用各写入方式写入 totalEvents 条事件，每轮开始前清空基准测试表
+func Benchmark(ctx context.Context, db *sql.DB, totalEvents int, batchSizes []int) ([]BenchmarkResult, error) {
+	if err := createBenchTable(ctx, db); err != nil {
+		return nil, err
+	}
+	defer db.ExecContext(context.Background(), "DROP TABLE IF EXISTS "+benchTable)
+
+	events := benchEvents(totalEvents)
+	var results []BenchmarkResult
+	for _, batchSize := range batchSizes {
+		for _, method := range benchMethods {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			if _, err := db.ExecContext(ctx, "TRUNCATE "+benchTable); err != nil {
+				return results, fmt.Errorf("清空基准测试表失败: %w", err)
+			}
+
+			start := time.Now()
+			for i := 0; i < len(events); i += batchSize {
+				end := min(i+batchSize, len(events))
+				if err := method.insert(db, benchTable, events[i:end]); err != nil {
+					return results, fmt.Errorf("%s 写入失败 (batch=%d): %w", method.name, batchSize, err)
+				}
+			}
+			results = append(results, BenchmarkResult{
+				Method:    method.name,
+				BatchSize: batchSize,
+				Events:    len(events),
+				Elapsed:   time.Since(start),
+			})
+		}
+	}
+	return results, nil
+}
+
+// createBenchTable 按 track_event 的结构和索引创建基准测试表，id 改用独立的自增序列
+func createBenchTable(ctx context.Context, db *sql.DB) error {
+	stmts := []string{
+		"DROP TABLE IF EXISTS " + benchTable,
+		"CREATE TABLE " + benchTable + " (LIKE track_event INCLUDING ALL EXCLUDING DEFAULTS)",
+		"ALTER TABLE " + benchTable + " ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY",
+	}
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("创建基准测试表失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// benchEvents 生成接近真实数据的事件，包含中文内容和 JSON 属性
+func benchEvents(n int) []*UnpartitionedTrackEvent {
+	events := make([]*UnpartitionedTrackEvent, n)
+	now := time.Now()
+	for i := range events {
+		session := "bench-session-" + strconv.Itoa(i/20)
+		events[i] = &UnpartitionedTrackEvent{
+			SessionID:        session,
+			UserID:           "bench-user-" + strconv.Itoa(i/100),
+			EventType:        []string{"PAGEVIEW", "CLICK", "SCROLL"}[i%3],
+			ElementPath:      "body > main > article > a:nth-child(" + strconv.Itoa(i%10) + ")",
+			PagePath:         "/article/" + strconv.Itoa(i%500),
+			Referrer:         "https://www.google.com/search?q=博客",
+			Metadata:         `{"title":"基准测试文章标题","scroll_depth":` + strconv.Itoa(i%100) + `}`,
+			UserAgent:        "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36",
+			IPAddress:        "203.0.113." + strconv.Itoa(i%256),
+			CreatedAt:        now.Add(time.Duration(i) * time.Millisecond),
+			CustomProperties: `{"auto_tracked":true}`,
+			Platform:         "WEB",
+			DeviceInfo:       `{"screen":"1920x1080","language":"zh-CN"}`,
+			EventDuration:    i % 5000,
+			DeviceID:         "bench-device-" + strconv.Itoa(i/100),
+			Version:          "1.0.0",
+			DeviceType:       "Desktop",
+		}
+	}
+	return events
+}
+
+// insertPrepared 旧的写入方式：事务内预编译 INSERT，每条事件执行一次，仅用于基准对比
+func insertPrepared(db *sql.DB, table string, events []*UnpartitionedTrackEvent) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(insertEventSQL(table))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, event := range events {
+		fillEmptyJSON(event)
+		if _, err := stmt.Exec(eventValues(event)...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,203 @@
+package tracking
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 访客对埋点的同意状态，按前端的设备指纹记录在 tracking_consent 中，修改时需提交设备密钥。
+// 拒绝（denied）的设备上报的事件直接丢弃；同意（granted）或没有记录时按隐私信号策略处理。
+// 写入时的查询结果在进程内缓存一分钟，多个实例之间最多延迟一分钟生效。
+
+// 同意状态
+const (
+	ConsentGranted = "granted"
+	ConsentDenied  = "denied"
+	ConsentUnknown = "unknown" // 没有记录
+)
+
+const (
+	consentCacheTTL   = time.Minute
+	consentCacheLimit = 10000
+	maxDeviceIDLength = 100 // 与 track_event.user_id、tracking_consent.device_id 的长度一致
+
+	minDeviceSecretLength = 32 // 设备密钥至少 128 位，前端以十六进制保存
+	deviceIDHexLength     = 32
+)
+
+// Consent 设备的同意状态
+type Consent struct {
+	DeviceID  string     `json:"device_id"`
+	Status    string     `json:"status"`
+	UpdatedAt *time.Time `json:"updated_at"` // 没有记录时为 null
+}
+
+// ConsentRequest 记录同意状态的请求
+type ConsentRequest struct {
+	DeviceID     string `json:"device_id"`
+	DeviceSecret string `json:"device_secret"` // 设备密钥，见 deviceOwned
+	Status       string `json:"status"`        // granted 或 denied
+}
+
+type consentEntry struct {
+	denied  bool
+	expires time.Time
+}
+
+// consentStore 读写同意状态，并缓存写入时的查询结果
+type consentStore struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	cache map[string]consentEntry
+}
+
+func newConsentStore(db *sql.DB) *consentStore {
+	return &consentStore{db: db, cache: make(map[string]consentEntry)}
+}
+
+// denied 判断设备是否拒绝了埋点，查询失败时按未拒绝处理
+func (s *consentStore) denied(deviceID string) bool {
+	if deviceID == "" || len(deviceID) > maxDeviceIDLength {
+		return false
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[deviceID]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.denied
+	}
+
+	var status string
+	err := s.db.QueryRow(`SELECT status FROM tracking_consent WHERE device_id = $1`, deviceID).Scan(&status)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("查询埋点同意状态失败: %v", err)
+		return false
+	}
+	s.remember(deviceID, status == ConsentDenied, now)
+	return status == ConsentDenied
+}
+
+// remember 缓存设备的同意状态，缓存过大时先清除过期的条目
+func (s *consentStore) remember(deviceID string, denied bool, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.cache) >= consentCacheLimit {
+		for id, entry := range s.cache {
+			if !now.Before(entry.expires) {
+				delete(s.cache, id)
+			}
+		}
+		if len(s.cache) >= consentCacheLimit {
+			clear(s.cache)
+		}
+	}
+	s.cache[deviceID] = consentEntry{denied: denied, expires: now.Add(consentCacheTTL)}
+}
+
+// get 返回设备的同意状态
+func (s *consentStore) get(deviceID string) (Consent, error) {
+	consent := Consent{DeviceID: deviceID, Status: ConsentUnknown}
+	var updatedAt time.Time
+	err := s.db.QueryRow(`SELECT status, updated_at FROM tracking_consent WHERE device_id = $1`, deviceID).
+		Scan(&consent.Status, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return consent, nil
+	}
+	if err != nil {
+		return consent, err
+	}
+	consent.UpdatedAt = &updatedAt
+	return consent, nil
+}
+
+// set 记录设备的同意状态，本实例立即生效
+func (s *consentStore) set(deviceID, status string) (Consent, error) {
+	var updatedAt time.Time
+	err := s.db.QueryRow(`
+		INSERT INTO tracking_consent (device_id, status, updated_at) VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (device_id) DO UPDATE SET status = EXCLUDED.status, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at`, deviceID, status).Scan(&updatedAt)
+	if err != nil {
+		return Consent{}, err
+	}
+	s.remember(deviceID, status == ConsentDenied, time.Now())
+	return Consent{DeviceID: deviceID, Status: status, UpdatedAt: &updatedAt}, nil
+}
+
+// deviceOwned 判断请求者是否持有设备指纹对应的设备密钥。
+// 前端首次访问时生成 128 位随机密钥保存在本地，设备指纹取密钥 SHA-256 的前 32 个十六进制字符，
+// 密钥不随埋点上报，只在修改同意状态和删除个人数据时提交。旧版前端的设备指纹只有 32 位且可以遍历，没有对应的密钥
+func deviceOwned(deviceID, secret string) bool {
+	if len(secret) < minDeviceSecretLength || len(secret) > maxDeviceIDLength {
+		return false
+	}
+	sum := sha256.Sum256([]byte(secret))
+	want := hex.EncodeToString(sum[:])[:deviceIDHexLength]
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(deviceID)), []byte(want)) == 1
+}
+
+// validDeviceID 校验前端上报的设备指纹。auto_ 开头的是服务端按 IP 生成的临时标识，不能用于查询和删除
+func validDeviceID(deviceID string) bool {
+	return deviceID != "" && len(deviceID) <= maxDeviceIDLength && !strings.HasPrefix(deviceID, "auto_")
+}
+
+// handleGetConsent 查询设备的同意状态
+func (ts *TrackingService) handleGetConsent(c *gin.Context) {
+	deviceID := strings.TrimSpace(c.Param("deviceId"))
+	if !validDeviceID(deviceID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的设备标识"})
+		return
+	}
+
+	consent, err := ts.consent.get(deviceID)
+	if err != nil {
+		log.Printf("查询埋点同意状态失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询同意状态失败"})
+		return
+	}
+	c.JSON(http.StatusOK, consent)
+}
+
+// handleSetConsent 记录设备的同意状态
+func (ts *TrackingService) handleSetConsent(c *gin.Context) {
+	var req ConsentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+	req.DeviceID = strings.TrimSpace(req.DeviceID)
+	if !validDeviceID(req.DeviceID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的设备标识"})
+		return
+	}
+	if req.Status != ConsentGranted && req.Status != ConsentDenied {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status 必须是 granted 或 denied"})
+		return
+	}
+	if !deviceOwned(req.DeviceID, req.DeviceSecret) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "设备密钥与设备标识不匹配"})
+		return
+	}
+
+	consent, err := ts.consent.set(req.DeviceID, req.Status)
+	if err != nil {
+		log.Printf("记录埋点同意状态失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "记录同意状态失败"})
+		return
+	}
+	c.JSON(http.StatusOK, consent)
+}
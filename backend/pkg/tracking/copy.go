@@ -0,0 +1,113 @@
+package tracking
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// trackEventColumns 批量写入的列，顺序与 eventValues 返回的值一致
+var trackEventColumns = []string{
+	"session_id", "user_id", "event_type", "element_path", "page_path", "referrer",
+	"metadata", "user_agent", "ip_address", "created_at", "custom_properties",
+	"platform", "device_info", "event_duration", "device_id", "version", "device_type",
+	"country", "region", "city", "is_bot", "bot_name",
+}
+
+// fillEmptyJSON 确保JSON字段不为空
+func fillEmptyJSON(event *UnpartitionedTrackEvent) {
+	if event.Metadata == "" {
+		event.Metadata = "{}"
+	}
+	if event.CustomProperties == "" {
+		event.CustomProperties = "{}"
+	}
+	if event.DeviceInfo == "" {
+		event.DeviceInfo = "{}"
+	}
+}
+
+// eventValues 返回事件各列的值，JSON 字段以文本形式传给 jsonb 列
+func eventValues(event *UnpartitionedTrackEvent) []any {
+	return []any{
+		nullIfEmpty(event.SessionID),
+		nullIfEmpty(event.UserID),
+		event.EventType,
+		event.ElementPath,
+		event.PagePath,
+		event.Referrer,
+		event.Metadata,
+		event.UserAgent,
+		nullIfEmpty(event.IPAddress),
+		event.CreatedAt,
+		event.CustomProperties,
+		event.Platform,
+		event.DeviceInfo,
+		event.EventDuration,
+		event.DeviceID,
+		event.Version,
+		event.DeviceType,
+		nullIfEmpty(event.Country),
+		nullIfEmpty(event.Region),
+		nullIfEmpty(event.City),
+		event.IsBot,
+		nullIfEmpty(event.BotName),
+	}
+}
+
+// nullIfEmpty 未知的值写入 NULL
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// insertEventSQL 返回向 table 插入一条事件的 INSERT 语句，参数为 eventValues 返回的值
+func insertEventSQL(table string) string {
+	placeholders := make([]string, len(trackEventColumns))
+	for i := range placeholders {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		pq.QuoteIdentifier(table), strings.Join(trackEventColumns, ", "), strings.Join(placeholders, ", "))
+}
+
+// copyEvents 使用 COPY 协议在一个事务中把事件写入 table。
+// 整批数据以流的形式发送，服务端在 COPY 结束时统一校验，任意一条无效时整批回滚
+func copyEvents(db *sql.DB, table string, events []*UnpartitionedTrackEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(pq.CopyIn(table, trackEventColumns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, event := range events {
+		fillEmptyJSON(event)
+		if _, err := stmt.Exec(eventValues(event)...); err != nil {
+			return fmt.Errorf("COPY 写入事件失败: %w", err)
+		}
+	}
+	// 不带参数的 Exec 结束 COPY，数据错误在这里返回
+	if _, err := stmt.Exec(); err != nil {
+		return fmt.Errorf("COPY 写入 %d 条事件失败: %w", len(events), err)
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,180 @@
+package tracking
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 访客请求删除个人数据（“忘记我”）：删除设备指纹关联的全部埋点事件和评论，并生成删除回执。
+// 请求必须带上设备密钥证明设备属于请求者（见 deviceOwned），旧版前端生成的设备指纹没有密钥，需由管理员用 forget 命令删除。
+// 日汇总中的 PV、UV 等聚合数据不含个人信息，不会删除。回执只保存设备指纹的哈希，凭原始指纹可以核对回执；
+// 没有删除任何数据时不生成回执，避免公开接口被用来无限写入回执。
+
+// ErrReceiptNotFound 删除回执不存在
+var ErrReceiptNotFound = errors.New("删除回执不存在")
+
+// ForgetRequest 删除个人数据的请求
+type ForgetRequest struct {
+	DeviceID     string `json:"device_id"`     // 前端的设备指纹，对应 track_event.user_id 和 comments.device_id
+	DeviceSecret string `json:"device_secret"` // 前端保存的设备密钥，证明设备指纹属于请求者
+}
+
+// DeletionReceipt 删除回执，没有删除任何数据时 ID 为空且不保存
+type DeletionReceipt struct {
+	ID                 string    `json:"receipt_id,omitempty"`
+	SubjectHash        string    `json:"subject_hash,omitempty"` // 设备指纹的 SHA-256，见 subjectHash
+	TrackEventsDeleted int64     `json:"track_events_deleted"`
+	CommentsDeleted    int64     `json:"comments_deleted"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// subjectHash 返回设备指纹的 SHA-256 十六进制，没有设备指纹时为空
+func subjectHash(deviceID string) string {
+	if deviceID == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(deviceID))
+	return hex.EncodeToString(sum[:])
+}
+
+// Forget 在一个事务中删除设备指纹关联的埋点事件和评论，删除了数据时保存删除回执。
+// visitorID 为无 Cookie 模式下请求者当天的访客标识，为空时忽略。调用方负责确认设备属于请求者。
+// 已拒绝埋点的同意记录会保留，否则删除后该设备的事件又会被记录
+func Forget(db *sql.DB, deviceID, visitorID string) (*DeletionReceipt, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	receipt := &DeletionReceipt{}
+	res, err := tx.Exec(`
+		DELETE FROM track_event WHERE user_id = NULLIF($1, '') OR session_id = NULLIF($2, '')`,
+		deviceID, visitorID)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.TrackEventsDeleted, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	res, err = tx.Exec(`DELETE FROM comments WHERE device_id = NULLIF($1, '')`, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.CommentsDeleted, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM tracking_consent WHERE device_id = $1 AND status = $2`,
+		deviceID, ConsentGranted); err != nil {
+		return nil, err
+	}
+
+	if receipt.TrackEventsDeleted == 0 && receipt.CommentsDeleted == 0 {
+		receipt.CreatedAt = time.Now()
+		return receipt, tx.Commit()
+	}
+
+	receipt.ID, receipt.SubjectHash = newReceiptID(), subjectHash(deviceID)
+	if err := tx.QueryRow(`
+		INSERT INTO privacy_deletion_receipts (id, subject_hash, track_events_deleted, comments_deleted)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`,
+		receipt.ID, receipt.SubjectHash, receipt.TrackEventsDeleted, receipt.CommentsDeleted).
+		Scan(&receipt.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("已删除访客个人数据: 回执 %s，埋点事件 %d 条，评论 %d 条",
+		receipt.ID, receipt.TrackEventsDeleted, receipt.CommentsDeleted)
+	return receipt, nil
+}
+
+// GetDeletionReceipt 查询删除回执
+func (ts *TrackingService) GetDeletionReceipt(id string) (*DeletionReceipt, error) {
+	r := &DeletionReceipt{ID: id}
+	err := ts.db.QueryRow(`
+		SELECT subject_hash, track_events_deleted, comments_deleted, created_at
+		FROM privacy_deletion_receipts WHERE id = $1`, id).
+		Scan(&r.SubjectHash, &r.TrackEventsDeleted, &r.CommentsDeleted, &r.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func newReceiptID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// handleForget 删除请求者的个人数据并返回删除回执
+func (ts *TrackingService) handleForget(c *gin.Context) {
+	var req ForgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	req.DeviceID = strings.TrimSpace(req.DeviceID)
+	if req.DeviceID != "" && !validDeviceID(req.DeviceID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的设备标识"})
+		return
+	}
+	if req.DeviceID == "" && ts.salts == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 device_id"})
+		return
+	}
+	if req.DeviceID != "" && !deviceOwned(req.DeviceID, req.DeviceSecret) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "设备密钥与设备标识不匹配"})
+		return
+	}
+
+	// 无 Cookie 模式下事件只关联访客标识，按请求者当前的 IP 和 User-Agent 算出当天的标识一并删除
+	var currentVisitor string
+	if ts.salts != nil {
+		currentVisitor = visitorID(ts.salts.current(time.Now()), visitorSite(c.Request.Host), c.ClientIP(), c.Request.UserAgent())
+	}
+
+	receipt, err := Forget(ts.db, req.DeviceID, currentVisitor)
+	if err != nil {
+		log.Printf("删除访客个人数据失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+	c.JSON(http.StatusOK, receipt)
+}
+
+// handleGetReceipt 查询删除回执
+func (ts *TrackingService) handleGetReceipt(c *gin.Context) {
+	receipt, err := ts.GetDeletionReceipt(c.Param("id"))
+	if errors.Is(err, ErrReceiptNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("查询删除回执失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询删除回执失败"})
+		return
+	}
+	c.JSON(http.StatusOK, receipt)
+}
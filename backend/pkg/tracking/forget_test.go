@@ -0,0 +1,248 @@
+package tracking
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// forgetDriver 记录执行的语句，DELETE 影响的行数固定为 deleted
+type forgetDriver struct {
+	deleted int64
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (d *forgetDriver) Open(string) (driver.Conn, error) { return forgetConn{d}, nil }
+
+func (d *forgetDriver) executed(substr string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, q := range d.queries {
+		if strings.Contains(q, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+type forgetConn struct{ d *forgetDriver }
+
+func (c forgetConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	c.d.queries = append(c.d.queries, query)
+	c.d.mu.Unlock()
+	return forgetStmt{c.d, query}, nil
+}
+func (c forgetConn) Close() error              { return nil }
+func (c forgetConn) Begin() (driver.Tx, error) { return saltTx{}, nil }
+
+type forgetStmt struct {
+	d     *forgetDriver
+	query string
+}
+
+func (s forgetStmt) Close() error  { return nil }
+func (s forgetStmt) NumInput() int { return -1 }
+
+func (s forgetStmt) Exec([]driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "DELETE FROM tracking_consent") {
+		return driver.RowsAffected(0), nil
+	}
+	return driver.RowsAffected(s.d.deleted), nil
+}
+
+func (s forgetStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &timeRows{t: time.Now()}, nil
+}
+
+type timeRows struct{ t time.Time }
+
+func (r *timeRows) Columns() []string { return []string{"created_at"} }
+func (r *timeRows) Close() error      { return nil }
+
+func (r *timeRows) Next(dest []driver.Value) error {
+	if r.t.IsZero() {
+		return io.EOF
+	}
+	dest[0], r.t = r.t, time.Time{}
+	return nil
+}
+
+var forgetDrivers atomic.Int64
+
+func newForgetRouter(t *testing.T, deleted int64) (*gin.Engine, *forgetDriver) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	d := &forgetDriver{deleted: deleted}
+	name := fmt.Sprintf("forgetdriver%d", forgetDrivers.Add(1))
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ts := &TrackingService{db: db, consent: newConsentStore(db)}
+	router := gin.New()
+	ts.RegisterHandlers(router)
+	return router, d
+}
+
+// testDevice 按前端的方式由设备密钥生成设备指纹
+func testDevice(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])[:deviceIDHexLength]
+}
+
+const testDeviceSecret = "0123456789abcdef0123456789abcdef"
+
+func post(router *gin.Engine, path string, body any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(data)))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "203.0.113.7:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDeviceOwned(t *testing.T) {
+	device := testDevice(testDeviceSecret)
+	tests := []struct {
+		name     string
+		deviceID string
+		secret   string
+		want     bool
+	}{
+		{"密钥匹配", device, testDeviceSecret, true},
+		{"设备指纹大写", strings.ToUpper(device), testDeviceSecret, true},
+		{"密钥错误", device, "fedcba9876543210fedcba9876543210", false},
+		{"没有密钥", device, "", false},
+		{"密钥过短", testDevice("short"), "short", false},
+		{"旧版设备指纹", "3f2a9c1b", testDeviceSecret, false},
+		{"设备指纹为完整哈希", device + device, testDeviceSecret, false},
+	}
+	for _, tt := range tests {
+		if got := deviceOwned(tt.deviceID, tt.secret); got != tt.want {
+			t.Errorf("%s: deviceOwned = %v，期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestForgetRequiresDeviceSecret(t *testing.T) {
+	router, d := newForgetRouter(t, 3)
+	device := testDevice(testDeviceSecret)
+
+	tests := []struct {
+		name string
+		body map[string]string
+		want int
+	}{
+		{"没有设备标识", map[string]string{}, http.StatusBadRequest},
+		{"没有密钥", map[string]string{"device_id": device}, http.StatusForbidden},
+		{"密钥错误", map[string]string{"device_id": device, "device_secret": strings.Repeat("0", 32)}, http.StatusForbidden},
+		{"旧版设备指纹", map[string]string{"device_id": "3f2a9c1b", "device_secret": testDeviceSecret}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if w := post(router, "/api/tracking/forget", tt.body); w.Code != tt.want {
+			t.Errorf("%s: 状态码 = %d，期望 %d", tt.name, w.Code, tt.want)
+		}
+	}
+	if d.executed("DELETE") {
+		t.Fatal("未证明设备归属时不应删除任何数据")
+	}
+}
+
+func TestForgetWritesReceiptOnlyWhenDeleted(t *testing.T) {
+	device := testDevice(testDeviceSecret)
+	body := map[string]string{"device_id": device, "device_secret": testDeviceSecret}
+
+	router, d := newForgetRouter(t, 0)
+	w := post(router, "/api/tracking/forget", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d: %s", w.Code, w.Body)
+	}
+	var receipt DeletionReceipt
+	json.Unmarshal(w.Body.Bytes(), &receipt)
+	if receipt.ID != "" || d.executed("INSERT INTO privacy_deletion_receipts") {
+		t.Errorf("没有删除任何数据时不应生成回执: %s", w.Body)
+	}
+
+	router, d = newForgetRouter(t, 3)
+	w = post(router, "/api/tracking/forget", body)
+	json.Unmarshal(w.Body.Bytes(), &receipt)
+	if w.Code != http.StatusOK || receipt.ID == "" || !d.executed("INSERT INTO privacy_deletion_receipts") {
+		t.Errorf("删除数据后应生成回执: %d %s", w.Code, w.Body)
+	}
+	sum := sha256.Sum256([]byte(device))
+	if receipt.SubjectHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("subject_hash = %s", receipt.SubjectHash)
+	}
+}
+
+func TestSetConsentRequiresDeviceSecret(t *testing.T) {
+	router, d := newForgetRouter(t, 0)
+	device := testDevice(testDeviceSecret)
+
+	w := post(router, "/api/tracking/consent", map[string]string{"device_id": device, "status": ConsentDenied})
+	if w.Code != http.StatusForbidden || d.executed("tracking_consent") {
+		t.Errorf("没有密钥时状态码 = %d，期望 403 且不写入", w.Code)
+	}
+
+	w = post(router, "/api/tracking/consent",
+		map[string]string{"device_id": device, "device_secret": testDeviceSecret, "status": ConsentDenied})
+	if w.Code != http.StatusOK || !d.executed("INSERT INTO tracking_consent") {
+		t.Errorf("密钥匹配时状态码 = %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestForgetRateLimit(t *testing.T) {
+	router, _ := newForgetRouter(t, 0)
+	body := map[string]string{"device_id": testDevice(testDeviceSecret), "device_secret": testDeviceSecret}
+
+	for i := 0; i < forgetRateLimit; i++ {
+		if w := post(router, "/api/tracking/forget", body); w.Code != http.StatusOK {
+			t.Fatalf("第 %d 次请求状态码 = %d", i+1, w.Code)
+		}
+	}
+	w := post(router, "/api/tracking/forget", body)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("超过限制后状态码 = %d，期望 429 并带有 Retry-After", w.Code)
+	}
+}
+
+func TestIPRateLimiterWindow(t *testing.T) {
+	l := newIPRateLimiter(2, time.Minute)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("1.2.3.4", now); !ok {
+			t.Fatalf("第 %d 次请求被拒绝", i+1)
+		}
+	}
+	if ok, wait := l.allow("1.2.3.4", now.Add(10*time.Second)); ok || wait != 50*time.Second {
+		t.Errorf("超过限制: ok=%v wait=%v", ok, wait)
+	}
+	if ok, _ := l.allow("5.6.7.8", now); !ok {
+		t.Error("不同 IP 分别计数")
+	}
+	if ok, _ := l.allow("1.2.3.4", now.Add(time.Minute)); !ok {
+		t.Error("窗口结束后应重新计数")
+	}
+}
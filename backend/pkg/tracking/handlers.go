@@ -43,6 +43,14 @@ func (ts *TrackingService) RegisterHandlers(router *gin.Engine) {
 
 		// 检查跟踪服务状态
 		trackGroup.GET("/status", ts.handleTrackingStatus)
+
+		// 访客的埋点同意状态
+		trackGroup.GET("/consent/:deviceId", ts.handleGetConsent)
+		trackGroup.POST("/consent", newIPRateLimiter(consentRateLimit, privacyRateLimit).middleware(), ts.handleSetConsent)
+
+		// 删除访客的个人数据并查询删除回执
+		trackGroup.POST("/forget", newIPRateLimiter(forgetRateLimit, privacyRateLimit).middleware(), ts.handleForget)
+		trackGroup.GET("/forget/:id", ts.handleGetReceipt)
 	}
 }
 
@@ -77,6 +85,10 @@ func (ts *TrackingService) handleUnpartitionedTrackEvent(c *gin.Context) {
 
 	// 创建跟踪事件
 	event := convertToUnpartitionedTrackEvent(req, c)
+	if !ts.prepareEvent(c, event, req.UserID, req.DeviceInfo) {
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
 
 	// 发送到跟踪服务
 	ts.TrackUnpartitionedEvent(event)
@@ -114,6 +126,7 @@ func (ts *TrackingService) handleUnpartitionedBatchEvents(c *gin.Context) {
 	log.Printf("收到批量请求，事件数量: %d", len(events))
 	validEvents := 0
 	invalidEvents := 0
+	ignoredEvents := 0 // 访客拒绝埋点或隐私信号策略为 drop 而未记录的事件
 
 	// 处理每个事件
 	for i, eventMap := range events {
@@ -155,6 +168,10 @@ func (ts *TrackingService) handleUnpartitionedBatchEvents(c *gin.Context) {
 
 		// 转换为事件对象并发送
 		event := convertToUnpartitionedTrackEvent(req, c)
+		if !ts.prepareEvent(c, event, req.UserID, req.DeviceInfo) {
+			ignoredEvents++
+			continue
+		}
 		log.Printf("转换后的事件对象: platform=%s, event_duration=%d",
 			event.Platform, event.EventDuration)
 		ts.TrackUnpartitionedEvent(event)
@@ -165,6 +182,7 @@ func (ts *TrackingService) handleUnpartitionedBatchEvents(c *gin.Context) {
 		"status":    "success",
 		"processed": validEvents,
 		"invalid":   invalidEvents,
+		"ignored":   ignoredEvents,
 	})
 }
 
@@ -251,6 +269,11 @@ func (ts *TrackingService) handleTrackingStatus(c *gin.Context) {
 		log.Printf("获取埋点数量失败: %v", err)
 	}
 
+	partitions, err := ts.Partitions(c.Request.Context())
+	if err != nil {
+		log.Printf("获取埋点分区失败: %v", err)
+	}
+
 	// 使用中国时区的当前时间
 	currentTime := time.Now().In(chinaLocation)
 
@@ -260,5 +283,7 @@ func (ts *TrackingService) handleTrackingStatus(c *gin.Context) {
 		"total_events":   count,
 		"timezone":       "Asia/Shanghai",
 		"formatted_time": currentTime.Format("2006-01-02 15:04:05"),
+		"writer":         ts.Stats(),
+		"partitions":     partitions,
 	})
 }
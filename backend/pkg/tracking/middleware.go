@@ -10,6 +10,8 @@ import (
 	"sync"
 	"time"
 
+	"blog/pkg/useragent"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -60,18 +62,6 @@ func cleanExpiredCache() {
 	// 缓存已清理
 }
 
-// 确保数据库连接使用UTF8编码
-func (ts *TrackingService) ensureUTF8Encoding() error {
-	if ts.db != nil {
-		_, err := ts.db.Exec("SET client_encoding = 'UTF8'")
-		if err != nil {
-			log.Printf("设置数据库客户端编码失败: %v", err)
-			return err
-		}
-	}
-	return nil
-}
-
 // 添加新的结构体用于缓存上一次事件信息
 type LastEventInfo struct {
 	Timestamp int64
@@ -125,8 +115,9 @@ func convertToUnpartitionedTrackEvent(req UnpartitionedTrackEventRequest, c *gin
 
 	// 验证并智能处理platform字段
 	validPlatforms := map[string]bool{
-		"Windows": true, "macOS": true, "Linux": true,
-		"Android": true, "iOS": true, "Unknown": true,
+		useragent.OSWindows: true, useragent.OSWindowsPhone: true, useragent.OSMacOS: true,
+		useragent.OSIOS: true, useragent.OSAndroid: true, useragent.OSHarmonyOS: true,
+		useragent.OSChromeOS: true, useragent.OSLinux: true, "Unknown": true,
 	}
 
 	// 智能解析platform：处理前端发送的"OS/Browser"格式
@@ -142,19 +133,17 @@ func convertToUnpartitionedTrackEvent(req UnpartitionedTrackEventRequest, c *gin
 		}
 	}
 
-	// 如果platform为空、unknown或不在有效列表中，则从UserAgent提取
-	if req.Platform == "" || req.Platform == "unknown" || !validPlatforms[req.Platform] {
-		// 记录原始值（如果有）
-		if req.Platform != "" && req.Platform != "unknown" {
-
-		}
-
-		// 从user_agent中提取平台信息
-		userAgent := c.Request.UserAgent()
-		platform, browser := extractPlatformFromUA(userAgent)
-		req.Platform = platform
-		extractedBrowser = browser // 使用UserAgent提取的浏览器
-
+	// 平台和浏览器以服务端解析 User-Agent 的结果为准，解析不出时才使用前端上报的值
+	uaInfo := useragent.Parse(c.Request.UserAgent())
+	if uaInfo.OS != "" {
+		req.Platform = uaInfo.OS
+	} else if req.Platform == "" || !validPlatforms[req.Platform] {
+		req.Platform = "Unknown"
+	}
+	if uaInfo.Browser != "" {
+		extractedBrowser = uaInfo.Browser
+	} else if extractedBrowser == "" || extractedBrowser == "unknown" {
+		extractedBrowser = "Unknown"
 	}
 
 	// 将浏览器信息保存到metadata中
@@ -264,7 +253,7 @@ func convertToUnpartitionedTrackEvent(req UnpartitionedTrackEventRequest, c *gin
 	}
 
 	// 清理device_info中的冗余和敏感数据
-	deviceInfoMap := req.DeviceInfo
+	deviceInfoMap := setUserAgentInfo(req.DeviceInfo, uaInfo)
 	if deviceInfoMap != nil {
 		// 移除可能的敏感字段
 		sensitiveKeys := []string{"password", "token", "secret", "key", "auth"}
@@ -342,13 +331,10 @@ func convertMapToString(data map[string]interface{}) string {
 // TrackingMiddleware 跟踪中间件
 func (ts *TrackingService) TrackingMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 确保UTF8编码
-		if err := ts.ensureUTF8Encoding(); err != nil {
-			log.Printf("设置UTF8编码失败: %v", err)
-		}
-
-		// 只对/api/tracking/batch端点进行特殊处理
-		if c.Request.URL.Path == "/api/tracking/batch" {
+		// 只对/api/tracking/batch端点进行特殊处理；同意状态和删除个人数据的请求不记录
+		if c.Request.URL.Path == "/api/tracking/batch" ||
+			strings.HasPrefix(c.Request.URL.Path, "/api/tracking/consent") ||
+			strings.HasPrefix(c.Request.URL.Path, "/api/tracking/forget") {
 			c.Next()
 			return
 		}
@@ -400,6 +386,7 @@ func (ts *TrackingService) TrackingMiddleware() gin.HandlerFunc {
 			UserID:           deviceFingerprint,   // 使用设备指纹作为user_id
 			SessionID:        sessionID,           // 使用会话ID
 			Referrer:         c.Request.Referer(), // 添加来源页面
+			DeviceInfo:       convertMapToString(setUserAgentInfo(nil, useragent.Parse(c.Request.UserAgent()))),
 		}
 
 		// 如果没有设备指纹，使用一个临时ID
@@ -414,8 +401,13 @@ func (ts *TrackingService) TrackingMiddleware() gin.HandlerFunc {
 
 		}
 
-		log.Printf("自动跟踪请求: method=%s, path=%s, query=%s, user_id=%s, session_id=%s",
-			method, event.PagePath, query, event.UserID, event.SessionID)
+		if !ts.prepareEvent(c, event, deviceFingerprint, nil) {
+			c.Next()
+			return
+		}
+
+		log.Printf("自动跟踪请求: method=%s, path=%s, query=%s, user_id=%s, session_id=%s, bot=%s",
+			method, event.PagePath, query, event.UserID, event.SessionID, event.BotName)
 
 		ts.TrackUnpartitionedEvent(event)
 		c.Next()
@@ -478,11 +470,6 @@ func createCustomProperties(method string, query string) string {
 
 // BatchTrackingHandler 处理批量埋点请求
 func (ts *TrackingService) BatchTrackingHandler(c *gin.Context) {
-	// 确保UTF8编码
-	if err := ts.ensureUTF8Encoding(); err != nil {
-		log.Printf("设置UTF8编码失败: %v", err)
-	}
-
 	var events []UnpartitionedTrackEventRequest
 	if err := c.ShouldBindJSON(&events); err != nil {
 		c.JSON(400, gin.H{"error": "Invalid request body"})
@@ -491,49 +478,35 @@ func (ts *TrackingService) BatchTrackingHandler(c *gin.Context) {
 
 	for _, req := range events {
 		event := convertToUnpartitionedTrackEvent(req, c)
+		if !ts.prepareEvent(c, event, req.UserID, req.DeviceInfo) {
+			continue
+		}
 		ts.TrackUnpartitionedEvent(event)
 	}
 
 	c.JSON(200, gin.H{"status": "success"})
 }
 
-// 修改辅助函数
-func extractPlatformFromUA(ua string) (platform, browser string) {
-	ua = strings.ToLower(ua)
-
-	// 检测操作系统
-	switch {
-	case strings.Contains(ua, "windows"):
-		platform = "Windows"
-	case strings.Contains(ua, "macintosh") || strings.Contains(ua, "mac os x"):
-		platform = "macOS"
-	case strings.Contains(ua, "linux"):
-		platform = "Linux"
-	case strings.Contains(ua, "android"):
-		platform = "Android"
-	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") || strings.Contains(ua, "ipod"):
-		platform = "iOS"
-	default:
-		platform = "Unknown"
-	}
-
-	// 检测浏览器（按优先级排序）
-	switch {
-	case strings.Contains(ua, "edg/") || strings.Contains(ua, "edge/"):
-		browser = "Edge"
-	case strings.Contains(ua, "chrome/") && !strings.Contains(ua, "edg/") && !strings.Contains(ua, "edge/"):
-		browser = "Chrome"
-	case strings.Contains(ua, "firefox/"):
-		browser = "Firefox"
-	case strings.Contains(ua, "safari/") && !strings.Contains(ua, "chrome/"):
-		browser = "Safari"
-	case strings.Contains(ua, "opera") || strings.Contains(ua, "opr/"):
-		browser = "Opera"
-	default:
-		browser = "Unknown"
-	}
-
-	return platform, browser
+// setUserAgentInfo 把 User-Agent 的解析结果写入 device_info，覆盖客户端上报的同名字段，识别不出的字段不写入
+func setUserAgentInfo(deviceInfo map[string]interface{}, info useragent.Info) map[string]interface{} {
+	fields := map[string]string{
+		"browser":         info.Browser,
+		"browser_version": info.BrowserVersion,
+		"os":              info.OS,
+		"os_version":      info.OSVersion,
+		"device_model":    info.DeviceModel,
+		"engine":          info.Engine,
+	}
+	for key, value := range fields {
+		if value == "" {
+			continue
+		}
+		if deviceInfo == nil {
+			deviceInfo = make(map[string]interface{})
+		}
+		deviceInfo[key] = value
+	}
+	return deviceInfo
 }
 
 // extractDeviceTypeFromUA 从 User-Agent 中解析设备类型
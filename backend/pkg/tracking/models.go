@@ -2,8 +2,55 @@ package tracking
 
 import (
 	"time"
+
+	"blog/pkg/bots"
+	"blog/pkg/geoip"
 )
 
+// Options 埋点事件批量写入参数
+type Options struct {
+	BatchSize     int           // 缓冲区达到该数量时立即写入
+	FlushInterval time.Duration // 定时写入间隔，减少数据库压力
+	QueueSize     int           // 待处理事件队列容量，队列满时改为同步写入而不是丢弃
+
+	// 数据库不可用时写入失败的事件暂存到本地磁盘，SpoolDir 为空时不启用
+	SpoolDir     string
+	SpoolMaxSize int64 // 落盘缓冲的总大小上限，超过后丢弃新的失败事件
+
+	// 按月分区维护：预先创建的月份数，为 0 时不执行分区维护
+	PartitionPremake int
+
+	// 写入时根据 IP 地址补充地理位置，为 nil 时不补充
+	GeoIP *geoip.DB
+
+	// 写入时识别机器人流量，为 nil 时不识别
+	Bots *bots.Detector
+
+	// 无 Cookie 模式：以每日轮换的盐对站点、IP 和 User-Agent 计算访客标识，不保存 IP 地址，见 visitor.go
+	Cookieless bool
+
+	// 请求带有 DNT: 1、Sec-GPC: 1 时的处理策略：ignore、anonymous 或 drop，见 privacy.go
+	DNTPolicy string
+	GPCPolicy string
+}
+
+// ServiceStats 埋点写入的状态指标，计数均为进程启动以来的累计值
+type ServiceStats struct {
+	QueuePending int64       `json:"queue_pending"` // 已入队但尚未写入的事件数
+	Persisted    int64       `json:"persisted"`     // 写入数据库的事件数
+	Spooled      int64       `json:"spooled"`       // 转存到落盘缓冲的事件数
+	Dropped      int64       `json:"dropped"`       // 写入失败且未能转存而丢弃的事件数
+	Spool        *SpoolStats `json:"spool,omitempty"`
+}
+
+// PartitionInfo track_event 的一个分区
+type PartitionInfo struct {
+	Name string `json:"name"`
+	From string `json:"from,omitempty"` // 分区范围 [From, To)，默认分区为空
+	To   string `json:"to,omitempty"`
+	Rows int64  `json:"rows"` // 来自统计信息的估算行数
+}
+
 // UnpartitionedTrackEvent 表示不分区的埋点事件
 type UnpartitionedTrackEvent struct {
 	ID               int64     `json:"id"`
@@ -24,4 +71,9 @@ type UnpartitionedTrackEvent struct {
 	DeviceID         string    `json:"device_id"`         // 设备ID（与数据库表对齐）
 	Version          string    `json:"version"`           // 应用版本（与数据库表对齐）
 	DeviceType       string    `json:"device_type"`       // 设备类型：Desktop, Mobile, Tablet
+	Country          string    `json:"country"`           // 国家代码，由 IP 地址查询得到
+	Region           string    `json:"region"`            // 省/州
+	City             string    `json:"city"`              // 城市
+	IsBot            bool      `json:"is_bot"`            // 是否为爬虫、监控等机器人流量
+	BotName          string    `json:"bot_name"`          // 机器人名称，如 Googlebot
 }
@@ -0,0 +1,364 @@
+package tracking
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// 按月分区：track_event 是按 created_at 范围分区的父表（迁移 0002），每月一个分区
+// track_event_pYYYYMM，不属于任何月分区的数据（升级前的历史数据、时间异常的事件）落在默认分区
+// track_event_default 中。
+//
+// 分区维护在启动时和之后每小时执行一次：
+//  1. 预先创建当前月及之后 PartitionPremake 个月的分区，默认分区中已有的这些月份的数据一并搬入
+//  2. 把默认分区中的历史数据逐月搬入对应的月分区
+//
+// 每个月在一个事务中完成，搬迁期间数据始终可查；某个月失败不影响其他月份，下一轮重试。
+//
+// 过期分区由数据保留任务通过 DropPartitionsBefore 整体删除（见 pkg/retention）。
+// 多个实例通过咨询锁保证同一时间只有一个实例修改分区。
+
+const (
+	partitionParent            = "track_event"
+	partitionDefault           = "track_event_default"
+	partitionPrefix            = "track_event_p"
+	partitionLockKey     int64 = 0x6d626c6f6770 // "mblogp"
+	partitionInterval          = time.Hour
+	partitionLockTimeout       = "5s" // 等待表锁的上限，避免长时间阻塞埋点写入和查询
+)
+
+// partitionLoop 定期执行分区维护，服务关闭时退出
+func (ts *TrackingService) partitionLoop() {
+	defer close(ts.partitionStopped)
+
+	ticker := time.NewTicker(partitionInterval)
+	defer ticker.Stop()
+
+	for {
+		ts.maintainPartitions()
+
+		select {
+		case <-ts.partitionStop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// maintainPartitions 执行一轮分区维护，服务关闭时中止正在进行的事务
+func (ts *TrackingService) maintainPartitions() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-ts.partitionStop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := ts.runPartitionMaintenance(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("埋点分区维护失败: %v", err)
+	}
+}
+
+func (ts *TrackingService) runPartitionMaintenance(ctx context.Context) error {
+	conn, locked, err := lockPartitions(ctx, ts.db)
+	if err != nil || !locked {
+		return err // 未取得锁说明其他实例正在维护
+	}
+	defer unlockPartitions(conn)
+
+	partitions, err := listPartitions(ctx, conn)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool, len(partitions))
+	for _, p := range partitions {
+		existing[p.Name] = true
+	}
+	if !existing[partitionDefault] {
+		return fmt.Errorf("%s 不是分区表或缺少默认分区，请先执行数据库迁移", partitionParent)
+	}
+
+	current := monthStart(time.Now().In(chinaLocation))
+	last := current.AddDate(0, ts.partitionPremake, 0)
+
+	// 先创建当前月及之后的分区，新写入的事件尽快落入月分区而不是默认分区。
+	// 某个月失败时记录错误并继续处理其他月份，下一轮重试
+	var errs []error
+	failed := make(map[string]bool)
+	for month := current; !month.After(last); month = month.AddDate(0, 1, 0) {
+		if existing[partitionName(month)] {
+			continue
+		}
+		moved, err := splitPartition(ctx, conn, month)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, err)
+			failed[partitionName(month)] = true
+			continue
+		}
+		existing[partitionName(month)] = true
+		log.Printf("已创建埋点分区 %s，搬入默认分区中的 %d 条事件", partitionName(month), moved)
+	}
+
+	// 再逐月搬出默认分区中的历史数据，晚于预建范围的异常时间留在默认分区
+	cursor := "-infinity"
+	for {
+		var oldest sql.NullTime
+		err := conn.QueryRowContext(ctx,
+			"SELECT min(created_at) FROM "+partitionDefault+" WHERE created_at >= $1::timestamp", cursor,
+		).Scan(&oldest)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if !oldest.Valid {
+			break
+		}
+		month := time.Date(oldest.Time.Year(), oldest.Time.Month(), 1, 0, 0, 0, 0, chinaLocation)
+		if month.After(last) {
+			break
+		}
+		cursor = timestampLiteral(month.AddDate(0, 1, 0))
+		if existing[partitionName(month)] || failed[partitionName(month)] {
+			// 本轮已经失败过的月份留到下一轮重试
+			continue
+		}
+
+		moved, err := splitPartition(ctx, conn, month)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, err)
+			continue
+		}
+		existing[partitionName(month)] = true
+		log.Printf("已将 %d 条历史埋点事件搬入分区 %s", moved, partitionName(month))
+	}
+
+	return errors.Join(errs...)
+}
+
+// DropPartitionsBefore 分离并删除整月都早于 cutoff 的月分区，返回删除的分区名和其中的行数；
+// dryRun 时只统计不删除。cutoff 按与 created_at 相同的本地时间比较。
+// 其他实例正在修改分区时不做任何操作，剩余数据由调用方逐行删除
+func DropPartitionsBefore(ctx context.Context, db *sql.DB, cutoff time.Time, dryRun bool) ([]string, int64, error) {
+	conn, locked, err := lockPartitions(ctx, db)
+	if err != nil || !locked {
+		return nil, 0, err
+	}
+	defer unlockPartitions(conn)
+
+	partitions, err := listPartitions(ctx, conn)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), cutoff.Hour(), cutoff.Minute(), cutoff.Second(), 0, chinaLocation)
+	var dropped []string
+	var total int64
+	for _, p := range partitions {
+		month, ok := parsePartitionName(p.Name)
+		if !ok || month.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+		var rows int64
+		if err := conn.QueryRowContext(ctx, "SELECT count(*) FROM "+pq.QuoteIdentifier(p.Name)).Scan(&rows); err != nil {
+			return dropped, total, err
+		}
+		if !dryRun {
+			if err := dropPartition(ctx, conn, p.Name); err != nil {
+				return dropped, total, err
+			}
+		}
+		dropped = append(dropped, p.Name)
+		total += rows
+	}
+	return dropped, total, nil
+}
+
+// lockPartitions 获取修改分区的咨询锁，返回持有锁的连接；锁已被占用时 locked 为 false
+func lockPartitions(ctx context.Context, db *sql.DB) (*sql.Conn, bool, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", partitionLockKey).Scan(&locked); err != nil || !locked {
+		conn.Close()
+		return nil, false, err
+	}
+	return conn, true, nil
+}
+
+func unlockPartitions(conn *sql.Conn) {
+	conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", partitionLockKey)
+	conn.Close()
+}
+
+// splitPartition 创建 month 对应的月分区，并在同一事务中把默认分区里属于该月的数据搬入新分区，返回搬迁的行数。
+// 挂载新分区时会校验默认分区中没有该月的数据，因此搬迁前先锁住默认分区禁止写入（读取不受影响），
+// 直到事务结束；期间落入默认分区的写入会等待，写入其他月分区的不受影响
+func splitPartition(ctx context.Context, conn *sql.Conn, month time.Time) (int64, error) {
+	name := partitionName(month)
+	from, to := timestampLiteral(month), timestampLiteral(month.AddDate(0, 1, 0))
+	bound := fmt.Sprintf("created_at >= '%s' AND created_at < '%s'", from, to)
+	columns := "id, " + strings.Join(trackEventColumns, ", ")
+	table := pq.QuoteIdentifier(name)
+
+	var moved int64
+	err := inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SET LOCAL lock_timeout = '"+partitionLockTimeout+"'"); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+			"CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS)", table, partitionParent)); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "LOCK TABLE "+partitionDefault+" IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`
+			WITH moved AS (DELETE FROM %s WHERE %s RETURNING %s)
+			INSERT INTO %s (%s) SELECT %s FROM moved
+		`, partitionDefault, bound, columns, table, columns, columns))
+		if err != nil {
+			return err
+		}
+		if moved, err = result.RowsAffected(); err != nil {
+			return err
+		}
+
+		// 预先加上与分区范围一致的约束，挂载时不必再扫描新分区
+		check := pq.QuoteIdentifier(name + "_bound")
+		stmts := []string{
+			fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s)", table, check, bound),
+			fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')", partitionParent, table, from, to),
+			fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", table, check),
+		}
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("创建分区 %s 失败: %w", name, err)
+	}
+	return moved, nil
+}
+
+// dropPartition 分离并删除一个月分区
+func dropPartition(ctx context.Context, conn *sql.Conn, name string) error {
+	table := pq.QuoteIdentifier(name)
+	err := inTx(ctx, conn, func(tx *sql.Tx) error {
+		stmts := []string{
+			"SET LOCAL lock_timeout = '" + partitionLockTimeout + "'",
+			fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", partitionParent, table),
+			"DROP TABLE " + table,
+		}
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("删除分区 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// queryer 是 *sql.DB 和 *sql.Conn 共有的查询方法
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// listPartitions 列出 track_event 的全部分区，按名称排序
+func listPartitions(ctx context.Context, q queryer) ([]PartitionInfo, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT c.relname, GREATEST(c.reltuples, 0)::bigint
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = to_regclass($1)
+		ORDER BY c.relname
+	`, partitionParent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partitions []PartitionInfo
+	for rows.Next() {
+		var p PartitionInfo
+		if err := rows.Scan(&p.Name, &p.Rows); err != nil {
+			return nil, err
+		}
+		if month, ok := parsePartitionName(p.Name); ok {
+			p.From = month.Format("2006-01-02")
+			p.To = month.AddDate(0, 1, 0).Format("2006-01-02")
+		}
+		partitions = append(partitions, p)
+	}
+	return partitions, rows.Err()
+}
+
+// Partitions 返回 track_event 当前的分区，用于状态检查
+func (ts *TrackingService) Partitions(ctx context.Context) ([]PartitionInfo, error) {
+	return listPartitions(ctx, ts.db)
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// monthStart 返回 t 所在月份的第一天零点
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// partitionName 返回月分区的表名，如 track_event_p202601
+func partitionName(month time.Time) string {
+	return partitionPrefix + month.Format("200601")
+}
+
+// parsePartitionName 从月分区表名解析月份，默认分区等其他表返回 false
+func parsePartitionName(name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, partitionPrefix)
+	if !ok || len(suffix) != 6 {
+		return time.Time{}, false
+	}
+	month, err := time.ParseInLocation("200601", suffix, chinaLocation)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+// timestampLiteral 格式化为 created_at（不带时区的 timestamp）使用的字面量
+func timestampLiteral(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,66 @@
+package tracking
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 浏览器隐私信号（DNT: 1、Sec-GPC: 1）的处理策略，按信号分别配置，同时带有两个信号时取更严格的策略
+const (
+	PrivacyIgnore    = "ignore"    // 照常记录
+	PrivacyAnonymous = "anonymous" // 记录事件，但不保存会话 ID、用户 ID 和 IP 地址，只计入 PV 和事件数
+	PrivacyDrop      = "drop"      // 不记录
+)
+
+// privacyStrictness 策略的严格程度，未配置时按 ignore 处理，未知的策略按 drop 处理
+var privacyStrictness = map[string]int{
+	PrivacyIgnore:    0,
+	PrivacyAnonymous: 1,
+	PrivacyDrop:      2,
+}
+
+// privacyAction 根据请求头中的隐私信号返回应执行的策略
+func (ts *TrackingService) privacyAction(header http.Header) string {
+	action := PrivacyIgnore
+	apply := func(policy string) {
+		if policy == "" {
+			return
+		}
+		strictness, ok := privacyStrictness[policy]
+		if !ok {
+			policy, strictness = PrivacyDrop, privacyStrictness[PrivacyDrop]
+		}
+		if strictness > privacyStrictness[action] {
+			action = policy
+		}
+	}
+	if header.Get("DNT") == "1" {
+		apply(ts.dntPolicy)
+	}
+	if header.Get("Sec-GPC") == "1" {
+		apply(ts.gpcPolicy)
+	}
+	return action
+}
+
+// prepareEvent 写入前依次检查访客的同意状态和隐私信号、识别机器人并按需匿名化，返回 false 时丢弃该事件。
+// deviceID 为前端上报的设备指纹，没有时为空
+func (ts *TrackingService) prepareEvent(c *gin.Context, event *UnpartitionedTrackEvent, deviceID string, deviceInfo map[string]interface{}) bool {
+	if ts.consent.denied(deviceID) {
+		return false
+	}
+	action := ts.privacyAction(c.Request.Header)
+	if action == PrivacyDrop {
+		return false
+	}
+
+	ts.detectBot(event, c.Request.Header, deviceInfo)
+	if action == PrivacyAnonymous {
+		ts.enrichLocation(event)
+		event.SessionID, event.UserID, event.IPAddress = "", "", ""
+		return true
+	}
+	ts.anonymize(event, c.Request.Host)
+	return true
+}
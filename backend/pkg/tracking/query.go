@@ -0,0 +1,204 @@
+package tracking
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dbTimeZone created_at 保存的本地时间所在的时区，与数据库连接参数 timezone 一致
+const dbTimeZone = "Asia/Shanghai"
+
+// 统计查询参数的默认值和上限
+const (
+	defaultStatsDays        = 7
+	defaultStatsGranularity = "day"
+	defaultStatsLimit       = 10
+	maxStatsDays            = 366
+	maxStatsBuckets         = 1000
+	maxStatsLimit           = 100
+)
+
+// granularity 趋势图的时间粒度，layout 和 pgFormat 分别是 Go 和 PostgreSQL 中时间桶标签的格式
+type granularity struct {
+	layout   string
+	pgFormat string
+}
+
+var granularities = map[string]granularity{
+	"hour":  {"2006-01-02 15:00", "YYYY-MM-DD HH24:00"},
+	"day":   {"2006-01-02", "YYYY-MM-DD"},
+	"week":  {"2006-01-02", "YYYY-MM-DD"}, // 以周一的日期表示
+	"month": {"2006-01", "YYYY-MM"},
+}
+
+// StatsQuery 统计看板的查询窗口，所有统计项都只统计 [From, To) 内的数据
+type StatsQuery struct {
+	From        time.Time
+	To          time.Time
+	Granularity string         // hour、day、week、month
+	Location    *time.Location // 时间桶按该时区划分
+	Limit       int            // 热门页面数量
+	IncludeBots bool           // 统计中包含机器人流量，默认排除
+}
+
+// ParseStatsQuery 解析并校验 GET /api/analytics 的查询参数：
+//
+//	from, to     日期（2006-01-02，to 当天包含在内）或 RFC3339 时间，默认最近 7 天（含今天）
+//	granularity  hour、day、week、month，默认 day
+//	tz           IANA 时区名，默认 Asia/Shanghai，日期参数和时间桶都按该时区解释
+//	limit        热门页面数量，1-100，默认 10
+//	include_bots 为 true 时统计中包含机器人流量，默认排除（机器人流量总是单独统计在 bots 中）
+func ParseStatsQuery(params url.Values, now time.Time) (StatsQuery, error) {
+	q := StatsQuery{
+		Granularity: defaultStatsGranularity,
+		Location:    chinaLocation,
+		Limit:       defaultStatsLimit,
+	}
+
+	if tz := strings.TrimSpace(params.Get("tz")); tz != "" {
+		// Local 取决于服务器环境，数据库也无法识别
+		loc, err := time.LoadLocation(tz)
+		if err != nil || tz == "Local" {
+			return q, fmt.Errorf("无效的时区: %q", tz)
+		}
+		q.Location = loc
+	}
+
+	if g := strings.TrimSpace(params.Get("granularity")); g != "" {
+		if _, ok := granularities[g]; !ok {
+			return q, fmt.Errorf("granularity 必须是 hour、day、week 或 month: %q", g)
+		}
+		q.Granularity = g
+	}
+
+	if value := strings.TrimSpace(params.Get("limit")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxStatsLimit {
+			return q, fmt.Errorf("limit 必须是 1-%d 之间的整数: %q", maxStatsLimit, value)
+		}
+		q.Limit = n
+	}
+
+	if value := strings.TrimSpace(params.Get("include_bots")); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return q, fmt.Errorf("include_bots 必须是 true 或 false: %q", value)
+		}
+		q.IncludeBots = b
+	}
+
+	var err error
+	if value := strings.TrimSpace(params.Get("to")); value != "" {
+		if q.To, err = parseStatsTime(value, q.Location, true); err != nil {
+			return q, fmt.Errorf("to %w", err)
+		}
+	} else {
+		today := now.In(q.Location)
+		q.To = time.Date(today.Year(), today.Month(), today.Day()+1, 0, 0, 0, 0, q.Location)
+	}
+	if value := strings.TrimSpace(params.Get("from")); value != "" {
+		if q.From, err = parseStatsTime(value, q.Location, false); err != nil {
+			return q, fmt.Errorf("from %w", err)
+		}
+	} else {
+		q.From = q.To.AddDate(0, 0, -defaultStatsDays)
+	}
+
+	if !q.From.Before(q.To) {
+		return q, fmt.Errorf("from 必须早于 to")
+	}
+	if q.To.Sub(q.From) > maxStatsDays*24*time.Hour {
+		return q, fmt.Errorf("时间范围不能超过 %d 天", maxStatsDays)
+	}
+	if len(q.buckets()) > maxStatsBuckets {
+		return q, fmt.Errorf("按 %s 统计时时间点超过 %d 个，请缩小时间范围或使用更大的粒度", q.Granularity, maxStatsBuckets)
+	}
+	return q, nil
+}
+
+// parseStatsTime 解析日期或 RFC3339 时间；日期作为结束时间时表示当天结束
+func parseStatsTime(value string, loc *time.Location, end bool) (time.Time, error) {
+	if day, err := time.ParseInLocation(dateLayout, value, loc); err == nil {
+		if end {
+			day = day.AddDate(0, 0, 1)
+		}
+		return day, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("必须是日期（2006-01-02）或 RFC3339 时间: %q", value)
+	}
+	return t.In(loc), nil
+}
+
+// Previous 返回紧邻查询窗口之前、长度相同的上一周期。
+// 按整天查询时按天数向前推，不受夏令时切换影响
+func (q StatsQuery) Previous() (time.Time, time.Time) {
+	if isMidnight(q.From, q.Location) && isMidnight(q.To, q.Location) {
+		days := int(math.Round(q.To.Sub(q.From).Hours() / 24))
+		return q.From.AddDate(0, 0, -days), q.From
+	}
+	return q.From.Add(-q.To.Sub(q.From)), q.From
+}
+
+// truncate 返回 t 所在时间桶的起点
+func (q StatsQuery) truncate(t time.Time) time.Time {
+	t = t.In(q.Location)
+	switch q.Granularity {
+	case "hour":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, q.Location)
+	case "week":
+		offset := (int(t.Weekday()) + 6) % 7 // 周一为一周的第一天
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, q.Location)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, q.Location)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, q.Location)
+	}
+}
+
+// next 返回下一个时间桶的起点
+func (q StatsQuery) next(bucket time.Time) time.Time {
+	switch q.Granularity {
+	case "hour":
+		// 夏令时结束时同一本地小时出现两次，按绝对时间前进
+		next := bucket.Add(time.Hour)
+		if t := q.truncate(next); t.After(bucket) {
+			return t
+		}
+		return next
+	case "week":
+		return bucket.AddDate(0, 0, 7)
+	case "month":
+		return bucket.AddDate(0, 1, 0)
+	default:
+		return bucket.AddDate(0, 0, 1)
+	}
+}
+
+// buckets 返回窗口内各时间桶的起点，第一个桶可能早于 From
+func (q StatsQuery) buckets() []time.Time {
+	var result []time.Time
+	for b := q.truncate(q.From); b.Before(q.To); b = q.next(b) {
+		result = append(result, b)
+		if len(result) > maxStatsBuckets {
+			break
+		}
+	}
+	return result
+}
+
+// label 返回时间桶的标签
+func (q StatsQuery) label(bucket time.Time) string {
+	return bucket.In(q.Location).Format(granularities[q.Granularity].layout)
+}
+
+// isMidnight 判断 t 是否为 loc 时区的零点
+func isMidnight(t time.Time, loc *time.Location) bool {
+	t = t.In(loc)
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
@@ -0,0 +1,85 @@
+package tracking
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 修改同意状态和删除个人数据是公开接口，按客户端 IP 限制请求频率，限制只在本实例内生效。
+// 客户端 IP 取自 gin 的 ClientIP，只有可信代理（TRUSTED_PROXIES）转发的 X-Forwarded-For 才会被采用
+
+const rateLimiterEntries = 10000
+
+// 各接口每个 IP 在窗口内允许的请求数
+const (
+	forgetRateLimit  = 5
+	consentRateLimit = 30
+	privacyRateLimit = time.Hour // 窗口长度
+)
+
+type rateWindow struct {
+	count   int
+	expires time.Time
+}
+
+// ipRateLimiter 固定窗口计数的限流器
+type ipRateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]rateWindow
+}
+
+func newIPRateLimiter(limit int, window time.Duration) *ipRateLimiter {
+	return &ipRateLimiter{limit: limit, window: window, windows: make(map[string]rateWindow)}
+}
+
+// allow 记录一次请求，超过限制时返回 false 和需要等待的时间
+func (l *ipRateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows[ip]
+	if !ok || !now.Before(w.expires) {
+		// 记录过多时先清除过期的窗口，仍然过多时全部清除，避免伪造来源的请求耗尽内存
+		if len(l.windows) >= rateLimiterEntries {
+			for key, entry := range l.windows {
+				if !now.Before(entry.expires) {
+					delete(l.windows, key)
+				}
+			}
+			if len(l.windows) >= rateLimiterEntries {
+				clear(l.windows)
+			}
+		}
+		w = rateWindow{expires: now.Add(l.window)}
+	}
+	if w.count >= l.limit {
+		return false, w.expires.Sub(now)
+	}
+	w.count++
+	l.windows[ip] = w
+	return true, 0
+}
+
+// middleware 超过限制时返回 429
+func (l *ipRateLimiter) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, wait := l.allow(c.ClientIP(), time.Now())
+		if !ok {
+			retryAfter := int(wait.Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "请求过于频繁，请稍后再试",
+				"retry_after": retryAfter,
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,311 @@
+package tracking
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 日汇总：后台任务把已结束的每一天的原始事件汇总到 analytics_daily_* 表（迁移 0003），
+// 统计看板对已汇总的日期读取汇总表，只对之后（通常只有今天）的数据查询原始表。
+//
+// 每轮从上次汇总的最后一天继续，并重新汇总最近 rollupLookback 天，
+// 纳入延迟写入的事件（如落盘缓冲重放、客户端上报的历史时间戳）。单日汇总在一个事务中替换，可重复执行。
+
+const (
+	rollupLockKey  int64 = 0x6d626c6f6761 // "mbloga"
+	rollupLookback       = 2
+	dateLayout           = "2006-01-02"
+)
+
+// rollupDimensions 按维度汇总的字段，name 与 analytics_daily_dimension.dimension 对应
+var rollupDimensions = []struct {
+	name string
+	expr string
+}{
+	{"device_type", "device_type"},
+	{"platform", "platform"},
+	{"browser", "metadata->>'browser'"},
+	{"location", "concat_ws(' ', country, region)"}, // 如 "CN 广东"，未知地区为空
+	{"bot", "bot_name"},                             // 只有机器人流量有值
+}
+
+// counter 一个汇总分组的计数和访客估算器
+type counter struct {
+	count int64
+	uv    *HLL
+}
+
+func (c *counter) add(sessionID string, n int64) {
+	c.count += n
+	if sessionID != "" {
+		c.uv.Add(sessionID)
+	}
+}
+
+func getCounter(m map[string]*counter, key string) *counter {
+	c, ok := m[key]
+	if !ok {
+		c = &counter{uv: NewHLL()}
+		m[key] = c
+	}
+	return c
+}
+
+// rollupLoop 启动时汇总一次，之后按间隔定期汇总
+func (s *AnalyticsService) rollupLoop() {
+	defer close(s.rollupStopped)
+
+	ticker := time.NewTicker(s.rollupInterval)
+	defer ticker.Stop()
+
+	for {
+		s.rollup()
+
+		select {
+		case <-s.rollupStop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rollup 执行一轮汇总，服务关闭时中止
+func (s *AnalyticsService) rollup() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.rollupStop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := s.runRollup(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("统计日汇总失败: %v", err)
+	}
+}
+
+// runRollup 汇总从上次汇总位置到昨天的每一天，多个实例同时运行时只有一个实例执行
+func (s *AnalyticsService) runRollup(ctx context.Context) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", rollupLockKey).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", rollupLockKey)
+
+	today := localDay(time.Now())
+
+	// 从最后一个已汇总日期往前回溯；从未汇总过时从最早的事件开始
+	var last, first sql.NullString
+	if err := conn.QueryRowContext(ctx,
+		"SELECT to_char(max(day), 'YYYY-MM-DD') FROM analytics_daily_site",
+	).Scan(&last); err != nil {
+		return err
+	}
+	var start time.Time
+	if last.Valid {
+		lastDay, err := time.ParseInLocation(dateLayout, last.String, chinaLocation)
+		if err != nil {
+			return err
+		}
+		start = lastDay.AddDate(0, 0, 1-rollupLookback)
+	} else {
+		if err := conn.QueryRowContext(ctx,
+			"SELECT to_char(min(created_at), 'YYYY-MM-DD') FROM track_event",
+		).Scan(&first); err != nil {
+			return err
+		}
+		if !first.Valid {
+			return nil
+		}
+		if start, err = time.ParseInLocation(dateLayout, first.String, chinaLocation); err != nil {
+			return err
+		}
+	}
+
+	days := 0
+	for day := start; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if err := s.rollupDay(ctx, conn, day); err != nil {
+			return fmt.Errorf("汇总 %s 失败: %w", day.Format(dateLayout), err)
+		}
+		days++
+	}
+	if !last.Valid && days > 0 {
+		log.Printf("统计日汇总初始化完成，共汇总 %d 天", days)
+	}
+	return nil
+}
+
+// dayRollup 一天中机器人或非机器人流量的汇总
+type dayRollup struct {
+	site       *counter
+	pages      map[string]*counter
+	dimensions []map[string]*counter
+	events     int64
+}
+
+func newDayRollup() *dayRollup {
+	r := &dayRollup{
+		site:       &counter{uv: NewHLL()},
+		pages:      make(map[string]*counter),
+		dimensions: make([]map[string]*counter, len(rollupDimensions)),
+	}
+	for i := range r.dimensions {
+		r.dimensions[i] = make(map[string]*counter)
+	}
+	return r
+}
+
+// rollupDay 重新汇总一天的数据，在一个事务中替换该日期已有的汇总。
+// 机器人和非机器人流量分开汇总，非机器人部分每天都写入一行，用于确定汇总进度
+func (s *AnalyticsService) rollupDay(ctx context.Context, conn *sql.Conn, day time.Time) error {
+	date := day.Format(dateLayout)
+	groups := map[bool]*dayRollup{false: newDayRollup(), true: newDayRollup()}
+
+	// 数据库按 页面 × 会话 去重后返回，估算器在这里构建
+	rows, err := conn.QueryContext(ctx, `
+		SELECT is_bot, COALESCE(page_path, ''), COALESCE(session_id, ''), COUNT(*)
+		FROM track_event
+		WHERE created_at >= $1::date AND created_at < $1::date + 1
+		  AND event_type = 'PAGEVIEW'
+		GROUP BY 1, 2, 3
+	`, date)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var isBot bool
+		var path, session string
+		var n int64
+		if err := rows.Scan(&isBot, &path, &session, &n); err != nil {
+			rows.Close()
+			return err
+		}
+		g := groups[isBot]
+		g.site.add(session, n)
+		getCounter(g.pages, path).add(session, n)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// 各维度的取值 × 会话，统计全部事件
+	selects := []string{"is_bot"}
+	var groupBy []string
+	for _, d := range rollupDimensions {
+		selects = append(selects, "COALESCE("+d.expr+", '')")
+	}
+	for i := 0; i <= len(rollupDimensions)+1; i++ {
+		groupBy = append(groupBy, strconv.Itoa(i+1))
+	}
+	rows, err = conn.QueryContext(ctx, `
+		SELECT `+strings.Join(selects, ", ")+`, COALESCE(session_id, ''), COUNT(*)
+		FROM track_event
+		WHERE created_at >= $1::date AND created_at < $1::date + 1
+		GROUP BY `+strings.Join(groupBy, ", "), date)
+	if err != nil {
+		return err
+	}
+	values := make([]string, len(rollupDimensions))
+	for rows.Next() {
+		var isBot bool
+		var session string
+		var n int64
+		dest := make([]any, 0, len(values)+3)
+		dest = append(dest, &isBot)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(append(dest, &session, &n)...); err != nil {
+			rows.Close()
+			return err
+		}
+		g := groups[isBot]
+		g.events += n
+		for i, value := range values {
+			if value != "" {
+				getCounter(g.dimensions[i], value).add(session, n)
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return inTx(ctx, conn, func(tx *sql.Tx) error {
+		for _, table := range []string{"analytics_daily_site", "analytics_daily_page", "analytics_daily_dimension"} {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE day = $1::date", date); err != nil {
+				return err
+			}
+		}
+
+		siteStmt, err := tx.PrepareContext(ctx,
+			"INSERT INTO analytics_daily_site(day, is_bot, pv, events, uv_sketch, rolled_at) VALUES($1::date, $2, $3, $4, $5, $6)")
+		if err != nil {
+			return err
+		}
+		defer siteStmt.Close()
+		pageStmt, err := tx.PrepareContext(ctx,
+			"INSERT INTO analytics_daily_page(day, is_bot, page_path, pv, uv_sketch) VALUES($1::date, $2, $3, $4, $5)")
+		if err != nil {
+			return err
+		}
+		defer pageStmt.Close()
+		dimStmt, err := tx.PrepareContext(ctx,
+			"INSERT INTO analytics_daily_dimension(day, is_bot, dimension, value, events, uv_sketch) VALUES($1::date, $2, $3, $4, $5, $6)")
+		if err != nil {
+			return err
+		}
+		defer dimStmt.Close()
+
+		for _, isBot := range []bool{false, true} {
+			g := groups[isBot]
+			if isBot && g.events == 0 {
+				continue
+			}
+
+			sketch, _ := g.site.uv.MarshalBinary()
+			if _, err := siteStmt.ExecContext(ctx, date, isBot, g.site.count, g.events, sketch, time.Now()); err != nil {
+				return err
+			}
+			for _, path := range sortedKeys(g.pages) {
+				sketch, _ := g.pages[path].uv.MarshalBinary()
+				if _, err := pageStmt.ExecContext(ctx, date, isBot, path, g.pages[path].count, sketch); err != nil {
+					return err
+				}
+			}
+			for i, d := range rollupDimensions {
+				for _, value := range sortedKeys(g.dimensions[i]) {
+					sketch, _ := g.dimensions[i][value].uv.MarshalBinary()
+					if _, err := dimStmt.ExecContext(ctx, date, isBot, d.name, value, g.dimensions[i][value].count, sketch); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// localDay 返回 t 在本地时区（与 created_at 一致）所在日期的零点
+func localDay(t time.Time) time.Time {
+	t = t.In(chinaLocation)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, chinaLocation)
+}
@@ -1,10 +1,18 @@
 package tracking
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"blog/pkg/bots"
+	"blog/pkg/geoip"
 )
 
 // TrackingService 处理埋点数据的服务
@@ -15,46 +23,236 @@ type TrackingService struct {
 	unpartDataChan chan *UnpartitionedTrackEvent
 	batchSize      int
 	flushTime      time.Duration
+
+	// 关闭状态：closeMu 保证关闭队列时没有正在入队的事件
+	closeMu   sync.RWMutex
+	closed    bool
+	stopped   chan struct{}  // 批处理器退出后关闭
+	flushWG   sync.WaitGroup // 正在执行的批量写入
+	pending   atomic.Int64   // 已入队但尚未写入的事件数
+	persisted atomic.Int64   // 累计写入成功的事件数
+	spooled   atomic.Int64   // 累计转存到落盘缓冲的事件数
+	failed    atomic.Int64   // 累计写入失败且未能转存的事件数
+
+	// 落盘缓冲及其重放协程，未启用时 spool 为 nil
+	spool         *Spool
+	replayStop    chan struct{}
+	replayStopped chan struct{}
+
+	// 写入时补充地理位置，未配置 GeoIP 数据库时为 nil
+	geo *geoip.DB
+	// 写入时识别机器人流量
+	bots *bots.Detector
+
+	// 隐私信号策略和访客的同意状态
+	dntPolicy string
+	gpcPolicy string
+	consent   *consentStore
+
+	// 无 Cookie 模式的每日盐及其轮换协程，未启用时 salts 为 nil
+	salts       *visitorSalts
+	saltStop    chan struct{}
+	saltStopped chan struct{}
+
+	// 按月分区维护协程，见 partition.go
+	partitionPremake int
+	partitionStop    chan struct{}
+	partitionStopped chan struct{}
 }
 
 // NewTrackingService 创建新的跟踪服务
-func NewTrackingService(db *sql.DB) *TrackingService {
+func NewTrackingService(db *sql.DB, opts Options) *TrackingService {
 	ts := &TrackingService{
 		db:             db,
-		unpartBuffer:   make([]*UnpartitionedTrackEvent, 0, 1000),
-		unpartDataChan: make(chan *UnpartitionedTrackEvent, 50000), // 提升5倍容量，减少丢弃风险
-		batchSize:      200,                                        // 设置为200以匹配批处理大小
-		flushTime:      10 * time.Second,                           // 增加到10秒以减少数据库压力
+		unpartBuffer:   make([]*UnpartitionedTrackEvent, 0, opts.BatchSize),
+		unpartDataChan: make(chan *UnpartitionedTrackEvent, opts.QueueSize),
+		batchSize:      opts.BatchSize,
+		flushTime:      opts.FlushInterval,
+		geo:            opts.GeoIP,
+		bots:           opts.Bots,
+		dntPolicy:      opts.DNTPolicy,
+		gpcPolicy:      opts.GPCPolicy,
+		consent:        newConsentStore(db),
+		stopped:        make(chan struct{}),
+		replayStop:     make(chan struct{}),
+		replayStopped:  make(chan struct{}),
+		saltStop:       make(chan struct{}),
+		saltStopped:    make(chan struct{}),
+
+		partitionPremake: opts.PartitionPremake,
+		partitionStop:    make(chan struct{}),
+		partitionStopped: make(chan struct{}),
+	}
+
+	if opts.SpoolDir != "" {
+		spool, err := OpenSpool(opts.SpoolDir, opts.SpoolMaxSize)
+		if err != nil {
+			log.Printf("警告: 埋点落盘缓冲不可用，数据库故障期间的事件将被丢弃: %v", err)
+		} else {
+			ts.spool = spool
+		}
 	}
 
 	// 启动批处理协程
 	go ts.unpartBatchProcessor()
 
+	// 启动落盘缓冲重放协程
+	if ts.spool != nil {
+		go ts.spoolReplayLoop()
+	} else {
+		close(ts.replayStopped)
+	}
+
+	// 启动访客标识盐的轮换协程
+	if opts.Cookieless {
+		ts.salts = &visitorSalts{db: db}
+		go ts.saltLoop()
+	} else {
+		close(ts.saltStopped)
+	}
+
+	// 启动分区维护协程
+	if ts.partitionPremake > 0 {
+		go ts.partitionLoop()
+	} else {
+		close(ts.partitionStopped)
+	}
+
 	return ts
 }
 
 // TrackUnpartitionedEvent 记录一个不分区跟踪事件
 func (ts *TrackingService) TrackUnpartitionedEvent(event *UnpartitionedTrackEvent) {
+	ts.enrichLocation(event)
+
+	ts.closeMu.RLock()
+	defer ts.closeMu.RUnlock()
+
+	// 服务关闭后不再入队，直接同步写入
+	if ts.closed {
+		ts.insertSync(event)
+		return
+	}
 
 	// 异步处理，带背压机制
 	select {
 	case ts.unpartDataChan <- event:
+		ts.pending.Add(1)
 
 	default:
 		// 队列已满时，同步写入数据库而不是丢弃事件
 		log.Printf("警告: 埋点队列已满，切换到同步写入: type=%s, session=%s", event.EventType, event.SessionID)
-		ts.insertSingleEvent(event)
+		ts.insertSync(event)
+	}
+}
+
+// enrichLocation 根据 IP 地址补充事件的地理位置，查询失败时保留为空。
+// 日志中不记录 IP 地址，无 Cookie 模式承诺原始 IP 不落盘
+func (ts *TrackingService) enrichLocation(event *UnpartitionedTrackEvent) {
+	if ts.geo == nil || event.IPAddress == "" || event.Country != "" {
+		return
+	}
+	loc, err := ts.geo.Lookup(event.IPAddress)
+	if errors.Is(err, geoip.ErrInvalidIP) {
+		log.Printf("查询 IP 地理位置失败: 无效的 IP 地址")
+		return
+	}
+	if err != nil {
+		log.Printf("查询 IP 地理位置失败: %v", err)
+		return
+	}
+	event.Country, event.Region, event.City = loc.Country, loc.Region, loc.City
+}
+
+// detectBot 根据请求信息标记机器人流量，deviceInfo 为客户端上报的设备信息，可为 nil
+func (ts *TrackingService) detectBot(event *UnpartitionedTrackEvent, header http.Header, deviceInfo map[string]interface{}) {
+	if ts.bots == nil {
+		return
+	}
+	webDriver, _ := deviceInfo["webdriver"].(bool)
+	result := ts.bots.Detect(bots.Request{
+		UserAgent: event.UserAgent,
+		IP:        event.IPAddress,
+		Header:    header,
+		WebDriver: webDriver,
+	})
+	event.IsBot, event.BotName = result.IsBot, result.Name
+}
+
+// Cookieless 是否启用无 Cookie 模式，启用时不应在任何日志中记录客户端 IP
+func (ts *TrackingService) Cookieless() bool {
+	return ts.salts != nil
+}
+
+// Close 停止批处理器并写入队列和缓冲区中剩余的事件，ctx 到期后放弃等待
+// 返回前会输出关闭期间写入和丢弃的事件数量
+func (ts *TrackingService) Close(ctx context.Context) error {
+	ts.closeMu.Lock()
+	if ts.closed {
+		ts.closeMu.Unlock()
+		return nil
+	}
+	ts.closed = true
+	persistedBefore, spooledBefore, failedBefore := ts.persisted.Load(), ts.spooled.Load(), ts.failed.Load()
+	// 已没有正在入队的事件，关闭队列后批处理器会取完剩余事件并退出
+	close(ts.unpartDataChan)
+	ts.closeMu.Unlock()
+
+	log.Printf("正在关闭埋点服务，待写入事件 %d 条...", ts.pending.Load())
+
+	// 中止正在进行的分区维护，未完成的事务会回滚，下次启动时重新执行
+	close(ts.partitionStop)
+	close(ts.saltStop)
+
+	done := make(chan struct{})
+	go func() {
+		<-ts.partitionStopped
+		<-ts.saltStopped
+		<-ts.stopped
+		ts.flushWG.Wait()
+		// 剩余事件处理完后再停止重放并关闭落盘缓冲，关闭期间写入失败的事件仍可转存
+		close(ts.replayStop)
+		<-ts.replayStopped
+		if ts.spool != nil {
+			ts.spool.Close()
+		}
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("等待埋点事件写入超时: %w", ctx.Err())
 	}
+
+	persisted := ts.persisted.Load() - persistedBefore
+	spooled := ts.spooled.Load() - spooledBefore
+	dropped := ts.failed.Load() - failedBefore + ts.pending.Load()
+	log.Printf("埋点服务已关闭: 写入 %d 条，转存落盘缓冲 %d 条，丢弃 %d 条", persisted, spooled, dropped)
+	return err
 }
 
-// unpartBatchProcessor 不分区批处理器
+// unpartBatchProcessor 不分区批处理器，队列关闭后写入剩余事件并退出
 func (ts *TrackingService) unpartBatchProcessor() {
+	defer close(ts.stopped)
+
 	ticker := time.NewTicker(ts.flushTime)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case event := <-ts.unpartDataChan:
+		case event, ok := <-ts.unpartDataChan:
+			if !ok {
+				ts.unpartBufferMu.Lock()
+				buffer := ts.unpartBuffer
+				ts.unpartBuffer = nil
+				ts.unpartBufferMu.Unlock()
+				ts.flushWG.Add(1)
+				ts.flush(buffer)
+				return
+			}
+
 			ts.unpartBufferMu.Lock()
 			ts.unpartBuffer = append(ts.unpartBuffer, event)
 
@@ -63,7 +261,8 @@ func (ts *TrackingService) unpartBatchProcessor() {
 				buffer := ts.unpartBuffer
 				ts.unpartBuffer = make([]*UnpartitionedTrackEvent, 0, ts.batchSize)
 				ts.unpartBufferMu.Unlock()
-				go ts.flushUnpartBuffer(buffer) // 使用协程异步刷新，防止阻塞主处理循环
+				ts.flushWG.Add(1)
+				go ts.flush(buffer) // 使用协程异步刷新，防止阻塞主处理循环
 			} else {
 				ts.unpartBufferMu.Unlock()
 			}
@@ -75,7 +274,8 @@ func (ts *TrackingService) unpartBatchProcessor() {
 				buffer := ts.unpartBuffer
 				ts.unpartBuffer = make([]*UnpartitionedTrackEvent, 0, ts.batchSize)
 				ts.unpartBufferMu.Unlock()
-				go ts.flushUnpartBuffer(buffer) // 使用协程异步刷新
+				ts.flushWG.Add(1)
+				go ts.flush(buffer) // 使用协程异步刷新
 			} else {
 				ts.unpartBufferMu.Unlock()
 			}
@@ -83,166 +283,103 @@ func (ts *TrackingService) unpartBatchProcessor() {
 	}
 }
 
-// flushUnpartBuffer 将不分区缓冲区数据批量写入数据库
-func (ts *TrackingService) flushUnpartBuffer(events []*UnpartitionedTrackEvent) {
+// flush 批量写入一批已入队的事件并更新计数，调用前需执行 flushWG.Add(1)
+func (ts *TrackingService) flush(events []*UnpartitionedTrackEvent) {
+	defer ts.flushWG.Done()
+
+	failed := ts.flushUnpartBuffer(events)
+	ts.persisted.Add(int64(len(events) - len(failed)))
+	ts.spoolOrDrop(failed)
+	ts.pending.Add(-int64(len(events)))
+}
+
+// insertSync 同步写入一条未入队的事件并更新计数
+func (ts *TrackingService) insertSync(event *UnpartitionedTrackEvent) {
+	if ts.insertSingleEvent(event) {
+		ts.persisted.Add(1)
+	} else {
+		ts.spoolOrDrop([]*UnpartitionedTrackEvent{event})
+	}
+}
+
+// spoolOrDrop 将写入失败的事件转存到落盘缓冲，未启用或转存失败时计为丢弃
+func (ts *TrackingService) spoolOrDrop(events []*UnpartitionedTrackEvent) {
 	if len(events) == 0 {
 		return
 	}
-
-	// 开始事务
-	tx, err := ts.db.Begin()
-	if err != nil {
-		log.Printf("事务启动失败: %v，尝试继续处理", err)
-		// 事务失败也尝试单条插入
-		for _, event := range events {
-			ts.insertSingleEvent(event)
+	if ts.spool != nil {
+		err := ts.spool.Append(events)
+		if err == nil {
+			ts.spooled.Add(int64(len(events)))
+			return
 		}
-		return
+		log.Printf("转存 %d 条埋点事件到落盘缓冲失败: %v", len(events), err)
 	}
+	ts.failed.Add(int64(len(events)))
+}
 
-	// 确保事务最终会被处理
-	defer func() {
-		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				log.Printf("回滚事务失败: %v", rbErr)
-			}
-		}
-	}()
+// Stats 返回队列和落盘缓冲的状态，用于监控
+func (ts *TrackingService) Stats() ServiceStats {
+	stats := ServiceStats{
+		QueuePending: ts.pending.Load(),
+		Persisted:    ts.persisted.Load(),
+		Spooled:      ts.spooled.Load(),
+		Dropped:      ts.failed.Load(),
+	}
+	if ts.spool != nil {
+		spoolStats := ts.spool.Stats()
+		stats.Spool = &spoolStats
+	}
+	return stats
+}
 
-	// 设置客户端编码为UTF8
-	_, err = tx.Exec("SET client_encoding = 'UTF8'")
-	if err != nil {
-		log.Printf("设置客户端编码失败: %v", err)
+// flushUnpartBuffer 将不分区缓冲区数据批量写入数据库，返回未能写入的事件
+func (ts *TrackingService) flushUnpartBuffer(events []*UnpartitionedTrackEvent) []*UnpartitionedTrackEvent {
+	if len(events) == 0 {
+		return nil
 	}
 
-	// 准备批量插入语句
-	stmt, err := tx.Prepare(`
-		INSERT INTO track_event 
-		(session_id, user_id, event_type, element_path, page_path, referrer, 
-		metadata, user_agent, ip_address, created_at, custom_properties, 
-		platform, device_info, event_duration, device_id, version, device_type)
-		VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8, $9, $10, $11::jsonb, 
-		$12, $13::jsonb, $14, $15, $16, $17)
-	`)
+	err := ts.insertEventsTx(events)
+	if err == nil {
+		return nil
+	}
+	log.Printf("批量写入 %d 条埋点事件失败: %v", len(events), err)
 
-	if err != nil {
-		log.Printf("准备语句失败: %v，尝试单条插入", err)
-		// 准备语句失败也尝试单条插入
-		for _, event := range events {
-			ts.insertSingleEvent(event)
-		}
-		return
+	// 数据库不可用时逐条重试也会失败，直接交给落盘缓冲
+	if ts.spool != nil && !ts.dbAvailable() {
+		return events
 	}
-	defer stmt.Close()
+	// 数据库可用说明是个别事件的数据问题，逐条插入以免拖累整批
+	return ts.insertEach(events)
+}
 
-	successCount := 0
-	failCount := 0
+// insertEventsTx 在一个事务中写入全部事件，任意一条失败时整体回滚
+func (ts *TrackingService) insertEventsTx(events []*UnpartitionedTrackEvent) error {
+	return copyEvents(ts.db, "track_event", events)
+}
 
-	// 批量插入
+// insertEach 逐条插入事件，返回未能写入的事件
+func (ts *TrackingService) insertEach(events []*UnpartitionedTrackEvent) []*UnpartitionedTrackEvent {
+	var failed []*UnpartitionedTrackEvent
 	for _, event := range events {
-		// 确保JSON字段不为空
-		if event.Metadata == "" {
-			event.Metadata = "{}"
-		}
-		if event.CustomProperties == "" {
-			event.CustomProperties = "{}"
-		}
-		if event.DeviceInfo == "" {
-			event.DeviceInfo = "{}"
-		}
-
-		// 记录设备指纹（user_id）和页面路径，用于诊断中文问题
-
-		_, execErr := stmt.Exec(
-			event.SessionID,
-			event.UserID,
-			event.EventType,
-			event.ElementPath,
-			event.PagePath,
-			event.Referrer,
-			event.Metadata,
-			event.UserAgent,
-			event.IPAddress,
-			event.CreatedAt,
-			event.CustomProperties,
-			event.Platform,
-			event.DeviceInfo,
-			event.EventDuration,
-			event.DeviceID,
-			event.Version,
-			event.DeviceType,
-		)
-
-		if execErr != nil {
-			log.Printf("插入事件失败: %v\n事件详情: type=%s, session=%s, metadata=%s",
-				execErr, event.EventType, event.SessionID, event.Metadata)
-			failCount++
-		} else {
-			successCount++
+		if !ts.insertSingleEvent(event) {
+			failed = append(failed, event)
 		}
 	}
-
-	// 提交事务
-	if err = tx.Commit(); err != nil {
-		log.Printf("提交事务失败: %v, 但部分数据可能已经写入", err)
-		return
-	}
-
+	return failed
 }
 
 // insertSingleEvent 插入单条事件，用于批处理失败时的备选方案
-func (ts *TrackingService) insertSingleEvent(event *UnpartitionedTrackEvent) {
-	// 确保JSON字段不为空
-	if event.Metadata == "" {
-		event.Metadata = "{}"
-	}
-	if event.CustomProperties == "" {
-		event.CustomProperties = "{}"
-	}
-	if event.DeviceInfo == "" {
-		event.DeviceInfo = "{}"
-	}
-
-	// 先设置连接的编码
-	_, err := ts.db.Exec("SET client_encoding = 'UTF8'")
-	if err != nil {
-		log.Printf("单条插入设置编码失败: %v", err)
-	}
-
-	// 记录中文内容
+func (ts *TrackingService) insertSingleEvent(event *UnpartitionedTrackEvent) bool {
+	fillEmptyJSON(event)
 
 	// 直接执行插入
-	_, err = ts.db.Exec(`
-		INSERT INTO track_event 
-		(session_id, user_id, event_type, element_path, page_path, referrer, 
-		metadata, user_agent, ip_address, created_at, custom_properties, 
-		platform, device_info, event_duration, device_id, version, device_type)
-		VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8, $9, $10, $11::jsonb, 
-		$12, $13::jsonb, $14, $15, $16, $17)
-	`,
-		event.SessionID,
-		event.UserID,
-		event.EventType,
-		event.ElementPath,
-		event.PagePath,
-		event.Referrer,
-		event.Metadata,
-		event.UserAgent,
-		event.IPAddress,
-		event.CreatedAt,
-		event.CustomProperties,
-		event.Platform,
-		event.DeviceInfo,
-		event.EventDuration,
-		event.DeviceID,
-		event.Version,
-		event.DeviceType,
-	)
+	_, err := ts.db.Exec(insertEventSQL("track_event"), eventValues(event)...)
 
 	if err != nil {
 		log.Printf("单条插入失败: %v\n事件详情: type=%s, session=%s",
 			err, event.EventType, event.SessionID)
-	} else {
-
+		return false
 	}
+	return true
 }
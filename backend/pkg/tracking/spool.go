@@ -0,0 +1,380 @@
+package tracking
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 落盘缓冲（spool）：数据库不可用时，写入失败的事件追加到本地分段文件中，
+// 数据库恢复后由后台协程按写入顺序重新插入，插入成功后删除对应分段。
+//
+// 分段文件名为递增的序号（如 00000000000000000001.spool），每条记录的格式为：
+//
+//	[4 字节长度][4 字节 CRC32C 校验和][JSON 编码的事件]
+//
+// 进程崩溃可能留下写了一半的记录，读取时遇到长度或校验和不正确的记录即停止读取该分段。
+// 重放是“至少一次”语义：分段插入成功但删除文件前崩溃时，该分段会被重复插入。
+
+const (
+	spoolSegmentExt     = ".spool"
+	spoolRecordHeader   = 8
+	spoolMaxRecordSize  = 1 << 20 // 单条记录上限，超过视为损坏
+	spoolReplayInterval = 10 * time.Second
+)
+
+var spoolCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrSpoolFull 落盘缓冲已达到容量上限
+var ErrSpoolFull = errors.New("埋点落盘缓冲已满")
+
+// SpoolStats 落盘缓冲的状态指标
+type SpoolStats struct {
+	Segments int   `json:"segments"` // 分段文件数
+	Bytes    int64 `json:"bytes"`    // 占用的磁盘空间
+	Events   int64 `json:"events"`   // 等待重放的事件数
+	Replayed int64 `json:"replayed"` // 累计重放成功的事件数
+	Dropped  int64 `json:"dropped"`  // 因容量上限、记录损坏或数据无效而丢弃的事件数
+}
+
+// spoolSegment 一个分段文件
+type spoolSegment struct {
+	seq    uint64
+	path   string
+	size   int64
+	events int64
+}
+
+// Spool 追加写入的本地分段文件
+type Spool struct {
+	dir         string
+	maxBytes    int64 // 全部分段的总大小上限
+	segmentSize int64 // 单个分段达到该大小后切换到新分段
+
+	mu       sync.Mutex
+	segments []*spoolSegment // 按序号升序，最后一个为当前写入的分段
+	active   *os.File
+	nextSeq  uint64
+	bytes    int64
+	events   int64
+	closed   bool
+	replayed atomic.Int64
+	dropped  atomic.Int64
+}
+
+// OpenSpool 打开落盘缓冲目录，统计已有分段中等待重放的事件
+func OpenSpool(dir string, maxBytes int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建落盘缓冲目录失败: %w", err)
+	}
+
+	segmentSize := int64(4 << 20)
+	if maxBytes/4 < segmentSize {
+		segmentSize = maxBytes / 4
+	}
+	sp := &Spool{
+		dir:         dir,
+		maxBytes:    maxBytes,
+		segmentSize: segmentSize,
+		nextSeq:     1,
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取落盘缓冲目录失败: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seg := &spoolSegment{seq: seq, path: filepath.Join(dir, name)}
+		events, err := readSegment(seg.path)
+		if err != nil {
+			log.Printf("落盘缓冲分段 %s 存在损坏的记录，只重放其中完整的 %d 条: %v", name, len(events), err)
+		}
+		if info, err := entry.Info(); err == nil {
+			seg.size = info.Size()
+		}
+		seg.events = int64(len(events))
+		sp.segments = append(sp.segments, seg)
+		sp.bytes += seg.size
+		sp.events += seg.events
+		if seq >= sp.nextSeq {
+			sp.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(sp.segments, func(i, j int) bool { return sp.segments[i].seq < sp.segments[j].seq })
+
+	if sp.events > 0 {
+		log.Printf("落盘缓冲中有 %d 条埋点事件等待重放（%d 个分段）", sp.events, len(sp.segments))
+	}
+	return sp, nil
+}
+
+// Append 将事件追加到当前分段并同步到磁盘，超过容量上限时整批拒绝
+func (sp *Spool) Append(events []*UnpartitionedTrackEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var buf []byte
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("编码埋点事件失败: %w", err)
+		}
+		var header [spoolRecordHeader]byte
+		binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+		binary.BigEndian.PutUint32(header[4:8], crc32.Checksum(payload, spoolCRCTable))
+		buf = append(buf, header[:]...)
+		buf = append(buf, payload...)
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if sp.closed {
+		sp.dropped.Add(int64(len(events)))
+		return errors.New("埋点落盘缓冲已关闭")
+	}
+	if sp.bytes+int64(len(buf)) > sp.maxBytes {
+		sp.dropped.Add(int64(len(events)))
+		return ErrSpoolFull
+	}
+
+	seg, err := sp.activeSegment()
+	if err != nil {
+		sp.dropped.Add(int64(len(events)))
+		return err
+	}
+	_, err = sp.active.Write(buf)
+	if err == nil {
+		err = sp.active.Sync()
+	}
+	if err != nil {
+		// 可能留下写了一半的记录，封存该分段，之后的写入使用新分段
+		sp.sealActive()
+		sp.dropped.Add(int64(len(events)))
+		return fmt.Errorf("写入落盘缓冲失败: %w", err)
+	}
+
+	seg.size += int64(len(buf))
+	seg.events += int64(len(events))
+	sp.bytes += int64(len(buf))
+	sp.events += int64(len(events))
+	if seg.size >= sp.segmentSize {
+		sp.sealActive()
+	}
+	return nil
+}
+
+// activeSegment 返回当前写入的分段，没有时创建新分段，调用方需持有锁
+func (sp *Spool) activeSegment() (*spoolSegment, error) {
+	if sp.active != nil {
+		return sp.segments[len(sp.segments)-1], nil
+	}
+
+	seg := &spoolSegment{
+		seq:  sp.nextSeq,
+		path: filepath.Join(sp.dir, fmt.Sprintf("%020d%s", sp.nextSeq, spoolSegmentExt)),
+	}
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("创建落盘缓冲分段失败: %w", err)
+	}
+	sp.nextSeq++
+	sp.active = f
+	sp.segments = append(sp.segments, seg)
+	return seg, nil
+}
+
+// sealActive 关闭当前写入的分段，之后的写入使用新分段，调用方需持有锁
+func (sp *Spool) sealActive() {
+	if sp.active == nil {
+		return
+	}
+	if err := sp.active.Close(); err != nil {
+		log.Printf("关闭落盘缓冲分段失败: %v", err)
+	}
+	sp.active = nil
+}
+
+// oldestSealed 返回最早的分段供重放；只剩正在写入的分段时先将其封存
+func (sp *Spool) oldestSealed() *spoolSegment {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if len(sp.segments) == 0 {
+		return nil
+	}
+	if len(sp.segments) == 1 && sp.active != nil {
+		sp.sealActive()
+	}
+	return sp.segments[0]
+}
+
+// remove 删除已重放完成的分段
+func (sp *Spool) remove(seg *spoolSegment) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("删除落盘缓冲分段失败: %v", err)
+	}
+	for i, s := range sp.segments {
+		if s == seg {
+			sp.segments = append(sp.segments[:i], sp.segments[i+1:]...)
+			break
+		}
+	}
+	sp.bytes -= seg.size
+	sp.events -= seg.events
+}
+
+// Stats 返回落盘缓冲的当前状态
+func (sp *Spool) Stats() SpoolStats {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	return SpoolStats{
+		Segments: len(sp.segments),
+		Bytes:    sp.bytes,
+		Events:   sp.events,
+		Replayed: sp.replayed.Load(),
+		Dropped:  sp.dropped.Load(),
+	}
+}
+
+// Close 关闭当前写入的分段，之后的写入会被拒绝
+func (sp *Spool) Close() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	sp.sealActive()
+	sp.closed = true
+}
+
+// readSegment 按顺序读取分段中的事件，遇到损坏的记录时返回已读取的事件和错误
+func readSegment(path string) ([]*UnpartitionedTrackEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var events []*UnpartitionedTrackEvent
+	for {
+		var header [spoolRecordHeader]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err == io.EOF {
+				return events, nil
+			}
+			return events, fmt.Errorf("记录头不完整: %w", err)
+		}
+
+		size := binary.BigEndian.Uint32(header[0:4])
+		if size == 0 || size > spoolMaxRecordSize {
+			return events, fmt.Errorf("记录长度无效: %d", size)
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return events, fmt.Errorf("记录内容不完整: %w", err)
+		}
+		if crc32.Checksum(payload, spoolCRCTable) != binary.BigEndian.Uint32(header[4:8]) {
+			return events, errors.New("记录校验和不一致")
+		}
+
+		var event UnpartitionedTrackEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return events, fmt.Errorf("解码记录失败: %w", err)
+		}
+		events = append(events, &event)
+	}
+}
+
+// spoolReplayLoop 定期检查数据库是否恢复，恢复后按顺序重放落盘缓冲中的事件
+func (ts *TrackingService) spoolReplayLoop() {
+	defer close(ts.replayStopped)
+
+	ticker := time.NewTicker(spoolReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ts.replayStop:
+			return
+		case <-ticker.C:
+			ts.replaySpool()
+		}
+	}
+}
+
+// replaySpool 从最早的分段开始重放，数据库不可用时停止，等待下一轮
+func (ts *TrackingService) replaySpool() {
+	for {
+		select {
+		case <-ts.replayStop:
+			return
+		default:
+		}
+
+		seg := ts.spool.oldestSealed()
+		if seg == nil {
+			return
+		}
+		if !ts.dbAvailable() {
+			return
+		}
+
+		events, readErr := readSegment(seg.path)
+		if readErr != nil {
+			log.Printf("落盘缓冲分段 %s 存在损坏的记录，丢弃之后的内容: %v", filepath.Base(seg.path), readErr)
+			ts.spool.dropped.Add(seg.events - int64(len(events)))
+		}
+
+		if err := ts.insertEventsTx(events); err != nil {
+			// 数据库仍然可用说明是个别事件的数据问题，逐条插入并丢弃无法写入的事件
+			if !ts.dbAvailable() {
+				log.Printf("重放落盘缓冲失败，等待数据库恢复: %v", err)
+				return
+			}
+			log.Printf("批量重放落盘缓冲失败，改为逐条插入: %v", err)
+			failed := ts.insertEach(events)
+			ts.spool.dropped.Add(int64(len(failed)))
+			ts.spool.replayed.Add(int64(len(events) - len(failed)))
+		} else {
+			ts.spool.replayed.Add(int64(len(events)))
+		}
+
+		ts.spool.remove(seg)
+		stats := ts.spool.Stats()
+		log.Printf("已重放落盘缓冲分段 %s（%d 条事件），剩余 %d 条", filepath.Base(seg.path), len(events), stats.Events)
+	}
+}
+
+// dbAvailable 检查数据库当前是否可以连接
+func (ts *TrackingService) dbAvailable() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return ts.db.PingContext(ctx) == nil
+}
@@ -0,0 +1,161 @@
+package tracking
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 无 Cookie 模式（Options.Cookieless）：不使用前端保存的设备指纹和会话 ID，
+// 访客标识为 HMAC-SHA256(当日盐, 站点 + IP + User-Agent)，同一访客当天内标识不变，
+// 写入 session_id 和 user_id，PV/UV 等统计无需改动；IP 地址只用于计算标识、识别机器人和补充地理位置，不写入数据库。
+//
+// 盐每天（Asia/Shanghai 零点）轮换一次，保存在 tracking_visitor_salt 中供多个实例共用，轮换时删除旧盐，
+// 之后即使拿到 IP 和 User-Agent 也无法算出之前的访客标识。代价是跨天的同一访客会被计为不同访客。
+
+const (
+	visitorSaltSize   = 32
+	visitorIDSize     = 16 // 标识取 HMAC 的前 16 字节，32 个十六进制字符
+	visitorSaltPeriod = time.Minute
+)
+
+// visitorSalts 当日的盐，跨过零点后首次使用时轮换
+type visitorSalts struct {
+	db *sql.DB
+
+	mu   sync.Mutex
+	day  string
+	salt []byte
+	// memoryOnly 为 true 表示当前的盐是数据库不可用时生成的，saltLoop 会继续重试
+	memoryOnly bool
+}
+
+// current 返回 now 所在日期的盐。调用方在锁外使用返回的切片，轮换时只替换 v.salt，不能原地清零，
+// 否则并发计算的标识会用全零的盐。
+// 数据库不可用时暂时改用只保存在内存中的盐，此时各实例算出的标识不同，同一访客可能被重复计数
+func (v *visitorSalts) current(now time.Time) []byte {
+	day := now.In(chinaLocation).Format(dateLayout)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.day != day {
+		v.load(day)
+	}
+	return v.salt
+}
+
+// refresh 供 saltLoop 定时调用：跨过零点后轮换，当天的盐只在内存中时重新从数据库获取
+func (v *visitorSalts) refresh(now time.Time) {
+	day := now.In(chinaLocation).Format(dateLayout)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.day != day || v.memoryOnly {
+		v.load(day)
+	}
+}
+
+// load 从数据库取 day 的盐，调用方持有 v.mu。
+// 失败时改用内存中的盐，同一天内重试失败继续沿用之前生成的内存盐，避免访客标识反复变化
+func (v *visitorSalts) load(day string) {
+	salt, err := v.rotate(day)
+	if err != nil {
+		log.Printf("获取访客标识盐失败，暂用仅保存在内存中的盐，稍后重试: %v", err)
+		if v.day == day && v.memoryOnly {
+			return
+		}
+		salt = randomSalt()
+	} else if v.memoryOnly && v.day == day {
+		log.Printf("已从数据库取得访客标识盐，今天此前的访客标识与之后的不一致")
+	}
+	v.day, v.salt, v.memoryOnly = day, salt, err != nil
+}
+
+// rotate 取 day 的盐，不存在时生成，并删除之前日期的盐。
+// 多个实例同时轮换时以先写入的为准
+func (v *visitorSalts) rotate(day string) ([]byte, error) {
+	tx, err := v.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO tracking_visitor_salt (day, salt) VALUES ($1, $2)
+		ON CONFLICT (day) DO NOTHING`, day, randomSalt()); err != nil {
+		return nil, err
+	}
+	var salt []byte
+	if err := tx.QueryRow(`SELECT salt FROM tracking_visitor_salt WHERE day = $1`, day).Scan(&salt); err != nil {
+		return nil, err
+	}
+	// 只删除更早的日期：时钟稍慢的实例仍在轮换前一天时不会删掉当天的盐
+	if _, err := tx.Exec(`DELETE FROM tracking_visitor_salt WHERE day < $1`, day); err != nil {
+		return nil, err
+	}
+	return salt, tx.Commit()
+}
+
+func randomSalt() []byte {
+	salt := make([]byte, visitorSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+	return salt
+}
+
+// visitorID 计算访客标识，site 区分同一数据库中的不同站点
+func visitorID(salt []byte, site, ip, userAgent string) string {
+	mac := hmac.New(sha256.New, salt)
+	for _, field := range []string{site, ip, userAgent} {
+		mac.Write([]byte(field))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil)[:visitorIDSize])
+}
+
+// visitorSite 返回请求的站点（Host 去掉端口，转为小写）
+func visitorSite(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// anonymize 无 Cookie 模式下用访客标识代替会话 ID 和用户 ID，并丢弃 IP 地址。
+// 补充地理位置和识别机器人都需要 IP，必须在 detectBot 之后调用
+func (ts *TrackingService) anonymize(event *UnpartitionedTrackEvent, host string) {
+	if ts.salts == nil {
+		return
+	}
+	ts.enrichLocation(event)
+
+	id := visitorID(ts.salts.current(time.Now()), visitorSite(host), event.IPAddress, event.UserAgent)
+	event.SessionID, event.UserID = id, id
+	event.IPAddress = ""
+}
+
+// saltLoop 每分钟检查一次日期，跨过零点后及时轮换并删除旧盐，数据库不可用时按同样的间隔重试，服务关闭时退出
+func (ts *TrackingService) saltLoop() {
+	defer close(ts.saltStopped)
+
+	ticker := time.NewTicker(visitorSaltPeriod)
+	defer ticker.Stop()
+
+	for {
+		ts.salts.refresh(time.Now())
+
+		select {
+		case <-ts.saltStop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package tracking
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// saltDriver 模拟 tracking_visitor_salt 表，down 为 true 时所有操作失败
+type saltDriver struct {
+	down  atomic.Bool
+	mu    sync.Mutex
+	salts map[string][]byte
+}
+
+func (d *saltDriver) Open(string) (driver.Conn, error) { return saltConn{d}, nil }
+
+type saltConn struct{ d *saltDriver }
+
+func (c saltConn) Prepare(query string) (driver.Stmt, error) { return saltStmt{c.d, query}, nil }
+func (c saltConn) Close() error                              { return nil }
+
+func (c saltConn) Begin() (driver.Tx, error) {
+	if c.d.down.Load() {
+		return nil, errors.New("saltdriver: 数据库不可用")
+	}
+	return saltTx{}, nil
+}
+
+type saltTx struct{}
+
+func (saltTx) Commit() error   { return nil }
+func (saltTx) Rollback() error { return nil }
+
+type saltStmt struct {
+	d     *saltDriver
+	query string
+}
+
+func (s saltStmt) Close() error  { return nil }
+func (s saltStmt) NumInput() int { return -1 }
+
+func (s saltStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	day := args[0].(string)
+	switch {
+	case strings.Contains(s.query, "INSERT"):
+		if _, ok := s.d.salts[day]; !ok {
+			s.d.salts[day] = args[1].([]byte)
+		}
+	case strings.Contains(s.query, "DELETE"):
+		for d := range s.d.salts {
+			if d < day {
+				delete(s.d.salts, d)
+			}
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s saltStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	return &saltRows{salt: s.d.salts[args[0].(string)]}, nil
+}
+
+type saltRows struct{ salt []byte }
+
+func (r *saltRows) Columns() []string { return []string{"salt"} }
+func (r *saltRows) Close() error      { return nil }
+
+func (r *saltRows) Next(dest []driver.Value) error {
+	if r.salt == nil {
+		return io.EOF
+	}
+	dest[0], r.salt = r.salt, nil
+	return nil
+}
+
+var saltDrivers atomic.Int64
+
+func newSaltDB(t *testing.T) (*sql.DB, *saltDriver) {
+	t.Helper()
+	d := &saltDriver{salts: map[string][]byte{}}
+	name := fmt.Sprintf("saltdriver%d", saltDrivers.Add(1))
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func TestVisitorSaltRetriesAfterDatabaseFailure(t *testing.T) {
+	db, d := newSaltDB(t)
+	v := &visitorSalts{db: db}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, chinaLocation)
+
+	d.down.Store(true)
+	memory := bytes.Clone(v.current(now))
+	if len(memory) != visitorSaltSize {
+		t.Fatalf("数据库不可用时应使用内存中的盐，得到 %d 字节", len(memory))
+	}
+	// 同一天内事件不触发重试，saltLoop 重试失败时沿用同一个内存盐
+	v.refresh(now.Add(time.Minute))
+	if !bytes.Equal(v.current(now.Add(2*time.Minute)), memory) {
+		t.Fatal("重试失败后内存中的盐不应变化")
+	}
+
+	d.down.Store(false)
+	v.refresh(now.Add(3 * time.Minute))
+	got := v.current(now.Add(4 * time.Minute))
+	if !bytes.Equal(got, d.salts["2026-10-17"]) {
+		t.Fatal("数据库恢复后 saltLoop 应改用数据库中的盐")
+	}
+	if bytes.Equal(got, memory) {
+		t.Fatal("数据库中的盐不应与内存中的盐相同")
+	}
+}
+
+func TestVisitorSaltRotationKeepsHandedOutSalt(t *testing.T) {
+	db, _ := newSaltDB(t)
+	v := &visitorSalts{db: db}
+	day1 := time.Date(2026, 10, 17, 23, 59, 0, 0, chinaLocation)
+
+	held := v.current(day1)
+	want := bytes.Clone(held)
+	next := v.current(day1.Add(2 * time.Minute))
+
+	if !bytes.Equal(held, want) {
+		t.Fatal("轮换时不能原地清零调用方仍在使用的盐")
+	}
+	if bytes.Equal(next, held) {
+		t.Fatal("跨过零点后应使用新的盐")
+	}
+	if visitorID(held, "example.com", "1.2.3.4", "ua") == visitorID(make([]byte, visitorSaltSize), "example.com", "1.2.3.4", "ua") {
+		t.Fatal("访客标识不应使用全零的盐")
+	}
+}
+
+// TestVisitorSaltConcurrentRotation 在 -race 下检查跨零点轮换与并发计算标识没有数据竞争
+func TestVisitorSaltConcurrentRotation(t *testing.T) {
+	db, _ := newSaltDB(t)
+	v := &visitorSalts{db: db}
+	start := time.Date(2026, 10, 17, 23, 59, 0, 0, chinaLocation)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				now := start.Add(time.Duration(i*200+j) * time.Second)
+				visitorID(v.current(now), "example.com", "1.2.3.4", "ua")
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,81 @@
+package useragent
+
+// 浏览器识别规则。按列表顺序匹配，App 内置浏览器和国产浏览器的 User-Agent 中通常也带有 Chrome、Safari 等字样，
+// 必须排在通用浏览器之前。token 为 User-Agent 中的小写子串，版本号取 token 之后的数字；
+// 设置了 version 时优先取 version 之后的数字（如 Safari 的版本在 Version/ 之后）；设置了 require 时 User-Agent 还必须包含该子串。
+
+// browserRule 一种浏览器的识别规则
+type browserRule struct {
+	name    string
+	tokens  []string
+	version string
+	require string
+}
+
+var browserRules = []browserRule{
+	// App 内置浏览器
+	{name: "WeCom", tokens: []string{"wxwork/"}},
+	{name: "WeChat", tokens: []string{"micromessenger/"}},
+	{name: "QQ", tokens: []string{" qq/"}},
+	{name: "DingTalk", tokens: []string{"dingtalk/", "dingtalk("}},
+	{name: "Feishu", tokens: []string{"lark/", "feishu/"}},
+	{name: "Alipay", tokens: []string{"alipayclient/"}},
+	{name: "Weibo", tokens: []string{"__weibo__"}},
+	{name: "Douyin", tokens: []string{"aweme_", "aweme/"}, version: "app_version/"},
+	{name: "Toutiao", tokens: []string{"newsarticle/"}},
+	{name: "Xiaohongshu", tokens: []string{"xhsdiscover/"}},
+	{name: "Baidu App", tokens: []string{"baiduboxapp/"}},
+
+	// 国产浏览器和手机厂商浏览器
+	{name: "Quark", tokens: []string{"quark/"}},
+	{name: "UC Browser", tokens: []string{"ucbrowser/", "ucweb/"}},
+	{name: "QQ Browser", tokens: []string{"mqqbrowser/", "qqbrowser/"}},
+	{name: "Sogou Browser", tokens: []string{"sogoumobilebrowser/", "metasr"}},
+	{name: "360 Browser", tokens: []string{"qihoobrowser/", "qhbrowser/", "360se", "360ee"}},
+	{name: "Baidu Browser", tokens: []string{"baidubrowser/", "bidubrowser/", "bdbrowser/"}},
+	{name: "2345 Browser", tokens: []string{"2345explorer/", "mb2345browser/"}},
+	{name: "Liebao", tokens: []string{"lbbrowser"}},
+	{name: "Maxthon", tokens: []string{"maxthon/", "mxbrowser/"}},
+	{name: "Huawei Browser", tokens: []string{"huaweibrowser/"}},
+	{name: "MIUI Browser", tokens: []string{"miuibrowser/"}},
+	{name: "Vivo Browser", tokens: []string{"vivobrowser/"}},
+	{name: "OPPO Browser", tokens: []string{"heytapbrowser/", "oppobrowser/"}},
+	{name: "Samsung Internet", tokens: []string{"samsungbrowser/"}},
+
+	// 通用浏览器
+	{name: "Yandex", tokens: []string{"yabrowser/"}},
+	{name: "Vivaldi", tokens: []string{"vivaldi/"}},
+	{name: "Opera", tokens: []string{"opr/", "opt/", "opios/", "opera mini/"}},
+	{name: "Opera", tokens: []string{"opera/", "opera "}, version: "version/"},
+	{name: "Edge", tokens: []string{"edg/", "edga/", "edgios/", "edge/"}},
+	{name: "Firefox", tokens: []string{"firefox/", "fxios/"}},
+	{name: "Electron", tokens: []string{"electron/"}},
+	{name: "Headless Chrome", tokens: []string{"headlesschrome/"}},
+	{name: "Android WebView", tokens: []string{"; wv)"}, version: "chrome/"},
+	{name: "Chrome", tokens: []string{"crios/", "chrome/", "chromium/"}},
+	{name: "IE", tokens: []string{"msie "}},
+	{name: "IE", tokens: []string{"trident/"}, version: "rv:"},
+	{name: "Android Browser", tokens: []string{"android"}, version: "version/", require: "version/"},
+	{name: "Safari", tokens: []string{"safari/"}, version: "version/"},
+}
+
+// windowsVersions Windows NT 内核版本对应的系统版本。Windows 11 的 User-Agent 与 Windows 10 相同，都是 NT 10.0
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.2":  "XP",
+	"5.1":  "XP",
+	"5.0":  "2000",
+}
+
+// Android User-Agent 括号中不是设备型号的字段（小写）。Chrome 精简后的 User-Agent 用 K 代替型号
+var (
+	ignoredModelTokens = map[string]bool{
+		"linux": true, "u": true, "wv": true, "k": true, "mobile": true, "tablet": true, "phone": true,
+		"pc": true, "arm": true, "touch": true, "x11": true,
+	}
+	ignoredModelPrefixes = []string{"android", "harmonyos", "openharmony", "hmscore", "opera mini/", "rv:", "build/"}
+)
@@ -0,0 +1,254 @@
+package useragent
+
+import (
+	"strings"
+)
+
+// 操作系统名称
+const (
+	OSWindows      = "Windows"
+	OSWindowsPhone = "Windows Phone"
+	OSMacOS        = "macOS"
+	OSIOS          = "iOS"
+	OSAndroid      = "Android"
+	OSHarmonyOS    = "HarmonyOS"
+	OSChromeOS     = "ChromeOS"
+	OSLinux        = "Linux"
+)
+
+// Info User-Agent 的解析结果，识别不出的字段为空
+type Info struct {
+	Browser        string // 浏览器或 App 名称，如 Chrome、WeChat
+	BrowserVersion string
+	OS             string // 操作系统，取值见 OS* 常量
+	OSVersion      string
+	DeviceModel    string // 设备型号，如 iPhone、SM-S9180
+	Engine         string // 渲染引擎：Blink、WebKit、Gecko、Trident、EdgeHTML、Presto、ArkWeb
+}
+
+// Parse 解析 User-Agent。
+// iOS 上的 Chrome、Firefox 等浏览器都使用 WebKit 内核；Safari 在 iPad 上默认请求桌面版网页，会被识别为 macOS
+func Parse(ua string) Info {
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return Info{}
+	}
+	// 只转换 ASCII 字母，保证与原字符串的下标一致
+	lower := asciiLower(ua)
+
+	var info Info
+	info.OS, info.OSVersion = parseOS(ua, lower)
+	info.Browser, info.BrowserVersion = parseBrowser(ua, lower)
+	info.DeviceModel = parseDeviceModel(ua, lower, info.OS)
+	info.Engine = parseEngine(lower, info.OS)
+	return info
+}
+
+func parseBrowser(ua, lower string) (string, string) {
+	for _, rule := range browserRules {
+		if rule.require != "" && !strings.Contains(lower, rule.require) {
+			continue
+		}
+		for _, token := range rule.tokens {
+			i := strings.Index(lower, token)
+			if i < 0 {
+				continue
+			}
+			version := ""
+			if rule.version != "" {
+				version = versionAfter(ua, lower, rule.version)
+			}
+			if version == "" {
+				version = readVersion(ua, i+len(token))
+			}
+			return rule.name, version
+		}
+	}
+	return "", ""
+}
+
+func parseOS(ua, lower string) (string, string) {
+	switch {
+	case strings.Contains(lower, "windows phone"):
+		v := versionAfter(ua, lower, "windows phone os ")
+		if v == "" {
+			v = versionAfter(ua, lower, "windows phone ")
+		}
+		return OSWindowsPhone, v
+	case strings.Contains(lower, "windows nt "):
+		v := versionAfter(ua, lower, "windows nt ")
+		if name, ok := windowsVersions[v]; ok {
+			v = name
+		}
+		return OSWindows, v
+	case strings.Contains(lower, "windows"):
+		return OSWindows, ""
+	case strings.Contains(lower, "openharmony"):
+		return OSHarmonyOS, versionAfter(ua, lower, "openharmony ")
+	case strings.Contains(lower, "harmonyos"):
+		// 鸿蒙 2-4 兼容 Android 应用，User-Agent 中同时带有 Android 版本
+		return OSHarmonyOS, versionAfter(ua, lower, "harmonyos ")
+	case strings.Contains(lower, "iphone") || strings.Contains(lower, "ipad") || strings.Contains(lower, "ipod"):
+		v := versionAfter(ua, lower, "iphone os ")
+		if v == "" {
+			v = versionAfter(ua, lower, "cpu os ")
+		}
+		return OSIOS, v
+	case strings.Contains(lower, "android"):
+		v := versionAfter(ua, lower, "android ")
+		if v == "" {
+			v = versionAfter(ua, lower, "android/")
+		}
+		return OSAndroid, v
+	case strings.Contains(lower, "cros "):
+		// CrOS x86_64 14541.0.0，版本在 CPU 架构之后
+		rest := ua[strings.Index(lower, "cros ")+len("cros "):]
+		if i := strings.IndexByte(rest, ' '); i >= 0 {
+			return OSChromeOS, readVersion(rest, i+1)
+		}
+		return OSChromeOS, ""
+	case strings.Contains(lower, "mac os x") || strings.Contains(lower, "macintosh"):
+		return OSMacOS, versionAfter(ua, lower, "mac os x ")
+	case strings.Contains(lower, "linux") || strings.Contains(lower, "x11"):
+		return OSLinux, ""
+	}
+	return "", ""
+}
+
+// parseDeviceModel 返回设备型号。Android 和鸿蒙设备的型号在第一对括号中系统版本之后，如
+// (Linux; Android 13; SM-S9180 Build/TP1A.220624.014; wv)。
+// iPod touch 的 User-Agent 中也带有 iPhone OS，需先于 iPhone 判断
+func parseDeviceModel(ua, lower, os string) string {
+	switch {
+	case os == OSIOS && strings.Contains(lower, "ipad"):
+		return "iPad"
+	case os == OSIOS && strings.Contains(lower, "ipod"):
+		return "iPod"
+	case os == OSIOS && strings.Contains(lower, "iphone"):
+		return "iPhone"
+	case os == OSMacOS:
+		return "Mac"
+	case os != OSAndroid && os != OSHarmonyOS:
+		return ""
+	}
+
+	fields := firstParenthesized(ua)
+	for _, field := range strings.Split(fields, ";") {
+		field = strings.TrimSpace(field)
+		if i := strings.Index(asciiLower(field), "build/"); i > 0 {
+			field = strings.TrimSpace(field[:i])
+		}
+		// 三星浏览器在型号前加上厂商名，如 SAMSUNG SM-S918B
+		if len(field) > len(samsungPrefix) && asciiLower(field[:len(samsungPrefix)]) == samsungPrefix {
+			field = field[len(samsungPrefix):]
+		}
+		if field == "" || isIgnoredModelField(asciiLower(field)) {
+			continue
+		}
+		return field
+	}
+	return ""
+}
+
+const samsungPrefix = "samsung "
+
+// firstParenthesized 返回第一对括号中的内容，型号本身可能带括号，如 Moto G (5)
+func firstParenthesized(ua string) string {
+	start := strings.IndexByte(ua, '(')
+	if start < 0 {
+		return ""
+	}
+	depth := 0
+	for i := start; i < len(ua); i++ {
+		switch ua[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return ua[start+1 : i]
+			}
+		}
+	}
+	return ""
+}
+
+func isIgnoredModelField(field string) bool {
+	if ignoredModelTokens[field] || isLocale(field) {
+		return true
+	}
+	for _, prefix := range ignoredModelPrefixes {
+		if strings.HasPrefix(field, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocale 判断是否为 zh-cn、en_us 之类的语言标记
+func isLocale(field string) bool {
+	if len(field) == 2 {
+		return isLetters(field)
+	}
+	return len(field) == 5 && (field[2] == '-' || field[2] == '_') && isLetters(field[:2]) && isLetters(field[3:])
+}
+
+func isLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'a' || s[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func parseEngine(lower, os string) string {
+	switch {
+	case strings.Contains(lower, "arkweb/"):
+		return "ArkWeb"
+	case strings.Contains(lower, "trident/") || strings.Contains(lower, "msie "):
+		return "Trident"
+	case strings.Contains(lower, "edge/"):
+		return "EdgeHTML"
+	case strings.Contains(lower, "presto/"):
+		return "Presto"
+	case os == OSIOS && strings.Contains(lower, "applewebkit/"):
+		return "WebKit"
+	case strings.Contains(lower, "chrome/") || strings.Contains(lower, "chromium/"):
+		return "Blink"
+	case strings.Contains(lower, "applewebkit/"):
+		return "WebKit"
+	case strings.Contains(lower, "gecko/"):
+		return "Gecko"
+	}
+	return ""
+}
+
+// versionAfter 返回 token 之后的版本号
+func versionAfter(ua, lower, token string) string {
+	i := strings.Index(lower, token)
+	if i < 0 {
+		return ""
+	}
+	return readVersion(ua, i+len(token))
+}
+
+// readVersion 读取 ua[i:] 开头的版本号，下划线分隔的版本（如 iOS 的 17_2_1）转换为点分隔
+func readVersion(ua string, i int) string {
+	j := i
+	for j < len(ua) && (ua[j] >= '0' && ua[j] <= '9' || ua[j] == '.' || ua[j] == '_') {
+		j++
+	}
+	v := strings.Trim(ua[i:j], "._")
+	return strings.ReplaceAll(v, "_", ".")
+}
+
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
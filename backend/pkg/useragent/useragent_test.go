@@ -0,0 +1,598 @@
+package useragent
+
+import "testing"
+
+// uaCorpus 实际采集到的 User-Agent 及期望的解析结果，字段依次为浏览器、浏览器版本、系统、系统版本、设备型号、内核
+var uaCorpus = []struct {
+	ua   string
+	want Info
+}{
+	// Windows 通用浏览器
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		Info{"Chrome", "120.0.0.0", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+		Info{"Chrome", "119.0.0.0", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36",
+		Info{"Chrome", "109.0.0.0", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36",
+		Info{"Chrome", "109.0.0.0", OSWindows, "7", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 6.3; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.5414.120 Safari/537.36",
+		Info{"Chrome", "109.0.5414.120", OSWindows, "8.1", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 6.2; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/49.0.2623.112 Safari/537.36",
+		Info{"Chrome", "49.0.2623.112", OSWindows, "8", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 5.1) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/49.0.2623.112 Safari/537.36",
+		Info{"Chrome", "49.0.2623.112", OSWindows, "XP", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 6.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/49.0.2623.112 Safari/537.36",
+		Info{"Chrome", "49.0.2623.112", OSWindows, "Vista", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+		Info{"Edge", "120.0.0.0", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36 Edg/119.0.2151.97",
+		Info{"Edge", "119.0.2151.97", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36 Edg/109.0.1518.140",
+		Info{"Edge", "109.0.1518.140", OSWindows, "7", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/70.0.3538.102 Safari/537.36 Edge/18.19041",
+		Info{"Edge", "18.19041", OSWindows, "10", "", "EdgeHTML"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/64.0.3282.140 Safari/537.36 Edge/17.17134",
+		Info{"Edge", "17.17134", OSWindows, "10", "", "EdgeHTML"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0",
+		Info{"Firefox", "121.0", OSWindows, "10", "", "Gecko"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:120.0) Gecko/20100101 Firefox/120.0",
+		Info{"Firefox", "120.0", OSWindows, "10", "", "Gecko"}},
+	{"Mozilla/5.0 (Windows NT 10.0; rv:115.0) Gecko/20100101 Firefox/115.0",
+		Info{"Firefox", "115.0", OSWindows, "10", "", "Gecko"}},
+	{"Mozilla/5.0 (Windows NT 6.1; Win64; x64; rv:115.0) Gecko/20100101 Firefox/115.0",
+		Info{"Firefox", "115.0", OSWindows, "7", "", "Gecko"}},
+	{"Mozilla/5.0 (Windows NT 5.1; rv:52.0) Gecko/20100101 Firefox/52.0",
+		Info{"Firefox", "52.0", OSWindows, "XP", "", "Gecko"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 OPR/106.0.0.0",
+		Info{"Opera", "106.0.0.0", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/95.0.4638.69 Safari/537.36 OPR/81.0.4196.60",
+		Info{"Opera", "81.0.4196.60", OSWindows, "10", "", "Blink"}},
+	{"Opera/9.80 (Windows NT 6.1; WOW64) Presto/2.12.388 Version/12.18",
+		Info{"Opera", "12.18", OSWindows, "7", "", "Presto"}},
+	{"Opera/9.80 (Windows NT 5.1; U; zh-cn) Presto/2.10.289 Version/12.02",
+		Info{"Opera", "12.02", OSWindows, "XP", "", "Presto"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 YaBrowser/23.11.0.0 Safari/537.36",
+		Info{"Yandex", "23.11.0.0", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Vivaldi/6.5.3206.48",
+		Info{"Vivaldi", "6.5.3206.48", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Code/1.85.1 Chrome/114.0.5735.289 Electron/25.9.7 Safari/537.36",
+		Info{"Electron", "25.9.7", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Brave/120",
+		Info{"Chrome", "120.0.0.0", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chromium/120.0.6099.109 Safari/537.36",
+		Info{"Chrome", "120.0.6099.109", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.6099.28 Safari/537.36",
+		Info{"Headless Chrome", "120.0.6099.28", OSWindows, "10", "", "Blink"}},
+
+	// Internet Explorer
+	{"Mozilla/5.0 (Windows NT 10.0; WOW64; Trident/7.0; rv:11.0) like Gecko",
+		Info{"IE", "11.0", OSWindows, "10", "", "Trident"}},
+	{"Mozilla/5.0 (Windows NT 6.1; WOW64; Trident/7.0; rv:11.0) like Gecko",
+		Info{"IE", "11.0", OSWindows, "7", "", "Trident"}},
+	{"Mozilla/5.0 (Windows NT 6.3; Trident/7.0; rv:11.0) like Gecko",
+		Info{"IE", "11.0", OSWindows, "8.1", "", "Trident"}},
+	{"Mozilla/5.0 (Windows NT 6.1; WOW64; Trident/7.0; SLCC2; .NET CLR 2.0.50727; .NET CLR 3.5.30729; .NET CLR 3.0.30729; Media Center PC 6.0; .NET4.0C; .NET4.0E; rv:11.0) like Gecko",
+		Info{"IE", "11.0", OSWindows, "7", "", "Trident"}},
+	{"Mozilla/5.0 (compatible; MSIE 10.0; Windows NT 6.2; Trident/6.0)",
+		Info{"IE", "10.0", OSWindows, "8", "", "Trident"}},
+	{"Mozilla/5.0 (compatible; MSIE 10.0; Windows NT 6.1; WOW64; Trident/6.0)",
+		Info{"IE", "10.0", OSWindows, "7", "", "Trident"}},
+	{"Mozilla/5.0 (compatible; MSIE 9.0; Windows NT 6.1; Trident/5.0)",
+		Info{"IE", "9.0", OSWindows, "7", "", "Trident"}},
+	{"Mozilla/5.0 (compatible; MSIE 9.0; Windows NT 6.0; Trident/5.0)",
+		Info{"IE", "9.0", OSWindows, "Vista", "", "Trident"}},
+	{"Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 6.1; WOW64; Trident/4.0; SLCC2; .NET CLR 2.0.50727)",
+		Info{"IE", "8.0", OSWindows, "7", "", "Trident"}},
+	{"Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 5.1; Trident/4.0)",
+		Info{"IE", "8.0", OSWindows, "XP", "", "Trident"}},
+	{"Mozilla/4.0 (compatible; MSIE 7.0; Windows NT 5.1; .NET CLR 2.0.50727)",
+		Info{"IE", "7.0", OSWindows, "XP", "", "Trident"}},
+	{"Mozilla/4.0 (compatible; MSIE 6.0; Windows NT 5.1; SV1)",
+		Info{"IE", "6.0", OSWindows, "XP", "", "Trident"}},
+	{"Mozilla/4.0 (compatible; MSIE 6.0; Windows NT 5.0)",
+		Info{"IE", "6.0", OSWindows, "2000", "", "Trident"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; Trident/7.0; .NET4.0C; .NET4.0E; Tablet PC 2.0; rv:11.0) like Gecko",
+		Info{"IE", "11.0", OSWindows, "10", "", "Trident"}},
+
+	// Windows 国产浏览器和客户端内置浏览器
+	{"Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/108.0.0.0 Safari/537.36 QIHU 360SE",
+		Info{"360 Browser", "", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.198 Safari/537.36 QIHU 360EE",
+		Info{"360 Browser", "", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/63.0.3239.132 Safari/537.36 QIHU 360SE",
+		Info{"360 Browser", "", OSWindows, "7", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.198 Safari/537.36 QHBrowser/13.1.6",
+		Info{"360 Browser", "13.1.6", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/94.0.4606.71 Safari/537.36 Core/1.94.218.400 QQBrowser/12.1.5498.400",
+		Info{"QQ Browser", "12.1.5498.400", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/70.0.3538.25 Safari/537.36 Core/1.70.3877.400 QQBrowser/10.8.4506.400",
+		Info{"QQ Browser", "10.8.4506.400", OSWindows, "7", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.198 Safari/537.36 SE 2.X MetaSr 1.0",
+		Info{"Sogou Browser", "", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/58.0.3029.81 Safari/537.36 SE 2.X MetaSr 1.0",
+		Info{"Sogou Browser", "", OSWindows, "7", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/63.0.3239.132 Safari/537.36 LBBROWSER",
+		Info{"Liebao", "", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.198 Safari/537.36 2345Explorer/10.30.0.21940",
+		Info{"2345 Browser", "10.30.0.21940", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Maxthon/5.3.8.2000 Chrome/61.0.3163.79 Safari/537.36",
+		Info{"Maxthon", "5.3.8.2000", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/80.0.3987.87 Safari/537.36 BIDUBrowser/8.7",
+		Info{"Baidu Browser", "8.7", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/81.0.4044.138 Safari/537.36 NetType/WIFI MicroMessenger/7.0.20.1781(0x6700143B) WindowsWechat(0x6309092b) XWEB/9129 Flue",
+		Info{"WeChat", "7.0.20.1781", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/81.0.4044.138 Safari/537.36 NetType/WIFI MicroMessenger/7.0.20.1781(0x6700143B) WindowsWechat(0x63090819) XWEB/8461 Flue",
+		Info{"WeChat", "7.0.20.1781", OSWindows, "7", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/107.0.0.0 Safari/537.36 wxwork/4.1.16 (MicroMessenger/6.2) WindowsWechat MailPlugin_Electron WeMail embeddisk",
+		Info{"WeCom", "4.1.16", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.93 Safari/537.36 DingTalk(7.0.40-Release.2229111) nw Channel/201200",
+		Info{"DingTalk", "7.0.40", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Lark/7.6.10 Chrome/108.0.5359.215 Electron/22.3.27 Safari/537.36 LarkLocale/zh_CN ChannelName/Feishu",
+		Info{"Feishu", "7.6.10", OSWindows, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/98.0.4758.102 Safari/537.36 QQ/9.7.21.29261",
+		Info{"QQ", "9.7.21.29261", OSWindows, "10", "", "Blink"}},
+
+	// macOS
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		Info{"Chrome", "120.0.0.0", OSMacOS, "10.15.7", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+		Info{"Chrome", "119.0.0.0", OSMacOS, "10.15.7", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_13_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Safari/537.36",
+		Info{"Chrome", "116.0.0.0", OSMacOS, "10.13.6", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_11_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/103.0.5060.134 Safari/537.36",
+		Info{"Chrome", "103.0.5060.134", OSMacOS, "10.11.6", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2.1 Safari/605.1.15",
+		Info{"Safari", "17.2.1", OSMacOS, "10.15.7", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+		Info{"Safari", "17.1", OSMacOS, "10.15.7", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Safari/605.1.15",
+		Info{"Safari", "16.6", OSMacOS, "10.15.7", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_6) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1.2 Safari/605.1.15",
+		Info{"Safari", "14.1.2", OSMacOS, "10.14.6", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_13_6) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.1.2 Safari/605.1.15",
+		Info{"Safari", "13.1.2", OSMacOS, "10.13.6", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_12_6) AppleWebKit/603.3.8 (KHTML, like Gecko) Version/10.1.2 Safari/603.3.8",
+		Info{"Safari", "10.1.2", OSMacOS, "10.12.6", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_9_5) AppleWebKit/600.8.9 (KHTML, like Gecko) Version/7.1.8 Safari/537.85.17",
+		Info{"Safari", "7.1.8", OSMacOS, "10.9.5", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:121.0) Gecko/20100101 Firefox/121.0",
+		Info{"Firefox", "121.0", OSMacOS, "10.15", "Mac", "Gecko"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:109.0) Gecko/20100101 Firefox/115.0",
+		Info{"Firefox", "115.0", OSMacOS, "10.15", "Mac", "Gecko"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+		Info{"Edge", "120.0.0.0", OSMacOS, "10.15.7", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 OPR/106.0.0.0",
+		Info{"Opera", "106.0.0.0", OSMacOS, "10.15.7", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 YaBrowser/23.11.1.804 Yowser/2.5 Safari/537.36",
+		Info{"Yandex", "23.11.1.804", OSMacOS, "10.15.7", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Vivaldi/6.5.3206.53",
+		Info{"Vivaldi", "6.5.3206.53", OSMacOS, "10.15.7", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Safari/537.36 Core/1.116.462.400 QQBrowser/12.4.5.204",
+		Info{"QQ Browser", "12.4.5.204", OSMacOS, "10.15.7", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) MicroMessenger/6.8.0(0x16080000) MacWechat/3.8.6(0x13080610) XWEB/1156 Flue NetType/WIFI",
+		Info{"WeChat", "6.8.0", OSMacOS, "10.15.7", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) wxwork/4.1.16 (MicroMessenger/6.2) WeChat/2.0.4 Language/zh ColorScheme/Light",
+		Info{"WeCom", "4.1.16", OSMacOS, "10.15.7", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.93 Safari/537.36 DingTalk(7.0.40-macOS-arm64-29130130) nw Channel/201200",
+		Info{"DingTalk", "7.0.40", OSMacOS, "10.15.7", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Lark/7.7.7 Chrome/108.0.5359.215 Electron/22.3.27 Safari/537.36 LarkLocale/zh_CN ChannelName/Feishu",
+		Info{"Feishu", "7.7.7", OSMacOS, "10.15.7", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.5735.289 Electron/25.8.4 Safari/537.36",
+		Info{"Electron", "25.8.4", OSMacOS, "10.15.7", "Mac", "Blink"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_6_8) AppleWebKit/534.59.10 (KHTML, like Gecko) Version/5.1.9 Safari/534.59.10",
+		Info{"Safari", "5.1.9", OSMacOS, "10.6.8", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; U; Intel Mac OS X 10_6_8; en-us) AppleWebKit/533.21.1 (KHTML, like Gecko) Version/5.0.5 Safari/533.21.1",
+		Info{"Safari", "5.0.5", OSMacOS, "10.6.8", "Mac", "WebKit"}},
+
+	// iPadOS 13 起 Safari 默认请求桌面版网页，与 Mac 上的 Safari 无法区分
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+		Info{"Safari", "17.2", OSMacOS, "10.15.7", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_6) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.3 Safari/605.1.15",
+		Info{"Safari", "16.3", OSMacOS, "10.15.6", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.6 Safari/605.1.15",
+		Info{"Safari", "15.6", OSMacOS, "10.15.7", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Safari/605.1.15",
+		Info{"Chrome", "120.0.6099.119", OSMacOS, "10.15.7", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) EdgiOS/120.0.2210.126 Version/17.0 Safari/605.1.15",
+		Info{"Edge", "120.0.2210.126", OSMacOS, "10.15.7", "Mac", "WebKit"}},
+	{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.46(0x18002e2c) NetType/WIFI Language/zh_CN",
+		Info{"WeChat", "8.0.46", OSMacOS, "10.15.7", "Mac", "WebKit"}},
+
+	// Linux 和 ChromeOS
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		Info{"Chrome", "120.0.0.0", OSLinux, "", "", "Blink"}},
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36",
+		Info{"Chrome", "114.0.0.0", OSLinux, "", "", "Blink"}},
+	{"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+		Info{"Firefox", "121.0", OSLinux, "", "", "Gecko"}},
+	{"Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0",
+		Info{"Firefox", "115.0", OSLinux, "", "", "Gecko"}},
+	{"Mozilla/5.0 (X11; Fedora; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0",
+		Info{"Firefox", "120.0", OSLinux, "", "", "Gecko"}},
+	{"Mozilla/5.0 (X11; Linux i686; rv:102.0) Gecko/20100101 Firefox/102.0",
+		Info{"Firefox", "102.0", OSLinux, "", "", "Gecko"}},
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Ubuntu Chromium/83.0.4103.61 Chrome/83.0.4103.61 Safari/537.36",
+		Info{"Chrome", "83.0.4103.61", OSLinux, "", "", "Blink"}},
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+		Info{"Edge", "120.0.0.0", OSLinux, "", "", "Blink"}},
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 OPR/106.0.0.0",
+		Info{"Opera", "106.0.0.0", OSLinux, "", "", "Blink"}},
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Vivaldi/6.5.3206.48",
+		Info{"Vivaldi", "6.5.3206.48", OSLinux, "", "", "Blink"}},
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.6099.28 Safari/537.36",
+		Info{"Headless Chrome", "120.0.6099.28", OSLinux, "", "", "Blink"}},
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/79.0.3945.0 Safari/537.36",
+		Info{"Headless Chrome", "79.0.3945.0", OSLinux, "", "", "Blink"}},
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Safari/605.1.15 Epiphany/605.1.15",
+		Info{"Safari", "16.0", OSLinux, "", "", "WebKit"}},
+	{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/110.0.5481.208 Safari/537.36 UOS",
+		Info{"Chrome", "110.0.5481.208", OSLinux, "", "", "Blink"}},
+	{"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		Info{"Chrome", "120.0.0.0", OSChromeOS, "14541.0.0", "", "Blink"}},
+	{"Mozilla/5.0 (X11; CrOS x86_64 15633.69.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.6045.212 Safari/537.36",
+		Info{"Chrome", "119.0.6045.212", OSChromeOS, "15633.69.0", "", "Blink"}},
+	{"Mozilla/5.0 (X11; CrOS aarch64 15359.58.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.5735.350 Safari/537.36",
+		Info{"Chrome", "114.0.5735.350", OSChromeOS, "15359.58.0", "", "Blink"}},
+	{"Mozilla/5.0 (X11; CrOS armv7l 13597.84.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/88.0.4324.186 Safari/537.36",
+		Info{"Chrome", "88.0.4324.186", OSChromeOS, "13597.84.0", "", "Blink"}},
+
+	// iPhone 和 iPad
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "17.2", OSIOS, "17.2.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1.2 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "17.1.2", OSIOS, "17.1.2", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "16.6", OSIOS, "16.6", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 15_8 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.6.6 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "15.6.6", OSIOS, "15.8", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 14_8 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1.2 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "14.1.2", OSIOS, "14.8", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 12_5_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1.2 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "12.1.2", OSIOS, "12.5.7", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 10_3_4 like Mac OS X) AppleWebKit/603.3.8 (KHTML, like Gecko) Version/10.0 Mobile/14G61 Safari/602.1",
+		Info{"Safari", "10.0", OSIOS, "10.3.4", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 9_3_5 like Mac OS X) AppleWebKit/601.1.46 (KHTML, like Gecko) Version/9.0 Mobile/13G36 Safari/601.1",
+		Info{"Safari", "9.0", OSIOS, "9.3.5", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 18_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "18.0", OSIOS, "18.0", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+		Info{"Chrome", "120.0.6099.119", OSIOS, "17.2", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 16_7_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/119.0.6045.169 Mobile/15E148 Safari/604.1",
+		Info{"Chrome", "119.0.6045.169", OSIOS, "16.7.2", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/121.0 Mobile/15E148 Safari/605.1.15",
+		Info{"Firefox", "121.0", OSIOS, "17.2", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 EdgiOS/120.2210.126 Mobile/15E148 Safari/605.1.15",
+		Info{"Edge", "120.2210.126", OSIOS, "17.2", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1 OPT/4.3.2",
+		Info{"Opera", "4.3.2", OSIOS, "17.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 15_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) OPiOS/16.0.14.122053 Mobile/15E148 Safari/9537.53",
+		Info{"Opera", "16.0.14.122053", OSIOS, "15.6", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 YaBrowser/23.11.4.466.10 SA/3 Mobile/15E148 Safari/604.1",
+		Info{"Yandex", "23.11.4.466.10", OSIOS, "17.2", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148",
+		Info{"", "", OSIOS, "17.2", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "17.2", OSIOS, "17.2", "iPad", "WebKit"}},
+	{"Mozilla/5.0 (iPad; CPU OS 12_5_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1.2 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "12.1.2", OSIOS, "12.5.7", "iPad", "WebKit"}},
+	{"Mozilla/5.0 (iPad; CPU OS 9_3_5 like Mac OS X) AppleWebKit/601.1.46 (KHTML, like Gecko) Version/9.0 Mobile/13G36 Safari/601.1",
+		Info{"Safari", "9.0", OSIOS, "9.3.5", "iPad", "WebKit"}},
+	{"Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+		Info{"Chrome", "120.0.6099.119", OSIOS, "17.2", "iPad", "WebKit"}},
+	{"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/120.0 Mobile/15E148 Safari/605.1.15",
+		Info{"Firefox", "120.0", OSIOS, "16.6", "iPad", "WebKit"}},
+	{"Mozilla/5.0 (iPad; CPU OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.44(0x1800302d) NetType/WIFI Language/zh_CN",
+		Info{"WeChat", "8.0.44", OSIOS, "17.1", "iPad", "WebKit"}},
+	{"Mozilla/5.0 (iPod touch; CPU iPhone OS 15_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.6.1 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "15.6.1", OSIOS, "15.7", "iPod", "WebKit"}},
+	{"Mozilla/5.0 (iPod touch; CPU iPhone OS 12_5_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.1.2 Mobile/15E148 Safari/604.1",
+		Info{"Safari", "12.1.2", OSIOS, "12.5.7", "iPod", "WebKit"}},
+
+	// iOS 上的 App 内置浏览器
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.46(0x18002e2c) NetType/WIFI Language/zh_CN",
+		Info{"WeChat", "8.0.46", OSIOS, "17.2.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.42(0x18002a32) NetType/4G Language/zh_CN",
+		Info{"WeChat", "8.0.42", OSIOS, "16.6", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 15_4_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.20(0x18001442) NetType/WIFI Language/en",
+		Info{"WeChat", "8.0.20", OSIOS, "15.4.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.44(0x1800302d) NetType/5G Language/zh_CN miniProgram/wx1234567890abcdef",
+		Info{"WeChat", "8.0.44", OSIOS, "17.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 wxwork/4.1.16 MicroMessenger/7.0.1 Language/zh ColorScheme/Light",
+		Info{"WeCom", "4.1.16", OSIOS, "17.0", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 QQ/9.0.8.605 V1_IPH_SQ_9.0.8_1_APP_A Pixel/1170 MiniAppEnable SimpleUISwitch/0 StudyMode/0 CurrentMode/0 CurrentFontScale/1.000000 QQTheme/1000 AppId/537192233 Core/WKWebView Device/Apple(iPhone 14) NetType/WIFI QBWebViewType/1 WKType/1",
+		Info{"QQ", "9.0.8.605", OSIOS, "17.1.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 16_3 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 QQ/8.9.33.627 V1_IPH_SQ_8.9.33_1_APP_A Pixel/828 Core/WKWebView Device/Apple(iPhone 11) NetType/4G QBWebViewType/1 WKType/1",
+		Info{"QQ", "8.9.33.627", OSIOS, "16.3", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 AliApp(DingTalk/7.0.45) com.laiwang.DingTalk/31366813 Channel/201200 language/zh-Hans-CN UT4Aplus/0.0.6 WK",
+		Info{"DingTalk", "7.0.45", OSIOS, "16.6", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Lark/7.6.8 LarkLocale/zh_CN ChannelName/Feishu TTWebView/1180020075037",
+		Info{"Feishu", "7.6.8", OSIOS, "17.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 ChannelId(0) Nebula PSDType(1) AlipayDefined(nt:WIFI,ws:390|780|3.0) AliApp(AP/10.5.60.6000) AlipayClient/10.5.60.6000 Language/zh-Hans Region/CN",
+		Info{"Alipay", "10.5.60.6000", OSIOS, "17.2", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Weibo (iPhone15,2__weibo__13.11.3__iphone__os17.1.2)",
+		Info{"Weibo", "13.11.3", OSIOS, "17.1.2", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 16_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Weibo (iPhone13,2__weibo__12.11.1__iphone__os16.1)",
+		Info{"Weibo", "12.11.1", OSIOS, "16.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 aweme_27.6.0 JsSdk/2.0 NetType/WIFI Channel/App Store ByteLocale/zh Region/CN AppTheme/light app_version/27.6.0 ByteFullLocale/zh-Hans-CN WKWebView/1",
+		Info{"Douyin", "27.6.0", OSIOS, "17.0", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 16_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 aweme_25.5.0 JsSdk/2.0 NetType/4G Channel/App Store ByteLocale/zh Region/CN app_version/25.5.0",
+		Info{"Douyin", "25.5.0", OSIOS, "16.5", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 NewsArticle/9.5.6 JsSdk/2.0 NetType/WIFI (NewsLite 9.5.6 17.100000) TTWebView/0",
+		Info{"Toutiao", "9.5.6", OSIOS, "17.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 xhsdiscover/8.18.1 NetType/WiFi Lang/zh-Hans",
+		Info{"Xiaohongshu", "8.18.1", OSIOS, "17.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 SP-engine/2.91.0 main%2F1.0 baiduboxapp/13.50.0.10 (Baidu; P2 17.1) NABar/1.0",
+		Info{"Baidu App", "13.50.0.10", OSIOS, "17.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 16_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.5 MQQBrowser/14.2.5 Mobile/15E148 Safari/604.1 QBWebViewUA/2 QBWebViewType/1 WKType/1",
+		Info{"QQ Browser", "14.2.5", OSIOS, "16.5", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 UCBrowser/13.4.2.1307 Mobile AliApp(TUnionSDK/0.1.20.4)",
+		Info{"UC Browser", "13.4.2.1307", OSIOS, "17.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Quark/6.6.8.1888 Mobile",
+		Info{"Quark", "6.6.8.1888", OSIOS, "17.2", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1 SogouMobileBrowser/5.28.12",
+		Info{"Sogou Browser", "5.28.12", OSIOS, "16.6", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 MicroMessenger/8.0.44(0x1800302d) NetType/WIFI Language/zh_CN wechatdevtools",
+		Info{"WeChat", "8.0.44", OSIOS, "17.1", "iPhone", "WebKit"}},
+	{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 [FBAN/FBIOS;FBAV/444.0.0.36.111;FBBV/548328013;FBDV/iPhone15,3;FBMD/iPhone;FBSN/iOS;FBSV/17.2;FBSS/3;FBID/phone;FBLC/en_US;FBOP/5]",
+		Info{"", "", OSIOS, "17.2", "iPhone", "WebKit"}},
+
+	// Android 通用浏览器
+	{"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+		Info{"Chrome", "120.0.0.0", OSAndroid, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Mobile Safari/537.36",
+		Info{"Chrome", "119.0.0.0", OSAndroid, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 14; Pixel 8 Pro) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+		Info{"Chrome", "120.0.6099.144", OSAndroid, "14", "Pixel 8 Pro", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Mobile Safari/537.36",
+		Info{"Chrome", "116.0.0.0", OSAndroid, "13", "Pixel 7", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.0.0 Mobile Safari/537.36",
+		Info{"Chrome", "112.0.0.0", OSAndroid, "12", "SM-G991B", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 11; Redmi Note 8 Pro) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/108.0.0.0 Mobile Safari/537.36",
+		Info{"Chrome", "108.0.0.0", OSAndroid, "11", "Redmi Note 8 Pro", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 9; Mi A2 Lite) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.45 Mobile Safari/537.36",
+		Info{"Chrome", "96.0.4664.45", OSAndroid, "9", "Mi A2 Lite", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 8.0.0; SM-G930F Build/R16NW) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/74.0.3729.157 Mobile Safari/537.36",
+		Info{"Chrome", "74.0.3729.157", OSAndroid, "8.0.0", "SM-G930F", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 7.0; Moto G (5) Build/NPPS25.137-93-14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/64.0.3282.137 Mobile Safari/537.36",
+		Info{"Chrome", "64.0.3282.137", OSAndroid, "7.0", "Moto G (5)", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/41.0.2272.96 Mobile Safari/537.36",
+		Info{"Chrome", "41.0.2272.96", OSAndroid, "6.0.1", "Nexus 5X", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 5.1.1; SM-J111F) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/94.0.4606.85 Mobile Safari/537.36",
+		Info{"Chrome", "94.0.4606.85", OSAndroid, "5.1.1", "SM-J111F", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		Info{"Chrome", "120.0.0.0", OSAndroid, "13", "SM-X700", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; Lenovo TB-J606F) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+		Info{"Chrome", "119.0.0.0", OSAndroid, "12", "Lenovo TB-J606F", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+		Info{"Chrome", "120.0.6099.144", OSAndroid, "14", "", "Blink"}},
+	{"Mozilla/5.0 (Android 14; Mobile; rv:121.0) Gecko/121.0 Firefox/121.0",
+		Info{"Firefox", "121.0", OSAndroid, "14", "", "Gecko"}},
+	{"Mozilla/5.0 (Android 13; Mobile; rv:109.0) Gecko/115.0 Firefox/115.0",
+		Info{"Firefox", "115.0", OSAndroid, "13", "", "Gecko"}},
+	{"Mozilla/5.0 (Android 12; Tablet; rv:120.0) Gecko/120.0 Firefox/120.0",
+		Info{"Firefox", "120.0", OSAndroid, "12", "", "Gecko"}},
+	{"Mozilla/5.0 (Android 9; Mobile; rv:68.0) Gecko/68.0 Firefox/68.0",
+		Info{"Firefox", "68.0", OSAndroid, "9", "", "Gecko"}},
+	{"Mozilla/5.0 (Linux; Android 10; HD1913) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36 EdgA/120.0.2210.115",
+		Info{"Edge", "120.0.2210.115", OSAndroid, "10", "HD1913", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Mobile Safari/537.36 EdgA/119.0.2151.78",
+		Info{"Edge", "119.0.2151.78", OSAndroid, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; VOG-L29) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Mobile Safari/537.36 OPR/79.0.4195.76186",
+		Info{"Opera", "79.0.4195.76186", OSAndroid, "10", "VOG-L29", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; SM-A325F) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.5993.112 YaBrowser/23.11.2.126.00 SA/3 Mobile Safari/537.36",
+		Info{"Yandex", "23.11.2.126.00", OSAndroid, "12", "SM-A325F", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36 Vivaldi/6.5.3217.55",
+		Info{"Vivaldi", "6.5.3217.55", OSAndroid, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; SAMSUNG SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+		Info{"Samsung Internet", "23.0", OSAndroid, "13", "SM-S918B", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 14; SAMSUNG SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+		Info{"Samsung Internet", "23.0", OSAndroid, "14", "SM-S911B", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 11; SAMSUNG SM-A515F) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/19.0 Chrome/102.0.5005.125 Mobile Safari/537.36",
+		Info{"Samsung Internet", "19.0", OSAndroid, "11", "SM-A515F", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; SM-X200) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/22.0 Chrome/111.0.5563.116 Safari/537.36",
+		Info{"Samsung Internet", "22.0", OSAndroid, "13", "SM-X200", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+		Info{"Samsung Internet", "23.0", OSAndroid, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 9; SAMSUNG SM-T510 Build/PPR1.180610.011) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/9.2 Chrome/67.0.3396.87 Safari/537.36",
+		Info{"Samsung Internet", "9.2", OSAndroid, "9", "SM-T510", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 4.0.3; ko-kr; LG-L160L Build/IML74K) AppleWebKit/534.30 (KHTML, like Gecko) Version/4.0 Mobile Safari/534.30",
+		Info{"Android Browser", "4.0", OSAndroid, "4.0.3", "LG-L160L", "WebKit"}},
+	{"Mozilla/5.0 (Linux; U; Android 4.1.2; en-us; GT-I9300 Build/JZO54K) AppleWebKit/534.30 (KHTML, like Gecko) Version/4.0 Mobile Safari/534.30",
+		Info{"Android Browser", "4.0", OSAndroid, "4.1.2", "GT-I9300", "WebKit"}},
+	{"Mozilla/5.0 (Linux; U; Android 2.3.6; zh-cn; GT-S5830i Build/GINGERBREAD) AppleWebKit/533.1 (KHTML, like Gecko) Version/4.0 Mobile Safari/533.1",
+		Info{"Android Browser", "4.0", OSAndroid, "2.3.6", "GT-S5830i", "WebKit"}},
+	{"Mozilla/5.0 (Linux; U; Android 4.4.2; zh-CN; HUAWEI MT7-TL00 Build/HuaweiMT7-TL00) AppleWebKit/534.30 (KHTML, like Gecko) Version/4.0 Mobile Safari/534.30",
+		Info{"Android Browser", "4.0", OSAndroid, "4.4.2", "HUAWEI MT7-TL00", "WebKit"}},
+	{"Opera/9.80 (Android; Opera Mini/36.2.2254/119.132; U; id) Presto/2.12.423 Version/12.16",
+		Info{"Opera", "36.2.2254", OSAndroid, "", "", "Presto"}},
+
+	// Android WebView
+	{"Mozilla/5.0 (Linux; Android 13; Pixel 7 Build/TQ3A.230901.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/119.0.6045.193 Mobile Safari/537.36",
+		Info{"Android WebView", "119.0.6045.193", OSAndroid, "13", "Pixel 7", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; SM-G991B Build/SP1A.210812.016; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0.6099.43 Mobile Safari/537.36",
+		Info{"Android WebView", "120.0.6099.43", OSAndroid, "12", "SM-G991B", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 11; M2012K11AC Build/RKQ1.200826.002; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/104.0.5112.97 Mobile Safari/537.36",
+		Info{"Android WebView", "104.0.5112.97", OSAndroid, "11", "M2012K11AC", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; MI 8 Build/QKQ1.190828.002; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/83.0.4103.101 Mobile Safari/537.36",
+		Info{"Android WebView", "83.0.4103.101", OSAndroid, "10", "MI 8", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 14; 23127PN0CC Build/UKQ1.230804.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/118.0.0.0 Mobile Safari/537.36",
+		Info{"Android WebView", "118.0.0.0", OSAndroid, "14", "23127PN0CC", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 9; ONEPLUS A6003 Build/PKQ1.180716.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/77.0.3865.92 Mobile Safari/537.36",
+		Info{"Android WebView", "77.0.3865.92", OSAndroid, "9", "ONEPLUS A6003", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; Pixel 6 Build/SD1A.210817.036; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/94.0.4606.71 Mobile Safari/537.36",
+		Info{"Android WebView", "94.0.4606.71", OSAndroid, "12", "Pixel 6", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; K; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0.6099.43 Mobile Safari/537.36",
+		Info{"Android WebView", "120.0.6099.43", OSAndroid, "10", "", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; SM-S9180 Build/TP1A.220624.014; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/115.0.5790.166 Mobile Safari/537.36",
+		Info{"Android WebView", "115.0.5790.166", OSAndroid, "13", "SM-S9180", "Blink"}},
+
+	// Android 国产浏览器和手机厂商浏览器
+	{"Mozilla/5.0 (Linux; U; Android 10; zh-CN; V1990A Build/QP1A.190711.020) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/78.0.3904.108 UCBrowser/15.5.2.1222 Mobile Safari/537.36",
+		Info{"UC Browser", "15.5.2.1222", OSAndroid, "10", "V1990A", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 13; zh-CN; 2211133C Build/TKQ1.220905.001) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/100.0.4896.58 UCBrowser/16.1.8.1290 Mobile Safari/537.36",
+		Info{"UC Browser", "16.1.8.1290", OSAndroid, "13", "2211133C", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 9; en-US; SM-J730F Build/PPR1.180610.011) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/78.0.3904.108 UCBrowser/13.4.0.1306 Mobile Safari/537.36",
+		Info{"UC Browser", "13.4.0.1306", OSAndroid, "9", "SM-J730F", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 6.0.1; zh-CN; SM-C7000 Build/MMB29M) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/40.0.2214.89 UCBrowser/11.6.2.948 Mobile Safari/537.36",
+		Info{"UC Browser", "11.6.2.948", OSAndroid, "6.0.1", "SM-C7000", "Blink"}},
+	{"UCWEB/2.0 (MIDP-2.0; U; Adr 9; zh-CN; Redmi Note 8) U2/1.0.0 UCBrowser/12.9.0.1070 U2/1.0.0 Mobile",
+		Info{"UC Browser", "12.9.0.1070", "", "", "", ""}},
+	{"Mozilla/5.0 (Linux; U; Android 12; zh-cn; PFFM10 Build/SP1A.210812.016) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/109.0.5414.86 MQQBrowser/14.3 Mobile Safari/537.36 COVC/046515",
+		Info{"QQ Browser", "14.3", OSAndroid, "12", "PFFM10", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 11; zh-cn; M2011K2C Build/RKQ1.200928.002) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/89.0.4389.72 MQQBrowser/13.6 Mobile Safari/537.36 COVC/046305",
+		Info{"QQ Browser", "13.6", OSAndroid, "11", "M2011K2C", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 9; zh-cn; vivo X21A Build/PKQ1.180819.001) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/66.0.3359.126 MQQBrowser/10.1 Mobile Safari/537.36",
+		Info{"QQ Browser", "10.1", OSAndroid, "9", "vivo X21A", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 13; zh-CN; 22081212C Build/TKQ1.220829.002) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/100.0.4896.58 Quark/6.9.5.451 Mobile Safari/537.36",
+		Info{"Quark", "6.9.5.451", OSAndroid, "13", "22081212C", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 12; zh-CN; V2172A Build/SP1A.210812.003) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/100.0.4896.58 Quark/6.4.5.320 Mobile Safari/537.36",
+		Info{"Quark", "6.4.5.320", OSAndroid, "12", "V2172A", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 13; zh-cn; 22081212C Build/TKQ1.220829.002) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/109.0.5414.118 Mobile Safari/537.36 XiaoMi/MiuiBrowser/17.7.190118 swan-mibrowser",
+		Info{"MIUI Browser", "17.7.190118", OSAndroid, "13", "22081212C", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 12; zh-cn; M2102K1C Build/SKQ1.211006.001) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/100.0.4896.127 Mobile Safari/537.36 XiaoMi/MiuiBrowser/17.1.90 swan-mibrowser",
+		Info{"MIUI Browser", "17.1.90", OSAndroid, "12", "M2102K1C", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 11; en-us; Redmi Note 9 Pro Build/RKQ1.200826.002) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/89.0.4389.116 Mobile Safari/537.36 XiaoMi/MiuiBrowser/13.5.0-gn",
+		Info{"MIUI Browser", "13.5.0", OSAndroid, "11", "Redmi Note 9 Pro", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; V2241A; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/87.0.4280.141 Mobile Safari/537.36 VivoBrowser/17.6.5.0",
+		Info{"Vivo Browser", "17.6.5.0", OSAndroid, "13", "V2241A", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 11; V2055A; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/87.0.4280.141 Mobile Safari/537.36 VivoBrowser/10.2.10.0",
+		Info{"Vivo Browser", "10.2.10.0", OSAndroid, "11", "V2055A", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 13; zh-cn; PHB110 Build/TP1A.220905.001) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/115.0.5790.168 Mobile Safari/537.36 HeyTapBrowser/40.8.40.1",
+		Info{"OPPO Browser", "40.8.40.1", OSAndroid, "13", "PHB110", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 11; zh-cn; PCLM10 Build/RKQ1.200903.002) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/70.0.3538.80 Mobile Safari/537.36 HeyTapBrowser/10.7.29.3",
+		Info{"OPPO Browser", "10.7.29.3", OSAndroid, "11", "PCLM10", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 8.1.0; zh-cn; PBAM00 Build/OPM1.171019.026) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/53.0.2785.134 Mobile Safari/537.36 OppoBrowser/10.5.1.2",
+		Info{"OPPO Browser", "10.5.1.2", OSAndroid, "8.1.0", "PBAM00", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; ELS-AN00 Build/HUAWEIELS-AN00) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.88 HuaweiBrowser/13.0.5.302 Mobile Safari/537.36",
+		Info{"Huawei Browser", "13.0.5.302", OSAndroid, "10", "ELS-AN00", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; HMA-AL00 Build/HUAWEIHMA-AL00; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/88.0.4324.93 Mobile Safari/537.36",
+		Info{"Android WebView", "88.0.4324.93", OSAndroid, "10", "HMA-AL00", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 12; zh-cn; SM-S9080 Build/SP1A.210812.016) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/89.0.4389.72 Mobile Safari/537.36 SogouMobileBrowser/5.28.12",
+		Info{"Sogou Browser", "5.28.12", OSAndroid, "12", "SM-S9080", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; V1938CT; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/70.0.3538.110 Mobile Safari/537.36 QihooBrowser/4.0.10",
+		Info{"360 Browser", "4.0.10", OSAndroid, "10", "V1938CT", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 9; MI 6 Build/PKQ1.190118.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/70.0.3538.110 Mobile Safari/537.36 baidubrowser/7.19.13.0 (Baidu; P1 9)",
+		Info{"Baidu Browser", "7.19.13.0", OSAndroid, "9", "MI 6", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 11; M2007J3SC Build/RKQ1.200826.002; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/83.0.4103.106 Mobile Safari/537.36 Mb2345Browser/14.4.1",
+		Info{"2345 Browser", "14.4.1", OSAndroid, "11", "M2007J3SC", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 9; Redmi 7A Build/PKQ1.190319.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/74.0.3729.136 Mobile Safari/537.36 MXBrowser/5.1.9.2000",
+		Info{"Maxthon", "5.1.9.2000", OSAndroid, "9", "Redmi 7A", "Blink"}},
+
+	// Android 上的 App 内置浏览器
+	{"Mozilla/5.0 (Linux; Android 13; V2148A Build/TP1A.220624.014; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/116.0.0.0 Mobile Safari/537.36 XWEB/1160083 MMWEBSDK/20231202 MMWEBID/4194 MicroMessenger/8.0.47.2560(0x28002F51) WeChat/arm64 Weixin NetType/WIFI Language/zh_CN ABI/arm64",
+		Info{"WeChat", "8.0.47.2560", OSAndroid, "13", "V2148A", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; 22041211AC Build/SP1A.210812.016; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/111.0.5563.116 Mobile Safari/537.36 XWEB/5307 MMWEBSDK/20230805 MMWEBID/2624 MicroMessenger/8.0.42.2460(0x28002A35) WeChat/arm64 Weixin NetType/4G Language/zh_CN ABI/arm64",
+		Info{"WeChat", "8.0.42.2460", OSAndroid, "12", "22041211AC", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; ELS-AN00 Build/HUAWEIELS-AN00; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/107.0.5304.141 Mobile Safari/537.36 XWEB/5023 MMWEBSDK/20230405 MMWEBID/6782 MicroMessenger/8.0.35.2360(0x2800235D) WeChat/arm64 Weixin NetType/WIFI Language/zh_CN ABI/arm64",
+		Info{"WeChat", "8.0.35.2360", OSAndroid, "10", "ELS-AN00", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 9; PCAM10 Build/PPR1.180610.011; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/86.0.4240.99 XWEB/4375 MMWEBSDK/20221011 Mobile Safari/537.36 MMWEBID/1234 MicroMessenger/8.0.30.2260(0x28001E3B) WeChat/arm64 Weixin NetType/WIFI Language/zh_CN ABI/arm64 miniProgram/wx0123456789abcdef",
+		Info{"WeChat", "8.0.30.2260", OSAndroid, "9", "PCAM10", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 7.1.1; OPPO R11 Build/NMF26X; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/57.0.2987.132 MQQBrowser/6.2 TBS/044607 Mobile Safari/537.36 MicroMessenger/6.7.3.1360(0x2607033D) NetType/WIFI Language/zh_CN Process/tools",
+		Info{"WeChat", "6.7.3.1360", OSAndroid, "7.1.1", "OPPO R11", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; M2012K11AC Build/SKQ1.211006.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/107.0.5304.141 Mobile Safari/537.36 XWEB/5127 MMWEBSDK/20230604 MMWEBID/2550 wxwork/4.1.10 MicroMessenger/7.0.1 NetType/WIFI Language/zh Lang/zh ColorScheme/Light",
+		Info{"WeCom", "4.1.10", OSAndroid, "12", "M2012K11AC", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; 2206122SC Build/TKQ1.220829.002; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/109.0.5414.86 MQQBrowser/6.2 TBS/046713 Mobile Safari/537.36 V1_AND_SQ_8.9.78_4548_YYB_D QQ/8.9.78.12275 NetType/WIFI WebP/0.3.0 AppId/537176903 Pixel/1440 StatusBarHeight/106 SimpleUISwitch/0 QQTheme/1000 StudyMode/0 CurrentMode/0 CurrentFontScale/1.0 GlobalDensityScale/0.9 AllowLandscape/false InMagicWin/0",
+		Info{"QQ", "8.9.78.12275", OSAndroid, "13", "2206122SC", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; V1990A Build/QP1A.190711.020; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/89.0.4389.72 MQQBrowser/6.2 TBS/046141 Mobile Safari/537.36 V1_AND_SQ_8.8.68_2538_YYB_D A_8086800 QQ/8.8.68.7265 NetType/4G WebP/0.3.0 Pixel/1080",
+		Info{"QQ", "8.8.68.7265", OSAndroid, "10", "V1990A", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 11; PEGM00 Build/RKQ1.200903.002; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/90.0.4430.210 Mobile Safari/537.36 AliApp(DingTalk/7.0.40) com.alibaba.android.rimet/31213408 Channel/700159 language/zh-CN abi/64 UT4Aplus/0.2.25 colorScheme/light",
+		Info{"DingTalk", "7.0.40", OSAndroid, "11", "PEGM00", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; PGT-AN10 Build/HONORPGT-AN10; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/108.0.5359.128 Mobile Safari/537.36 Lark/7.6.5 LarkLocale/zh_CN ChannelName/Feishu TTWebView/1080020075036",
+		Info{"Feishu", "7.6.5", OSAndroid, "13", "PGT-AN10", "Blink"}},
+	{"Mozilla/5.0 (Linux; U; Android 12; zh-CN; M2012K11AC Build/SKQ1.211006.001) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/69.0.3497.100 UWS/3.22.2.59 Mobile Safari/537.36 UCBS/3.22.2.59_230213143208 ChannelId(0) NebulaSDK/1.8.100112 Nebula AlipayDefined(nt:WIFI,ws:393|0|2.75) AliApp(AP/10.5.6.6000) AlipayClient/10.5.6.6000 Language/zh-Hans useStatusBar/true isConcaveScreen/true Region/CN",
+		Info{"Alipay", "10.5.6.6000", OSAndroid, "12", "M2012K11AC", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; V2183A Build/SP1A.210812.003; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/97.0.4692.98 Mobile Safari/537.36 Weibo (vivo-V2183A__weibo__13.10.2__android__android12)",
+		Info{"Weibo", "13.10.2", OSAndroid, "12", "V2183A", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; 22081212C Build/TKQ1.220829.002; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/106.0.5249.126 Mobile Safari/537.36 aweme_270600 JsSdk/1.0 NetType/WIFI Channel/xiaomi_1128_64 AppName/aweme app_version/27.6.0 ByteLocale/zh-CN Region/CN AppSkin/white AppTheme/light BytedanceWebview/d8a21c6 WebView/075113004008",
+		Info{"Douyin", "27.6.0", OSAndroid, "13", "22081212C", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; SEA-AL10 Build/HUAWEISEA-AL10; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/88.0.4324.181 Mobile Safari/537.36 aweme_190400 JsSdk/1.0 NetType/4G Channel/huawei_1128_64 AppName/aweme app_version/19.4.0 ByteLocale/zh-CN Region/CN",
+		Info{"Douyin", "19.4.0", OSAndroid, "10", "SEA-AL10", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; ANA-AN00 Build/HUAWEIANA-AN00; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/99.0.4844.88 Mobile Safari/537.36 JsSdk/2 NewsArticle/9.4.3 NetType/wifi",
+		Info{"Toutiao", "9.4.3", OSAndroid, "12", "ANA-AN00", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; M2102J2SC Build/TKQ1.221114.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/110.0.5481.153 Mobile Safari/537.36 XHSDiscover/8.14.0 NetType/WiFi",
+		Info{"Xiaohongshu", "8.14.0", OSAndroid, "13", "M2102J2SC", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; 22127RK46C Build/TKQ1.220905.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/97.0.4692.98 Mobile Safari/537.36 T7/13.50 SP-engine/2.89.0 baiduboxapp/13.50.0.10 (Baidu; P1 13) NABar/1.0",
+		Info{"Baidu App", "13.50.0.10", OSAndroid, "13", "22127RK46C", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; SM-G9910 Build/SP1A.210812.016; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0.6099.43 Mobile Safari/537.36 [FB_IAB/FB4A;FBAV/444.0.0.35.108;]",
+		Info{"Android WebView", "120.0.6099.43", OSAndroid, "12", "SM-G9910", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 13; SM-A536B Build/TP1A.220624.014; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0.6099.43 Mobile Safari/537.36 Instagram 311.0.0.32.118 Android",
+		Info{"Android WebView", "120.0.6099.43", OSAndroid, "13", "SM-A536B", "Blink"}},
+
+	// 鸿蒙：2-4 版兼容 Android，User-Agent 中带有 Android 版本；NEXT 版使用 OpenHarmony 和 ArkWeb 内核
+	{"Mozilla/5.0 (Linux; Android 10; HarmonyOS; ELS-AN00; HMSCore 6.12.0.302) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.88 HuaweiBrowser/14.0.1.300 Mobile Safari/537.36",
+		Info{"Huawei Browser", "14.0.1.300", OSHarmonyOS, "", "ELS-AN00", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 12; HarmonyOS; ALN-AL00; HMSCore 6.13.0.302) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.5735.196 HuaweiBrowser/15.0.4.312 Mobile Safari/537.36",
+		Info{"Huawei Browser", "15.0.4.312", OSHarmonyOS, "", "ALN-AL00", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; HarmonyOS; NOH-AN00; HMSCore 6.11.0.302) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.88 HuaweiBrowser/13.0.5.302 Mobile Safari/537.36",
+		Info{"Huawei Browser", "13.0.5.302", OSHarmonyOS, "", "NOH-AN00", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; HarmonyOS; MRX-W09; HMSCore 6.12.0.302) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.88 HuaweiBrowser/14.0.0.321 Safari/537.36",
+		Info{"Huawei Browser", "14.0.0.321", OSHarmonyOS, "", "MRX-W09", "Blink"}},
+	{"Mozilla/5.0 (Linux; HarmonyOS 3.0.0; NOH-AL10 Build/HUAWEINOH-AL10; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/99.0.4844.88 Mobile Safari/537.36",
+		Info{"Android WebView", "99.0.4844.88", OSHarmonyOS, "3.0.0", "NOH-AL10", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; HarmonyOS; JAD-AL50; HMSCore 6.12.0.302; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/99.0.4844.88 Mobile Safari/537.36",
+		Info{"Android WebView", "99.0.4844.88", OSHarmonyOS, "", "JAD-AL50", "Blink"}},
+	{"Mozilla/5.0 (Linux; Android 10; HarmonyOS; CDY-AN90; HMSCore 6.12.0.302; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/99.0.4844.88 Mobile Safari/537.36 XWEB/1160083 MMWEBSDK/20231201 MMWEBID/5207 MicroMessenger/8.0.45.2521(0x28002D3D) WeChat/arm64 Weixin NetType/WIFI Language/zh_CN ABI/arm64",
+		Info{"WeChat", "8.0.45.2521", OSHarmonyOS, "", "CDY-AN90", "Blink"}},
+	{"Mozilla/5.0 (Phone; OpenHarmony 4.1) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36 ArkWeb/4.1.6.1 Mobile HuaweiBrowser/5.0.4.300",
+		Info{"Huawei Browser", "5.0.4.300", OSHarmonyOS, "4.1", "", "ArkWeb"}},
+	{"Mozilla/5.0 (Phone; OpenHarmony 5.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36 ArkWeb/4.1.6.1 Mobile HuaweiBrowser/5.0.6.305",
+		Info{"Huawei Browser", "5.0.6.305", OSHarmonyOS, "5.0", "", "ArkWeb"}},
+	{"Mozilla/5.0 (Tablet; OpenHarmony 5.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36 ArkWeb/4.1.6.1 HuaweiBrowser/5.0.6.305",
+		Info{"Huawei Browser", "5.0.6.305", OSHarmonyOS, "5.0", "", "ArkWeb"}},
+	{"Mozilla/5.0 (Phone; OpenHarmony 5.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36 ArkWeb/4.1.6.1 Mobile MicroMessenger/8.0.1.47(0x28000132) NetType/WIFI Language/zh_CN",
+		Info{"WeChat", "8.0.1.47", OSHarmonyOS, "5.0", "", "ArkWeb"}},
+	{"Mozilla/5.0 (Phone; OpenHarmony 5.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36 ArkWeb/4.1.6.1 Mobile",
+		Info{"Chrome", "114.0.0.0", OSHarmonyOS, "5.0", "", "ArkWeb"}},
+	{"Mozilla/5.0 (PC; OpenHarmony 5.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36 ArkWeb/4.1.6.1",
+		Info{"Chrome", "114.0.0.0", OSHarmonyOS, "5.0", "", "ArkWeb"}},
+
+	// Windows Phone
+	{"Mozilla/5.0 (compatible; MSIE 10.0; Windows Phone 8.0; Trident/6.0; IEMobile/10.0; ARM; Touch; NOKIA; Lumia 920)",
+		Info{"IE", "10.0", OSWindowsPhone, "8.0", "", "Trident"}},
+	{"Mozilla/5.0 (Mobile; Windows Phone 8.1; Android 4.0; ARM; Trident/7.0; Touch; rv:11.0; IEMobile/11.0; NOKIA; Lumia 635) like iPhone OS 7_0_3 Mac OS X AppleWebKit/537 (KHTML, like Gecko) Mobile Safari/537",
+		Info{"IE", "11.0", OSWindowsPhone, "8.1", "", "Trident"}},
+	{"Mozilla/5.0 (Windows Phone 10.0; Android 6.0.1; Microsoft; Lumia 950) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/52.0.2743.116 Mobile Safari/537.36 Edge/15.15063",
+		Info{"Edge", "15.15063", OSWindowsPhone, "10.0", "", "EdgeHTML"}},
+	{"Mozilla/4.0 (compatible; MSIE 7.0; Windows Phone OS 7.0; Trident/3.1; IEMobile/7.0; HTC; 7 Trophy)",
+		Info{"IE", "7.0", OSWindowsPhone, "7.0", "", "Trident"}},
+
+	// 非浏览器客户端
+	{"curl/8.4.0", Info{}},
+	{"Wget/1.21.4", Info{}},
+	{"python-requests/2.31.0", Info{}},
+	{"Go-http-client/1.1", Info{}},
+	{"okhttp/4.12.0", Info{}},
+	{"PostmanRuntime/7.36.0", Info{}},
+	{"Dalvik/2.1.0 (Linux; U; Android 13; 22081212C Build/TKQ1.220829.002)",
+		Info{"", "", OSAndroid, "13", "22081212C", ""}},
+	{"", Info{}},
+	{"   ", Info{}},
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range uaCorpus {
+		if got := Parse(tt.ua); got != tt.want {
+			t.Errorf("Parse(%q)\n  得到 %+v\n  期望 %+v", tt.ua, got, tt.want)
+		}
+	}
+}
+
+// TestParseUpperCase 识别不区分大小写，版本号保留原样
+func TestParseUpperCase(t *testing.T) {
+	ua := "Mozilla/5.0 (Linux; Android 13; SM-S9180 Build/TP1A.220624.014; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/116.0.0.0 Mobile Safari/537.36 MicroMessenger/8.0.47.2560(0x28002F51)"
+	want := Parse(ua)
+	upper := make([]byte, len(ua))
+	for i := 0; i < len(ua); i++ {
+		c := ua[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper[i] = c
+	}
+	got := Parse(string(upper))
+	got.DeviceModel = want.DeviceModel
+	if got != want {
+		t.Errorf("Parse(大写) = %+v，期望 %+v", got, want)
+	}
+}
@@ -0,0 +1,323 @@
+package users
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHandlers 注册用户管理相关的路由，调用方负责传入已挂载认证中间件的路由组
+func (us *UserService) RegisterHandlers(rg *gin.RouterGroup) {
+	group := rg.Group("/api/users")
+	{
+		group.GET("", us.handleListUsers)
+		group.POST("", us.handleCreateUser)
+		group.GET("/:id", us.handleGetUser)
+		group.PUT("/:id", us.handleUpdateUser)
+		group.DELETE("/:id", us.handleDeleteUser)
+		// 用户丢失验证器时由管理员重置两步验证
+		group.DELETE("/:id/totp", us.handleResetTOTP)
+		// 已有账号需由管理员允许后才能绑定单点登录身份
+		group.POST("/:id/sso-link", us.handleAllowSSOLink)
+		group.DELETE("/:id/sso", us.handleUnlinkSSO)
+	}
+}
+
+// RegisterAccountHandlers 注册当前登录用户的自助设置路由（任意角色可用）
+func (us *UserService) RegisterAccountHandlers(rg *gin.RouterGroup) {
+	group := rg.Group("/api/account/totp")
+	{
+		group.GET("", us.handleTOTPStatus)
+		group.POST("/setup", us.handleTOTPSetup)
+		group.POST("/enable", us.handleTOTPEnable)
+		group.POST("/disable", us.handleTOTPDisable)
+		group.POST("/recovery-codes", us.handleRegenerateRecoveryCodes)
+	}
+}
+
+// handleListUsers 处理获取用户列表的请求
+func (us *UserService) handleListUsers(c *gin.Context) {
+	list, err := us.ListUsers()
+	if err != nil {
+		log.Printf("获取用户列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户列表失败"})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// handleCreateUser 处理创建用户的请求
+func (us *UserService) handleCreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	user, err := us.CreateUser(req.Username, req.Password, req.DisplayName, req.Role)
+	if err != nil {
+		us.writeError(c, err)
+		return
+	}
+
+	log.Printf("用户 %s 创建了账号: %s", c.GetString("username"), user.Username)
+	c.JSON(http.StatusCreated, user)
+}
+
+// handleGetUser 处理获取单个用户的请求
+func (us *UserService) handleGetUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := us.GetUserByID(id)
+	if err != nil {
+		us.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// handleUpdateUser 处理更新用户的请求
+func (us *UserService) handleUpdateUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 禁止禁用自己，避免把自己锁在后台之外
+	if req.Disabled != nil && *req.Disabled && id == c.GetInt64("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能禁用当前登录的账号"})
+		return
+	}
+
+	// 禁止修改自己的角色，避免管理员误操作后失去用户管理权限
+	if req.Role != nil && id == c.GetInt64("user_id") && *req.Role != c.GetString("role") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能修改当前登录账号的角色"})
+		return
+	}
+
+	user, err := us.UpdateUser(id, req)
+	if err != nil {
+		us.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// handleDeleteUser 处理删除用户的请求
+func (us *UserService) handleDeleteUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if id == c.GetInt64("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能删除当前登录的账号"})
+		return
+	}
+
+	if err := us.DeleteUser(id); err != nil {
+		us.writeError(c, err)
+		return
+	}
+
+	log.Printf("用户 %s 删除了账号: id=%d", c.GetString("username"), id)
+	c.JSON(http.StatusOK, gin.H{"message": "用户已删除"})
+}
+
+// handleResetTOTP 处理管理员重置用户两步验证的请求
+func (us *UserService) handleResetTOTP(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := us.DisableTOTP(id); err != nil {
+		us.writeError(c, err)
+		return
+	}
+
+	log.Printf("用户 %s 重置了用户 %d 的两步验证", c.GetString("username"), id)
+	c.JSON(http.StatusOK, gin.H{"message": "两步验证已重置"})
+}
+
+// handleAllowSSOLink 处理允许已有账号绑定单点登录身份的请求
+func (us *UserService) handleAllowSSOLink(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req SSOLinkRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+			return
+		}
+	}
+
+	user, err := us.AllowSSOLink(id, req.Email)
+	if err != nil {
+		us.writeError(c, err)
+		return
+	}
+
+	log.Printf("用户 %s 允许账号 %s 绑定单点登录身份 %s", c.GetString("username"), user.Username, user.SSOLinkEmail)
+	c.JSON(http.StatusOK, user)
+}
+
+// handleUnlinkSSO 处理解除单点登录绑定的请求
+func (us *UserService) handleUnlinkSSO(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := us.UnlinkSSO(id)
+	if err != nil {
+		us.writeError(c, err)
+		return
+	}
+
+	log.Printf("用户 %s 解除了账号 %s 的单点登录绑定", c.GetString("username"), user.Username)
+	c.JSON(http.StatusOK, user)
+}
+
+// handleTOTPStatus 处理查询当前用户两步验证状态的请求
+func (us *UserService) handleTOTPStatus(c *gin.Context) {
+	user, err := us.GetUserByID(c.GetInt64("user_id"))
+	if err != nil {
+		us.writeError(c, err)
+		return
+	}
+
+	remaining := 0
+	if user.TOTPEnabled {
+		if remaining, err = us.RemainingRecoveryCodes(user.ID); err != nil {
+			log.Printf("查询恢复码数量失败: %v", err)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":                  user.TOTPEnabled,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// handleTOTPSetup 处理生成两步验证密钥的请求
+func (us *UserService) handleTOTPSetup(c *gin.Context) {
+	secret, uri, err := us.BeginTOTPSetup(c.GetInt64("user_id"))
+	if err != nil {
+		us.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": uri,
+		"message":          "请使用验证器应用扫描二维码，然后提交验证码以启用",
+	})
+}
+
+// handleTOTPEnable 处理确认并启用两步验证的请求
+func (us *UserService) handleTOTPEnable(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入验证码"})
+		return
+	}
+
+	codes, err := us.EnableTOTP(c.GetInt64("user_id"), req.Code)
+	if err != nil {
+		us.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "两步验证已启用，请妥善保存恢复码，它们只会显示一次",
+		"recovery_codes": codes,
+	})
+}
+
+// handleTOTPDisable 处理关闭两步验证的请求，需要提供当前验证码或恢复码
+func (us *UserService) handleTOTPDisable(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入验证码"})
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	if err := us.VerifySecondFactor(userID, req.Code); err != nil {
+		us.writeError(c, err)
+		return
+	}
+	if err := us.DisableTOTP(userID); err != nil {
+		us.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "两步验证已关闭"})
+}
+
+// handleRegenerateRecoveryCodes 处理重新生成恢复码的请求，需要提供当前验证码
+func (us *UserService) handleRegenerateRecoveryCodes(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入验证码"})
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	if err := us.VerifySecondFactor(userID, req.Code); err != nil {
+		us.writeError(c, err)
+		return
+	}
+	codes, err := us.RegenerateRecoveryCodes(userID)
+	if err != nil {
+		us.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "恢复码已重新生成，旧恢复码全部失效",
+		"recovery_codes": codes,
+	})
+}
+
+// parseUserID 解析路径中的用户ID，失败时直接写入 400 响应
+func parseUserID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// writeError 将服务层错误映射为 HTTP 响应
+func (us *UserService) writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrUsernameTaken), errors.Is(err, ErrTOTPAlreadyEnabled), errors.Is(err, ErrSSOAlreadyLinked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPasswordTooShort), errors.Is(err, ErrInvalidUsername),
+		errors.Is(err, ErrInvalidRole), errors.Is(err, ErrInvalidTOTPCode),
+		errors.Is(err, ErrTOTPNotEnabled), errors.Is(err, ErrTOTPNotSetup), errors.Is(err, ErrInvalidEmail):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("用户操作失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
+	}
+}
@@ -0,0 +1,70 @@
+package users
+
+import (
+	"time"
+)
+
+// 角色定义
+const (
+	RoleAdmin   = "admin"   // 管理员：全部权限
+	RoleEditor  = "editor"  // 编辑：可编辑文章，不能删除或构建
+	RoleAnalyst = "analyst" // 分析师：只能查看统计数据
+)
+
+// ValidRole 判断角色是否合法
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleEditor, RoleAnalyst:
+		return true
+	}
+	return false
+}
+
+// User 代表一个后台账号
+type User struct {
+	ID           int64      `json:"id"`
+	Username     string     `json:"username"`      // 登录名，唯一
+	DisplayName  string     `json:"display_name"`  // 显示名称
+	Role         string     `json:"role"`          // 角色：admin, editor, analyst
+	PasswordHash string     `json:"-"`             // 密码哈希（bcrypt），不对外输出
+	Disabled     bool       `json:"disabled"`      // 是否禁用
+	TOTPEnabled  bool       `json:"totp_enabled"`  // 是否启用两步验证
+	TOTPSecret   string     `json:"-"`             // TOTP 密钥（Base32），未启用时为待确认的密钥
+	TOTPLastStep int64      `json:"-"`             // 最近一次成功验证的时间步，防止验证码重放
+	CreatedAt    time.Time  `json:"created_at"`    // 创建时间
+	UpdatedAt    time.Time  `json:"updated_at"`    // 更新时间
+	LastLoginAt  *time.Time `json:"last_login_at"` // 最近登录时间
+	Email        string     `json:"email"`         // 邮箱，单点登录账号由身份提供方同步
+	OIDCSubject  string     `json:"-"`             // 绑定的单点登录身份（sub），为空表示未绑定
+	SSO          bool       `json:"sso"`           // 是否已绑定单点登录身份
+	SSOManaged   bool       `json:"sso_managed"`   // 由单点登录创建，每次登录按用户组同步角色
+
+	SSOLinkEmail     string     `json:"sso_link_email"`      // 管理员允许绑定的单点登录邮箱，为空表示没有待绑定的请求
+	SSOLinkExpiresAt *time.Time `json:"sso_link_expires_at"` // 允许绑定的截止时间
+}
+
+// CreateUserRequest 代表创建用户请求
+type CreateUserRequest struct {
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	DisplayName string `json:"display_name"`
+	Role        string `json:"role" binding:"required"`
+}
+
+// UpdateUserRequest 代表更新用户请求，字段为空表示不修改
+type UpdateUserRequest struct {
+	DisplayName *string `json:"display_name"`
+	Role        *string `json:"role"`
+	Password    *string `json:"password"`
+	Disabled    *bool   `json:"disabled"`
+}
+
+// SSOLinkRequest 代表允许已有账号绑定单点登录身份的请求，邮箱为空时使用用户名
+type SSOLinkRequest struct {
+	Email string `json:"email"`
+}
+
+// TOTPCodeRequest 代表提交两步验证码的请求
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
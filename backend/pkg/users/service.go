@@ -0,0 +1,351 @@
+package users
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength 密码最小长度
+const MinPasswordLength = 8
+
+var (
+	ErrUserNotFound       = errors.New("用户不存在")
+	ErrUsernameTaken      = errors.New("用户名已存在")
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	ErrUserDisabled       = errors.New("账号已被禁用")
+	ErrPasswordTooShort   = fmt.Errorf("密码长度不能少于 %d 位", MinPasswordLength)
+	ErrInvalidUsername    = errors.New("用户名只能包含字母、数字、下划线、点、短横线和 @，长度 3-64")
+	ErrSSOIdentityTaken   = errors.New("该用户名已绑定其他单点登录身份")
+	ErrSSOLinkRequired    = errors.New("已存在同名的本地账号，请联系管理员在后台允许绑定单点登录后再登录")
+	ErrInvalidEmail       = errors.New("无效的邮箱")
+	ErrInvalidRole        = errors.New("无效的角色，可选值: admin, editor, analyst")
+)
+
+// dummyHash 用于用户不存在时执行一次等价的哈希比较，避免通过响应时间枚举用户名
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("mblog-dummy-password"), bcrypt.DefaultCost)
+
+// UserService 处理用户账号的服务
+type UserService struct {
+	db *sql.DB
+}
+
+// NewUserService 创建新的用户服务
+func NewUserService(db *sql.DB) *UserService {
+	return &UserService{
+		db: db,
+	}
+}
+
+// HashPassword 使用 bcrypt 计算密码哈希
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", ErrPasswordTooShort
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// validateUsername 校验用户名格式
+func validateUsername(username string) error {
+	if len(username) < 3 || len(username) > 64 {
+		return ErrInvalidUsername
+	}
+	for _, r := range username {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '_' || r == '.' || r == '-' || r == '@':
+		default:
+			return ErrInvalidUsername
+		}
+	}
+	return nil
+}
+
+const userColumns = `id, username, display_name, role, password_hash, disabled,
+	totp_enabled, totp_secret, totp_last_step, created_at, updated_at, last_login_at, email, oidc_subject,
+	sso_managed, sso_link_email, sso_link_expires_at`
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*User, error) {
+	var u User
+	var lastLogin sql.NullTime
+	var subject sql.NullString
+	var linkExpires sql.NullTime
+	err := row.Scan(&u.ID, &u.Username, &u.DisplayName, &u.Role, &u.PasswordHash, &u.Disabled,
+		&u.TOTPEnabled, &u.TOTPSecret, &u.TOTPLastStep, &u.CreatedAt, &u.UpdatedAt, &lastLogin,
+		&u.Email, &subject, &u.SSOManaged, &u.SSOLinkEmail, &linkExpires)
+	if err != nil {
+		return nil, err
+	}
+	if lastLogin.Valid {
+		u.LastLoginAt = &lastLogin.Time
+	}
+	if linkExpires.Valid {
+		u.SSOLinkExpiresAt = &linkExpires.Time
+	}
+	u.OIDCSubject = subject.String
+	u.SSO = subject.Valid
+	return &u, nil
+}
+
+// CreateUser 创建用户
+func (us *UserService) CreateUser(username, password, displayName, role string) (*User, error) {
+	username = strings.TrimSpace(username)
+	if err := validateUsername(username); err != nil {
+		return nil, err
+	}
+	if !ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	if displayName == "" {
+		displayName = username
+	}
+
+	now := time.Now()
+	row := us.db.QueryRow(`
+		INSERT INTO users(username, display_name, role, password_hash, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, $5)
+		RETURNING `+userColumns,
+		username, displayName, role, hash, now)
+	user, err := scanUser(row)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, ErrUsernameTaken
+		}
+		log.Printf("创建用户失败: %v", err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserByID 根据ID获取用户
+func (us *UserService) GetUserByID(id int64) (*User, error) {
+	user, err := scanUser(us.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// GetUserByUsername 根据用户名获取用户
+func (us *UserService) GetUserByUsername(username string) (*User, error) {
+	user, err := scanUser(us.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE username = $1`, username))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// ListUsers 获取所有用户
+func (us *UserService) ListUsers() ([]User, error) {
+	rows, err := us.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.Printf("扫描用户数据失败: %v", err)
+			continue
+		}
+		result = append(result, *user)
+	}
+	return result, rows.Err()
+}
+
+// UpdateUser 更新用户信息，请求中为 nil 的字段保持不变。
+// 修改角色、密码或禁用账号时同时注销该用户的全部会话，已签发的访问令牌和刷新令牌随即失效，需重新登录
+func (us *UserService) UpdateUser(id int64, req UpdateUserRequest) (*User, error) {
+	user, err := us.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	revokeSessions := false
+	if req.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Role != nil {
+		if !ValidRole(*req.Role) {
+			return nil, ErrInvalidRole
+		}
+		revokeSessions = revokeSessions || user.Role != *req.Role
+		user.Role = *req.Role
+	}
+	if req.Disabled != nil {
+		revokeSessions = revokeSessions || *req.Disabled && !user.Disabled
+		user.Disabled = *req.Disabled
+	}
+	if req.Password != nil {
+		hash, err := HashPassword(*req.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+		revokeSessions = true
+	}
+
+	tx, err := us.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	user.UpdatedAt = time.Now()
+	_, err = tx.Exec(`
+		UPDATE users SET display_name = $1, role = $2, password_hash = $3, disabled = $4, updated_at = $5
+		WHERE id = $6
+	`, user.DisplayName, user.Role, user.PasswordHash, user.Disabled, user.UpdatedAt, id)
+	if err != nil {
+		log.Printf("更新用户失败: %v", err)
+		return nil, err
+	}
+	if revokeSessions {
+		if err := revokeUserSessions(tx, id, user.UpdatedAt); err != nil {
+			log.Printf("注销用户会话失败: %v", err)
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// revokeUserSessions 注销用户的全部会话，与用户信息的修改在同一事务中完成
+func revokeUserSessions(tx *sql.Tx, userID int64, now time.Time) error {
+	result, err := tx.Exec("UPDATE auth_sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		now, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		log.Printf("用户 %d 的角色、密码或状态已变更，注销了 %d 个会话", userID, n)
+	}
+	return nil
+}
+
+// DeleteUser 删除用户
+func (us *UserService) DeleteUser(id int64) error {
+	result, err := us.db.Exec("DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		log.Printf("删除用户失败: %v", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// CountUsers 返回用户总数
+func (us *UserService) CountUsers() (int, error) {
+	var count int
+	err := us.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	return count, err
+}
+
+// Authenticate 校验用户名和密码，成功时返回用户
+// 启用两步验证的用户还需调用 VerifySecondFactor，全部通过后再调用 RecordLogin
+func (us *UserService) Authenticate(username, password string) (*User, error) {
+	user, err := us.GetUserByUsername(strings.TrimSpace(username))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	return user, nil
+}
+
+// RecordLogin 记录用户最近登录时间
+func (us *UserService) RecordLogin(user *User) {
+	now := time.Now()
+	if _, err := us.db.Exec("UPDATE users SET last_login_at = $1 WHERE id = $2", now, user.ID); err != nil {
+		log.Printf("更新最近登录时间失败: %v", err)
+	}
+	user.LastLoginAt = &now
+}
+
+// SetAdminPassword 创建管理员或重置已有用户的密码并提升为管理员，用于命令行初始化管理员
+func (us *UserService) SetAdminPassword(username, password string) (*User, error) {
+	user, err := us.GetUserByUsername(username)
+	if errors.Is(err, ErrUserNotFound) {
+		return us.CreateUser(username, password, "", RoleAdmin)
+	}
+	if err != nil {
+		return nil, err
+	}
+	role := RoleAdmin
+	disabled := false
+	return us.UpdateUser(user.ID, UpdateUserRequest{Password: &password, Role: &role, Disabled: &disabled})
+}
+
+// EnsureAdmin 在用户表为空时创建第一个管理员账号
+// 未提供密码时生成随机密码并打印到日志，仅在首次启动时出现一次
+func (us *UserService) EnsureAdmin(username, password string) error {
+	count, err := us.CountUsers()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	generated := false
+	if password == "" {
+		password, err = randomPassword()
+		if err != nil {
+			return err
+		}
+		generated = true
+	}
+
+	user, err := us.CreateUser(username, password, "", RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("创建初始管理员失败: %w", err)
+	}
+
+	if generated {
+		log.Printf("已创建初始管理员 %s，随机密码: %s （请登录后立即修改）", user.Username, password)
+	} else {
+		log.Printf("已根据环境变量创建初始管理员: %s", user.Username)
+	}
+	return nil
+}
+
+// randomPassword 生成随机初始密码
+func randomPassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
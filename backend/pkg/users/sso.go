@@ -0,0 +1,188 @@
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// SSOLinkTTL 管理员允许已有账号绑定单点登录身份后，用户完成绑定的期限
+const SSOLinkTTL = 24 * time.Hour
+
+// ErrSSOAlreadyLinked 账号已绑定单点登录身份
+var ErrSSOAlreadyLinked = errors.New("该账号已绑定单点登录身份，请先解除绑定")
+
+// LoginSSO 根据单点登录身份查找或创建本地账号
+// 查找顺序：已绑定该身份的账号 -> 管理员允许绑定该邮箱且未过期的账号（完成绑定）-> 新建账号。
+// 用户名等于邮箱的已有本地账号不会自动绑定，否则身份提供方中同邮箱的用户可以接管该账号（包括初始管理员）。
+// 只有通过单点登录创建的账号每次登录按用户组同步角色和显示名称，管理员绑定的账号保留本地角色。
+// 通过单点登录创建的账号使用随机密码，无法用密码登录
+func (us *UserService) LoginSSO(subject, email, displayName, role string) (*User, error) {
+	if !ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if displayName == "" {
+		displayName = email
+	}
+
+	user, err := scanUser(us.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE oidc_subject = $1`, subject))
+	if errors.Is(err, sql.ErrNoRows) {
+		return us.linkOrCreateSSOUser(subject, email, displayName, role)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	if !user.SSOManaged {
+		displayName, role = user.DisplayName, user.Role
+	}
+	now := time.Now()
+	_, err = us.db.Exec(`
+		UPDATE users SET email = $1, display_name = $2, role = $3, updated_at = $4
+		WHERE id = $5
+	`, email, displayName, role, now, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != role {
+		log.Printf("单点登录同步角色: %s %s -> %s", user.Username, user.Role, role)
+	}
+	user.Email, user.DisplayName, user.Role, user.UpdatedAt = email, displayName, role, now
+	return user, nil
+}
+
+// linkOrCreateSSOUser 首次使用该身份登录：完成管理员允许的绑定，或创建新账号
+func (us *UserService) linkOrCreateSSOUser(subject, email, displayName, role string) (*User, error) {
+	now := time.Now()
+	user, err := scanUser(us.db.QueryRow(`
+		SELECT `+userColumns+` FROM users
+		WHERE sso_link_email = $1 AND sso_link_expires_at > $2 AND oidc_subject IS NULL
+		ORDER BY id LIMIT 1`, email, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		existing, err := us.GetUserByUsername(email)
+		if errors.Is(err, ErrUserNotFound) {
+			return us.createSSOUser(subject, email, displayName, role)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if existing.SSO {
+			return nil, ErrSSOIdentityTaken
+		}
+		log.Printf("[安全] 单点登录身份 %s 与本地账号 %s 同名，未经管理员允许，拒绝绑定", email, existing.Username)
+		return nil, ErrSSOLinkRequired
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	result, err := us.db.Exec(`
+		UPDATE users SET oidc_subject = $1, email = $2, sso_link_email = '', sso_link_expires_at = NULL, updated_at = $3
+		WHERE id = $4 AND oidc_subject IS NULL
+	`, subject, email, now, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrSSOIdentityTaken
+	}
+	log.Printf("账号 %s 已按管理员的允许绑定单点登录身份 %s", user.Username, email)
+	user.OIDCSubject, user.SSO, user.Email, user.UpdatedAt = subject, true, email, now
+	user.SSOLinkEmail, user.SSOLinkExpiresAt = "", nil
+	return user, nil
+}
+
+// createSSOUser 为首次通过单点登录的用户创建账号
+func (us *UserService) createSSOUser(subject, email, displayName, role string) (*User, error) {
+	password, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+	user, err := us.CreateUser(email, password, displayName, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := us.db.Exec("UPDATE users SET oidc_subject = $1, email = $2, sso_managed = TRUE WHERE id = $3",
+		subject, email, user.ID); err != nil {
+		us.DeleteUser(user.ID)
+		return nil, err
+	}
+	log.Printf("通过单点登录创建账号: %s (%s)", user.Username, role)
+	user.OIDCSubject, user.SSO, user.SSOManaged, user.Email = subject, true, true, email
+	return user, nil
+}
+
+// AllowSSOLink 允许已有账号在 SSOLinkTTL 内绑定邮箱为 email 的单点登录身份，email 为空时使用用户名。
+// 该邮箱的用户下次通过单点登录时完成绑定，之后保留账号原有的角色
+func (us *UserService) AllowSSOLink(id int64, email string) (*User, error) {
+	user, err := us.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.SSO {
+		return nil, ErrSSOAlreadyLinked
+	}
+
+	if email == "" {
+		email = user.Username
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if local, domain, ok := strings.Cut(email, "@"); !ok || local == "" || domain == "" || len(email) > 255 {
+		return nil, ErrInvalidEmail
+	}
+
+	now := time.Now()
+	expires := now.Add(SSOLinkTTL)
+	if _, err := us.db.Exec(`
+		UPDATE users SET sso_link_email = $1, sso_link_expires_at = $2, updated_at = $3 WHERE id = $4
+	`, email, expires, now, id); err != nil {
+		return nil, err
+	}
+	user.SSOLinkEmail, user.SSOLinkExpiresAt, user.UpdatedAt = email, &expires, now
+	return user, nil
+}
+
+// UnlinkSSO 解除账号的单点登录绑定并取消待绑定的请求，同时注销该账号的全部会话。
+// 单点登录创建的账号没有可用的密码，解除后需由管理员设置密码才能登录
+func (us *UserService) UnlinkSSO(id int64) (*User, error) {
+	user, err := us.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := us.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec(`
+		UPDATE users SET oidc_subject = NULL, sso_managed = FALSE, sso_link_email = '', sso_link_expires_at = NULL,
+			updated_at = $1
+		WHERE id = $2
+	`, now, id); err != nil {
+		return nil, err
+	}
+	if user.SSO {
+		if err := revokeUserSessions(tx, id, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	user.OIDCSubject, user.SSO, user.SSOManaged, user.UpdatedAt = "", false, false, now
+	user.SSOLinkEmail, user.SSOLinkExpiresAt = "", nil
+	return user, nil
+}
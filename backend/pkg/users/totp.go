@@ -0,0 +1,295 @@
+package users
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RFC 6238 TOTP 参数，与 Google Authenticator 等主流应用的默认值一致
+const (
+	totpPeriod = 30 // 时间步长（秒）
+	totpDigits = 6  // 验证码位数
+	totpSkew   = 1  // 允许前后各偏差一个时间步，兼容客户端时钟误差
+	// TOTPIssuer 显示在验证器应用中的签发方名称
+	TOTPIssuer = "MBlog"
+	// recoveryCodeCount 每次启用两步验证生成的恢复码数量
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTOTPNotEnabled     = errors.New("未启用两步验证")
+	ErrTOTPAlreadyEnabled = errors.New("已启用两步验证")
+	ErrTOTPNotSetup       = errors.New("请先生成两步验证密钥")
+	ErrInvalidTOTPCode    = errors.New("验证码错误或已使用")
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret 生成 160 位随机 TOTP 密钥（Base32 编码）
+func generateTOTPSecret() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// totpCode 计算指定时间步的验证码（HMAC-SHA1，RFC 4226 动态截断）
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// validateTOTP 校验验证码，返回匹配的时间步；早于或等于 lastStep 的时间步视为重放
+func validateTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for delta := int64(-totpSkew); delta <= totpSkew; delta++ {
+		step := current + delta
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// ProvisioningURI 生成 otpauth:// 链接，前端可将其渲染为二维码供验证器应用扫描
+func ProvisioningURI(username, secret string) string {
+	label := url.PathEscape(TOTPIssuer + ":" + username)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", TOTPIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// hashRecoveryCode 计算恢复码哈希，忽略大小写和分隔符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRecoveryCode 生成形如 XXXXX-XXXXX 的恢复码
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := totpEncoding.EncodeToString(buf)[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// BeginTOTPSetup 为用户生成待确认的 TOTP 密钥，返回密钥和供扫码的 otpauth 链接
+func (us *UserService) BeginTOTPSetup(userID int64) (string, string, error) {
+	user, err := us.GetUserByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user.TOTPEnabled {
+		return "", "", ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := us.db.Exec("UPDATE users SET totp_secret = $1, totp_last_step = 0 WHERE id = $2", secret, userID); err != nil {
+		return "", "", err
+	}
+	return secret, ProvisioningURI(user.Username, secret), nil
+}
+
+// EnableTOTP 校验首个验证码后正式启用两步验证，返回一次性展示的恢复码
+func (us *UserService) EnableTOTP(userID int64, code string) ([]string, error) {
+	user, err := us.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotSetup
+	}
+
+	step, ok := validateTOTP(user.TOTPSecret, code, time.Now(), 0)
+	if !ok {
+		return nil, ErrInvalidTOTPCode
+	}
+
+	tx, err := us.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE users SET totp_enabled = TRUE, totp_last_step = $1, updated_at = $2 WHERE id = $3",
+		step, time.Now(), userID); err != nil {
+		return nil, err
+	}
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("用户 %s 已启用两步验证", user.Username)
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (us *UserService) RegenerateRecoveryCodes(userID int64) ([]string, error) {
+	user, err := us.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTOTPNotEnabled
+	}
+
+	tx, err := us.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return codes, tx.Commit()
+}
+
+// replaceRecoveryCodes 删除旧恢复码并写入新生成的恢复码
+func replaceRecoveryCodes(tx *sql.Tx, userID int64) ([]string, error) {
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("INSERT INTO user_recovery_codes(user_id, code_hash) VALUES($1, $2)",
+			userID, hashRecoveryCode(code)); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// DisableTOTP 关闭两步验证并删除密钥和恢复码
+func (us *UserService) DisableTOTP(userID int64) error {
+	tx, err := us.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET totp_enabled = FALSE, totp_secret = '', totp_last_step = 0, updated_at = $1
+		WHERE id = $2
+	`, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// VerifySecondFactor 校验登录第二步，code 可以是 TOTP 验证码或一次性恢复码
+func (us *UserService) VerifySecondFactor(userID int64, code string) error {
+	user, err := us.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTOTPNotEnabled
+	}
+
+	if step, ok := validateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		// 条件更新，防止同一验证码在并发请求中被使用两次
+		result, err := us.db.Exec("UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1", step, userID)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return ErrInvalidTOTPCode
+		}
+		return nil
+	}
+
+	// 尝试作为恢复码使用
+	result, err := us.db.Exec(`
+		UPDATE user_recovery_codes SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+	`, time.Now(), userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrInvalidTOTPCode
+	}
+	log.Printf("用户 %s 使用恢复码完成两步验证", user.Username)
+	return nil
+}
+
+// RemainingRecoveryCodes 返回用户未使用的恢复码数量
+func (us *UserService) RemainingRecoveryCodes(userID int64) (int, error) {
+	var count int
+	err := us.db.QueryRow("SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL", userID).Scan(&count)
+	return count, err
+}
@@ -5,6 +5,7 @@ const iconPaths = {
     chart: "M9 19v-6a2 2 0 00-2-2H5a2 2 0 00-2 2v6a2 2 0 002 2h2a2 2 0 002-2zm0 0V9a2 2 0 012-2h2a2 2 0 012 2v10m-6 0a2 2 0 002 2h2a2 2 0 002-2m0 0V5a2 2 0 012-2h2a2 2 0 012 2v14a2 2 0 01-2 2h-2a2 2 0 01-2-2z",
     menu: "M4 6h16M4 12h16M4 18h16",
     search: "M21 21l-6-6m2-5a7 7 0 11-14 0 7 7 0 0114 0z",
+    key: "M15 7a2 2 0 012 2m4 0a6 6 0 01-7.743 5.743L11 17H9v2H7v2H4a1 1 0 01-1-1v-2.586a1 1 0 01.293-.707l5.964-5.964A6 6 0 1121 9z",
 
     // 操作
     download: "M4 16v1a3 3 0 003 3h10a3 3 0 003-3v-1m-4-8l-4-4m0 0L8 8m4-4v12", // 用于导入
@@ -22,6 +23,21 @@ const iconPaths = {
     upload: "M28 8H12a4 4 0 00-4 4v20m32-12v8m0 0v8a4 4 0 01-4 4H12a4 4 0 01-4-4v-4m32-4l-3.172-3.172a4 4 0 00-5.656 0L28 28M8 32l9.172-9.172a4 4 0 015.656 0L28 28m0 0l4 4m4-24h8m-4-4v8m-12 4h.02" // Upload dashed box icon
 };
 
+// 读取登录时下发的 CSRF 令牌，修改类请求需放在 X-CSRF-Token 请求头中
+function csrfToken() {
+    const match = document.cookie.match(/(?:^|;\s*)mblog_csrf=([^;]*)/);
+    return match ? decodeURIComponent(match[1]) : '';
+}
+
+// 带登录 Cookie 和 CSRF 令牌的请求
+function apiFetch(url, options = {}) {
+    return fetch(url, {
+        ...options,
+        credentials: 'include',
+        headers: { ...(options.headers || {}), 'X-CSRF-Token': csrfToken() }
+    });
+}
+
 function adminApp() {
     return {
         // --- 核心数据 ---
@@ -38,6 +54,16 @@ function adminApp() {
             files: []
         },
 
+        // API 令牌
+        tokens: [],
+        tokenScopes: [],
+        tokenForm: {
+            name: '',
+            scopes: [],
+            expiresInDays: 90
+        },
+        newToken: '',
+
         modal: {
             show: false,
             type: 'new',
@@ -82,7 +108,7 @@ function adminApp() {
 
         rebuildSite() {
             this.isBuilding = true;
-            fetch('/api/build', { method: 'POST', credentials: 'include' })
+            apiFetch('/api/build', { method: 'POST' })
                 .then(res => {
                     setTimeout(() => {
                         this.isBuilding = false;
@@ -134,10 +160,9 @@ function adminApp() {
                 fullFilename = `${this.modal.directory}/${this.modal.filename}`;
             }
 
-            fetch('/api/files', {
+            apiFetch('/api/files', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
-                credentials: 'include',
                 body: JSON.stringify({
                     filename: fullFilename,
                     content: this.modal.content,
@@ -154,7 +179,7 @@ function adminApp() {
 
         deleteFile(file) {
             if (!confirm(`确定要删除文件 ${file} 吗？`)) return;
-            fetch(`/api/files/${file}`, { method: 'DELETE', credentials: 'include' })
+            apiFetch(`/api/files/${file}`, { method: 'DELETE' })
                 .then(res => {
                     if (res.ok) {
                         this.showToastMsg('文件删除成功');
@@ -163,6 +188,72 @@ function adminApp() {
                 });
         },
 
+        // --- API 令牌 ---
+        openTokensView() {
+            this.currentView = 'tokens';
+            this.newToken = '';
+            this.loadTokens();
+        },
+
+        loadTokens() {
+            fetch('/api/account/tokens', { credentials: 'include' })
+                .then(res => {
+                    if (res.status === 401) {
+                        window.location.href = '/login';
+                        return null;
+                    }
+                    return res.json();
+                })
+                .then(data => {
+                    if (!data) return;
+                    this.tokens = data.tokens || [];
+                    this.tokenScopes = data.scopes || [];
+                })
+                .catch(() => this.showToastMsg('加载令牌列表失败'));
+        },
+
+        createToken() {
+            if (!this.tokenForm.name) { alert('请输入令牌名称'); return; }
+            if (this.tokenForm.scopes.length === 0) { alert('请至少选择一个权限'); return; }
+
+            apiFetch('/api/account/tokens', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({
+                    name: this.tokenForm.name,
+                    scopes: this.tokenForm.scopes,
+                    expires_in_days: Number(this.tokenForm.expiresInDays) || 0
+                })
+            })
+                .then(res => res.json().then(data => ({ ok: res.ok, data })))
+                .then(({ ok, data }) => {
+                    if (!ok) {
+                        this.showToastMsg(data.error || '创建令牌失败');
+                        return;
+                    }
+                    this.newToken = data.token;
+                    this.tokenForm.name = '';
+                    this.tokenForm.scopes = [];
+                    this.loadTokens();
+                })
+                .catch(() => this.showToastMsg('创建令牌失败'));
+        },
+
+        revokeToken(token) {
+            if (!confirm(`确定要注销令牌 ${token.name} 吗？使用该令牌的脚本将无法继续访问。`)) return;
+            apiFetch(`/api/account/tokens/${token.id}`, { method: 'DELETE' })
+                .then(res => {
+                    if (res.ok) {
+                        this.showToastMsg('令牌已注销');
+                        this.loadTokens();
+                    } else this.showToastMsg('注销失败');
+                });
+        },
+
+        formatTime(value) {
+            return value ? new Date(value).toLocaleString('zh-CN') : '-';
+        },
+
         // --- 导入逻辑 ---
         openImportModal() { this.importModal.show = true; },
         closeImportModal() { this.importModal.show = false; this.importModal.files = []; },
@@ -191,7 +282,7 @@ function adminApp() {
 
             this.showToastMsg('正在上传...');
 
-            fetch('/api/upload', { method: 'POST', body: formData, credentials: 'include' })
+            apiFetch('/api/upload', { method: 'POST', body: formData })
                 .then(res => res.json())
                 .then(data => {
                     if (data.failed > 0) {
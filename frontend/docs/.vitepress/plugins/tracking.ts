@@ -65,95 +65,6 @@ export interface TrackingOptions {
   };
 }
 
-// 设备指纹生成
-function generateFingerprint(): string {
-  if (!isBrowser) return 'server-side-rendering'
-
-  try {
-
-
-    // 收集更多设备信息提高唯一性
-    const components = [
-      navigator.userAgent || 'unknown',
-      `${screen.width || 0}x${screen.height || 0}`,
-      screen.colorDepth || 0,
-      new Date().getTimezoneOffset(),
-      navigator.language || 'unknown',
-      navigator.hardwareConcurrency || 0,
-      navigator.platform || 'unknown',
-      navigator.cookieEnabled ? '1' : '0',
-      navigator.doNotTrack || 'unknown',
-      // 添加更多唯一标识
-      navigator.vendor || 'unknown',
-      navigator.productSub || 'unknown',
-      navigator.maxTouchPoints || 0,
-      // 添加内存信息（如果可用）
-      // @ts-ignore
-      navigator.deviceMemory || 'unknown',
-      // 添加连接信息（如果可用）
-      // @ts-ignore
-      navigator.connection?.type || 'unknown',
-      // 添加随机种子
-      Math.random().toString(36).substring(2) + Date.now().toString(36)
-    ];
-
-
-
-    // 生成指纹
-    const rawFingerprint = components.join('|');
-
-
-    const hash = hashCode(rawFingerprint);
-
-
-    // 确保生成的指纹是正数且为16进制
-    const fingerprint = Math.abs(hash).toString(16);
-
-
-    // 验证指纹有效性
-    if (!fingerprint || fingerprint.length < 8) {
-      throw new Error('生成的指纹无效');
-    }
-
-    return fingerprint;
-  } catch (error) {
-    console.error('[Tracker Error] 生成设备指纹失败:', error);
-
-    // 使用备用方案生成指纹
-    const timestamp = Date.now();
-    const random = Math.random();
-    const fallbackComponents = [
-      timestamp.toString(36),
-      random.toString(36).substring(2),
-      navigator.userAgent || 'unknown'
-    ];
-
-    const fallbackFingerprint = hashCode(fallbackComponents.join('|')).toString(16);
-
-
-    return fallbackFingerprint;
-  }
-}
-
-// 改进的哈希函数
-function hashCode(str: string): number {
-  let hash = 0;
-  if (!str || str.length === 0) return 1;
-
-  // 使用FNV-1a哈希算法
-  const FNV_PRIME = 16777619;
-  const FNV_OFFSET_BASIS = 2166136261;
-
-  hash = FNV_OFFSET_BASIS;
-  for (let i = 0; i < str.length; i++) {
-    hash ^= str.charCodeAt(i);
-    hash *= FNV_PRIME;
-  }
-
-  // 确保返回正数
-  return Math.abs(hash || 1);
-}
-
 // 生成随机会话ID
 function generate_session_id(): string {
   return Date.now().toString(36) + Math.random().toString(36).substring(2);
@@ -201,56 +112,9 @@ export class Tracker {
     this.log('Tracker初始化 - 完成');
   }
 
-  // 获取或创建设备指纹
+  // 获取或创建设备指纹，设备指纹由本地保存的设备密钥生成
   private getOrCreateFingerprint(): string {
-    if (!isBrowser) return 'server-side-rendering';
-
-    try {
-
-
-      // 尝试从localStorage获取存储的设备指纹
-      const storedFingerprint = localStorage.getItem(this.FINGERPRINT_STORAGE_KEY);
-
-
-      if (storedFingerprint && storedFingerprint.length >= 8) {
-
-        return storedFingerprint;
-      }
-
-      // 生成新的设备指纹
-
-      const fingerprint = generateFingerprint();
-
-      // 验证生成的指纹
-      if (!fingerprint || fingerprint.length < 8) {
-        throw new Error('生成的设备指纹无效');
-      }
-
-      // 验证localStorage是否可用
-      const testKey = '_test_storage_';
-      try {
-        localStorage.setItem(testKey, '1');
-        localStorage.removeItem(testKey);
-      } catch (e) {
-        console.error('[Tracker Error] localStorage不可用:', e);
-        return fingerprint; // 直接返回生成的指纹
-      }
-
-      // 将指纹存储到localStorage
-      try {
-        localStorage.setItem(this.FINGERPRINT_STORAGE_KEY, fingerprint);
-
-      } catch (e) {
-        console.error('[Tracker Error] 保存设备指纹失败:', e);
-      }
-
-      return fingerprint;
-    } catch (e) {
-      console.error('[Tracker Error] 获取/创建设备指纹时出错:', e);
-      // 生成临时指纹
-      const tempFingerprint = Date.now().toString(36) + Math.random().toString(36).substring(2);
-      return tempFingerprint;
-    }
+    return getOrCreateFingerprint(this.FINGERPRINT_STORAGE_KEY);
   }
 
   // 创建新会话
@@ -463,6 +327,8 @@ export class Tracker {
         language: navigator.language || 'unknown',
         hardware_concurrency: navigator.hardwareConcurrency || 0,
         platform: navigator.platform || 'unknown',
+        // 自动化工具控制的浏览器为 true，后端据此识别机器人流量
+        webdriver: navigator.webdriver === true,
       };
     } catch { return {}; }
   }
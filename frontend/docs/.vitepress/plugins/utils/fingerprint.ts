@@ -79,8 +79,86 @@ export function generateFingerprint(): string {
     }
 }
 
+/**
+ * 设备密钥在 localStorage 中的键名
+ * 密钥为 128 位随机数的十六进制，只保存在本地，修改埋点同意状态和删除个人数据时提交给服务端
+ */
+export const DEVICE_SECRET_STORAGE_KEY = 'track_device_secret';
+
+/**
+ * 生成设备密钥和对应的设备指纹
+ * 设备指纹取密钥 SHA-256 的前 32 个十六进制字符，服务端据此确认设备属于请求者
+ */
+export function createDeviceIdentity(): { fingerprint: string; secret: string } {
+    const bytes = new Uint8Array(16);
+    crypto.getRandomValues(bytes);
+    const secret = Array.from(bytes, b => b.toString(16).padStart(2, '0')).join('');
+    return { fingerprint: sha256Hex(secret).substring(0, 32), secret };
+}
+
+const SHA256_K = new Uint32Array([
+    0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+    0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+    0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+    0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+    0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+    0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+    0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+    0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2
+]);
+
+/**
+ * 同步计算 SHA-256，返回十六进制（crypto.subtle 只有异步接口，设备指纹需要在初始化时同步得到）
+ */
+function sha256Hex(message: string): string {
+    const data = new TextEncoder().encode(message);
+    const length = Math.ceil((data.length + 9) / 64) * 64;
+    const padded = new Uint8Array(length);
+    padded.set(data);
+    padded[data.length] = 0x80;
+    const view = new DataView(padded.buffer);
+    view.setUint32(length - 8, Math.floor(data.length / 0x20000000));
+    view.setUint32(length - 4, data.length * 8);
+
+    const h = new Uint32Array([
+        0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19
+    ]);
+    const w = new Uint32Array(64);
+    const rotr = (x: number, n: number) => (x >>> n) | (x << (32 - n));
+
+    for (let offset = 0; offset < length; offset += 64) {
+        for (let i = 0; i < 16; i++) {
+            w[i] = view.getUint32(offset + i * 4);
+        }
+        for (let i = 16; i < 64; i++) {
+            const s0 = rotr(w[i - 15], 7) ^ rotr(w[i - 15], 18) ^ (w[i - 15] >>> 3);
+            const s1 = rotr(w[i - 2], 17) ^ rotr(w[i - 2], 19) ^ (w[i - 2] >>> 10);
+            w[i] = w[i - 16] + s0 + w[i - 7] + s1;
+        }
+
+        let [a, b, c, d, e, f, g, hh] = h;
+        for (let i = 0; i < 64; i++) {
+            const t1 = hh + (rotr(e, 6) ^ rotr(e, 11) ^ rotr(e, 25)) + ((e & f) ^ (~e & g)) + SHA256_K[i] + w[i];
+            const t2 = (rotr(a, 2) ^ rotr(a, 13) ^ rotr(a, 22)) + ((a & b) ^ (a & c) ^ (b & c));
+            hh = g;
+            g = f;
+            f = e;
+            e = (d + t1) >>> 0;
+            d = c;
+            c = b;
+            b = a;
+            a = (t1 + t2) >>> 0;
+        }
+        h[0] += a; h[1] += b; h[2] += c; h[3] += d;
+        h[4] += e; h[5] += f; h[6] += g; h[7] += hh;
+    }
+
+    return Array.from(h, x => x.toString(16).padStart(8, '0')).join('');
+}
+
 /**
  * 从 localStorage 获取或创建设备指纹
+ * 旧版生成的指纹没有对应的设备密钥，会被替换为新的设备指纹
  */
 export function getOrCreateFingerprint(storageKey: string): string {
     if (!isBrowser) return 'server-side-rendering';
@@ -88,20 +166,16 @@ export function getOrCreateFingerprint(storageKey: string): string {
     try {
         // 尝试从 localStorage 获取
         const stored = localStorage.getItem(storageKey);
-        if (stored && stored.length >= 8) {
+        if (stored && stored.length >= 8 && localStorage.getItem(DEVICE_SECRET_STORAGE_KEY)) {
             return stored;
         }
 
-        // 生成新指纹
-        const fingerprint = generateFingerprint();
-
-        // 验证生成的指纹
-        if (!fingerprint || fingerprint.length < 8) {
-            throw new Error('Invalid fingerprint');
-        }
+        // 生成新的设备密钥和指纹
+        const { fingerprint, secret } = createDeviceIdentity();
 
         // 保存到 localStorage
         try {
+            localStorage.setItem(DEVICE_SECRET_STORAGE_KEY, secret);
             localStorage.setItem(storageKey, fingerprint);
         } catch (e) {
             console.error('[Fingerprint] Failed to save to localStorage:', e);